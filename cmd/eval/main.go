@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/eval"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+// eval runs a golden dataset of template inputs through the LLM (or recorded
+// responses), checks the outputs and compares two template versions.
+func main() {
+	fixturesDir := flag.String("fixtures", "eval/fixtures", "directory of <template>/<case>.json fixtures")
+	templatesDir := flag.String("templates", os.Getenv("PROMPT_TEMPLATES_DIR"), "prompt templates directory (default: embedded templates)")
+	template := flag.String("template", "", "only evaluate this template")
+	versionA := flag.String("a", "", "template version to evaluate (default: each template's current version)")
+	versionB := flag.String("b", "", "second template version to compare against -a")
+	responsesDir := flag.String("responses", "", "replay recorded responses from this directory instead of calling the LLM")
+	recordDir := flag.String("record", "", "save LLM responses to this directory for later replay")
+	cassettesDir := flag.String("cassettes", "", "replay raw LLM HTTP exchanges, judge calls included, from this directory (LLMTEST_RECORD=1 records them)")
+	judge := flag.Bool("judge", false, "score outputs with the LLM as a judge")
+	out := flag.String("out", "", "write the report to this file; .json writes JSON (default: markdown on stdout)")
+	failOnRegression := flag.Bool("fail-on-regression", false, "exit with status 1 if -b regresses any case that passes on -a")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	if err := run(*fixturesDir, *templatesDir, models.PromptTemplateEnum(*template), *versionA, *versionB,
+		*responsesDir, *recordDir, *cassettesDir, *judge, *out, *failOnRegression); err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(fixturesDir, templatesDir string, template models.PromptTemplateEnum, versionA, versionB,
+	responsesDir, recordDir, cassettesDir string, judge bool, out string, failOnRegression bool) error {
+	logger, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	registry, err := prompts.NewRegistry(templatesDir, logger)
+	if err != nil {
+		return fmt.Errorf("load templates: %w", err)
+	}
+
+	cases, err := eval.LoadCases(fixturesDir, template)
+	if err != nil {
+		return err
+	}
+
+	// Only build an LLM client when something needs it
+	var client *llm.Client
+	if responsesDir == "" || judge {
+		replaying := cassettesDir != "" && llmtest.ModeFromEnv() == llmtest.Replay
+
+		apiKey := strings.TrimSpace(os.Getenv("LLM_API_KEY"))
+		if apiKey == "" && !replaying {
+			return fmt.Errorf("LLM_API_KEY is required to call the LLM; use -responses or -cassettes to replay recordings")
+		}
+		client = llm.NewClient(apiKey, logger)
+		if url := strings.TrimSpace(os.Getenv("LLM_API_URL")); url != "" {
+			client.SetAPIURL(url)
+		}
+		if cassettesDir != "" {
+			client.SetHTTPClient(llmtest.NewTransport(cassettesDir, llmtest.ModeFromEnv()).Client())
+		}
+	}
+
+	var provider eval.Provider
+	if responsesDir != "" {
+		provider = eval.RecordedProvider{Dir: responsesDir}
+	} else {
+		provider = eval.LLMProvider{Client: client}
+		if recordDir != "" {
+			provider = eval.Recorder{Provider: provider, Dir: recordDir}
+		}
+	}
+
+	var judgeImpl eval.Judge
+	if judge {
+		judgeImpl = eval.LLMJudge{Client: client}
+	}
+
+	versions := []string{versionA}
+	if versionB != "" {
+		versions = append(versions, versionB)
+	}
+
+	report := eval.NewRunner(registry, provider, judgeImpl, logger).Run(cases, versions)
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if filepath.Ext(out) == ".json" {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteMarkdown(w)
+	}
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	if failOnRegression && len(report.Regressions) > 0 {
+		return fmt.Errorf("%d case(s) regressed on %s: %s", len(report.Regressions), versionB, strings.Join(report.Regressions, ", "))
+	}
+	return nil
+}
+
+// newLogger logs warnings and errors only, so the report stays readable on stdout
+func newLogger() (*zap.Logger, error) {
+	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	config.DisableStacktrace = true
+	return config.Build()
+}
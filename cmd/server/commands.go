@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/app"
+	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// configCheck validates the environment, config.ini and prompt templates, printing
+// one line per check
+func configCheck(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("config check", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	logger, err := commandLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("config check failed: %w", err)
+	}
+	fmt.Fprintln(stdout, "ok  environment")
+
+	var problems []string
+	admins := 0
+	for key, service := range cfg.AllowedServices {
+		if service.ClientName == "" || service.SecretHash == "" {
+			problems = append(problems, fmt.Sprintf("service %s needs a client_name and secret_hash", key))
+		}
+		if service.Admin {
+			admins++
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(stdout, "ok  config.ini: %d service(s), %d admin\n", len(cfg.AllowedServices), admins)
+	}
+
+	registry, err := prompts.NewRegistry(cfg.PromptTemplatesDir, logger)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("prompt templates: %v", err))
+	} else {
+		source := "embedded"
+		if cfg.PromptTemplatesDir != "" {
+			source = cfg.PromptTemplatesDir
+		}
+		fmt.Fprintf(stdout, "ok  prompt templates: %d loaded from %s\n", len(registry.Names()), source)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config check failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// render prints the LLM request a generation would send for a request file with the
+// same body as /actions/generate-prompt
+func render(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	templatesDir := flags.String("templates", os.Getenv("PROMPT_TEMPLATES_DIR"), "prompt templates directory (default: embedded templates)")
+	userID := flags.String("user-id", "", "render for this user, as the API would (needs BACKEND_SERVER_API)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: server render [flags] request.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("render takes one request file")
+	}
+
+	logger, err := commandLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	var req models.PromptGenerationRequest
+	if err := readJSONFile(flags.Arg(0), &req); err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	registry, err := prompts.NewRegistry(*templatesDir, logger)
+	if err != nil {
+		return fmt.Errorf("load templates: %w", err)
+	}
+
+	var backendClient *clients.BackendClient
+	if *userID != "" {
+		cfg := config.LoadEnv()
+		if cfg.BackendServerAPI == "" {
+			return fmt.Errorf("BACKEND_SERVER_API environment variable is required with -user-id")
+		}
+		backendClient = clients.NewBackendClient(cfg.BackendServerAPI, logger)
+	}
+
+	// The request is only built, never sent, so no API key is needed
+	svc := service.New(llm.NewClient("", logger), backendClient, registry, nil, nil, logger)
+	data, err := svc.RenderTemplate(context.Background(), req, *userID)
+	if err != nil {
+		return fmt.Errorf("render %s: %w", req.TemplateName, err)
+	}
+	return writeJSON(stdout, data)
+}
+
+// complete prints a completion for the prompt read from stdin
+func complete(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("complete", flag.ContinueOnError)
+	system := flags.String("system", "", "system prompt to add to the base system messages")
+	outputFormat := flags.String("format", "", "convert the completion to markdown, html or text (default: as written)")
+	temperature := flags.Float64("temperature", llm.DefaultOptions().Temperature, "sampling temperature")
+	maxTokens := flags.Int("max-tokens", llm.DefaultOptions().MaxTokens, "maximum tokens to generate")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var to models.OutputFormatEnum
+	if *outputFormat != "" {
+		var err error
+		if to, err = format.Parse(*outputFormat); err != nil {
+			return err
+		}
+	}
+
+	prompt, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("read prompt: %w", err)
+	}
+	if strings.TrimSpace(string(prompt)) == "" {
+		return fmt.Errorf("no prompt on stdin")
+	}
+
+	logger, err := commandLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	cfg := config.LoadEnv()
+	if cfg.CerebrasAPIKey == "" {
+		return fmt.Errorf("LLM_API_KEY environment variable is required")
+	}
+
+	// Completions need no backend or templates; the generation is recorded and dropped
+	svc := service.New(app.NewLLMClient(cfg, logger), nil, nil, generations.NewStore(1, logger), nil, logger)
+	data, err := svc.Complete(context.Background(), service.CompleteInput{
+		Text:   string(prompt),
+		Format: to,
+		System: *system,
+		Params: prompts.Params{Temperature: temperature, MaxTokens: maxTokens},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, data.Completion)
+	return err
+}
+
+// categorize reads one job per line ({"public_id": ..., "description": ...}) and
+// writes one categorized job per line
+func categorize(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("categorize", flag.ContinueOnError)
+	out := flags.String("out", "", "write results to this file (default: stdout)")
+	noCache := flags.Bool("no-cache", false, "skip the LLM response cache")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: server categorize [flags] jobs.jsonl")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("categorize takes one JSONL file")
+	}
+
+	logger, err := commandLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	cfg := config.LoadEnv()
+	if cfg.CerebrasAPIKey == "" {
+		return fmt.Errorf("LLM_API_KEY environment variable is required")
+	}
+	if cfg.BackendServerAPI == "" {
+		return fmt.Errorf("BACKEND_SERVER_API environment variable is required")
+	}
+
+	in, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	// Categorization needs no templates or generation store
+	svc := service.New(app.NewLLMClient(cfg, logger), clients.NewBackendClient(cfg.BackendServerAPI, logger), nil, nil, nil, logger)
+	encoder := json.NewEncoder(w)
+
+	// Categories are fetched once and shared by every line
+	categories, err := svc.JobCategories()
+	if err != nil {
+		return err
+	}
+
+	// Jobs are categorized as they are read, so results stream out for large files
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var job models.JobData
+		if err := json.Unmarshal([]byte(text), &job); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := binding.Validator.ValidateStruct(&job); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		results, err := svc.CategorizeJobs(context.Background(), service.CategorizeInput{Jobs: []models.JobData{job}, Categories: categories, NoCache: *noCache})
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := encoder.Encode(results[0]); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readJSONFile(path string, v interface{}) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
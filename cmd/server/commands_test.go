@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/clients/backendtest"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"deploy"}, nil, &out); err == nil || !strings.Contains(err.Error(), `unknown command "deploy"`) {
+		t.Errorf("run(deploy) error = %v", err)
+	}
+	if err := run([]string{"config", "show"}, nil, &out); err == nil {
+		t.Error("expected an error for an unknown config command")
+	}
+	if err := run([]string{"help"}, nil, &out); err != nil || !strings.Contains(out.String(), "config check") {
+		t.Errorf("run(help) = %q, %v", out.String(), err)
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	for _, key := range []string{"LLM_API_KEY", "BACKEND_SERVER_API", "X_SERVICE_KEY_NAME", "X_SERVICE_CLIENT_NAME", "X_SERVICE_SECRET_NAME", "PROMPT_TEMPLATES_DIR"} {
+		t.Setenv(key, "")
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"config", "check"}, nil, &out); err == nil || !strings.Contains(err.Error(), "LLM_API_KEY") {
+		t.Fatalf("config check without env error = %v", err)
+	}
+
+	t.Setenv("LLM_API_KEY", "key")
+	t.Setenv("BACKEND_SERVER_API", "http://backend")
+	t.Setenv("X_SERVICE_KEY_NAME", "X-Service-Key")
+	t.Setenv("X_SERVICE_CLIENT_NAME", "X-Client-Name")
+	t.Setenv("X_SERVICE_SECRET_NAME", "X-Secret-Hash")
+
+	// config.ini is read from the working directory
+	dir := t.TempDir()
+	ini := "[SERVICE_WEB]\nhost = https://dokoola.com\nclient_name = web\nsecret_hash = s3cret\n\n[SERVICE_OPS]\nclient_name = ops\nadmin = true\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.ini"), []byte(ini), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	out.Reset()
+	err := run([]string{"config", "check"}, nil, &out)
+	if err == nil || !strings.Contains(err.Error(), "service OPS needs a client_name and secret_hash") {
+		t.Fatalf("config check error = %v", err)
+	}
+	if !strings.Contains(out.String(), "ok  prompt templates") {
+		t.Errorf("templates should still be checked:\n%s", out.String())
+	}
+
+	ini = strings.Replace(ini, "admin = true", "secret_hash = ops\nadmin = true", 1)
+	if err := os.WriteFile(filepath.Join(dir, "config.ini"), []byte(ini), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := run([]string{"config", "check"}, nil, &out); err != nil {
+		t.Fatalf("config check error = %v", err)
+	}
+	if !strings.Contains(out.String(), "ok  config.ini: 2 service(s), 1 admin") {
+		t.Errorf("output:\n%s", out.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Setenv("PROMPT_TEMPLATES_DIR", "")
+	path := writeFile(t, "bio.json", `{"template_name": "talent_bio", "tone": "warm", "length": "short",
+		"data": {"profile": {"name": "Ada Obi", "title": "Backend Engineer"}}}`)
+
+	var out bytes.Buffer
+	if err := run([]string{"render", path}, nil, &out); err != nil {
+		t.Fatalf("render error = %v", err)
+	}
+
+	var data models.RenderedPromptData
+	if err := json.Unmarshal(out.Bytes(), &data); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	if data.TemplateName != models.PromptTalentBio || data.Tone != models.TuneWarm || data.EstimatedTokens == 0 {
+		t.Errorf("data = %+v", data)
+	}
+	if last := data.Messages[len(data.Messages)-1]; last.Role != "user" || !strings.Contains(last.Content, "Ada Obi") {
+		t.Errorf("last message = %+v", last)
+	}
+
+	invalid := writeFile(t, "invalid.json", `{"template_name": "talent_bio", "data": {"profile": {"name": "Ada"}}}`)
+	if err := run([]string{"render", invalid}, nil, &out); err == nil || !strings.Contains(err.Error(), "profile.title") {
+		t.Errorf("render invalid data error = %v", err)
+	}
+
+	// A user ID renders in the user's context, so it needs the backend
+	t.Setenv("BACKEND_SERVER_API", "")
+	if err := run([]string{"render", "-user-id", backendtest.TalentID, path}, nil, &out); err == nil || !strings.Contains(err.Error(), "BACKEND_SERVER_API") {
+		t.Errorf("render -user-id without a backend error = %v", err)
+	}
+	backend := backendtest.NewServer(t)
+	t.Setenv("BACKEND_SERVER_API", backend.URL())
+	out.Reset()
+	if err := run([]string{"render", "-user-id", backendtest.TalentID, path}, nil, &out); err != nil {
+		t.Fatalf("render -user-id error = %v", err)
+	}
+	if requests := backend.Requests(); len(requests) != 1 {
+		t.Errorf("expected the user to be fetched, got %v", requests)
+	}
+
+	badTone := writeFile(t, "tone.json", `{"template_name": "talent_bio", "tone": "sarcastic", "data": {}}`)
+	if err := run([]string{"render", badTone}, nil, &out); err == nil {
+		t.Error("expected an error for an unknown tone")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	server := llmtest.NewServer(t)
+	server.Enqueue(llmtest.Reply("**Hello** there"))
+	t.Setenv("LLM_API_KEY", "key")
+	t.Setenv("LLM_API_URL", server.URL())
+
+	var out bytes.Buffer
+	if err := run([]string{"complete", "-format", "text", "-system", "Be brief.", "-temperature", "0.2", "-max-tokens", "64"}, strings.NewReader("Say hello\n"), &out); err != nil {
+		t.Fatalf("complete error = %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "Hello there" {
+		t.Errorf("completion = %q", got)
+	}
+
+	req := server.Requests()[0]
+	if req.UserPrompt() != "Say hello\n" || req.Temperature != 0.2 || req.MaxTokens != 64 {
+		t.Errorf("request = %+v", req)
+	}
+	system := false
+	for _, m := range req.Messages {
+		system = system || (m.Role == "system" && strings.Contains(m.Content, "Be brief."))
+	}
+	if !system {
+		t.Errorf("system prompt not sent: %+v", req.Messages)
+	}
+
+	if err := run([]string{"complete"}, strings.NewReader("  "), &out); err == nil {
+		t.Error("expected an error for an empty prompt")
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	llm := llmtest.NewServer(t)
+	llm.Respond(func(req llmtest.Request) llmtest.Response {
+		if strings.Contains(req.UserPrompt(), "logo") {
+			return llmtest.Reply("graphic-design")
+		}
+		return llmtest.Reply("backend")
+	})
+	backend := backendtest.NewServer(t)
+	t.Setenv("LLM_API_KEY", "key")
+	t.Setenv("LLM_API_URL", llm.URL())
+	t.Setenv("BACKEND_SERVER_API", backend.URL())
+
+	path := writeFile(t, "jobs.jsonl", `{"public_id": "job-1", "description": "Build a REST API"}
+
+{"public_id": "job-2", "description": "Design a logo"}
+`)
+	var out bytes.Buffer
+	if err := run([]string{"categorize", path}, nil, &out); err != nil {
+		t.Fatalf("categorize error = %v", err)
+	}
+
+	want := `{"public_id":"job-1","category":"backend"}` + "\n" + `{"public_id":"job-2","category":"graphic-design"}` + "\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	invalid := writeFile(t, "invalid.jsonl", `{"public_id": "job-1"}`)
+	if err := run([]string{"categorize", invalid}, nil, &out); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("categorize invalid error = %v", err)
+	}
+}
@@ -2,32 +2,87 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/app"
 	"github.com/dokoola/llm-go/internal/config"
-	"github.com/dokoola/llm-go/internal/handlers"
-	"github.com/dokoola/llm-go/internal/llm"
-	"github.com/dokoola/llm-go/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
+const usage = `Usage: server <command> [flags]
+
+Commands:
+  serve          Run the HTTP and gRPC servers (default)
+  config check   Validate the environment, config.ini and prompt templates
+  render         Render a template from a JSON request file
+  complete       Print a one-shot completion for a prompt read from stdin
+  categorize     Categorize a JSONL file of jobs
+
+Run "server <command> -h" for a command's flags.
+`
+
 func main() {
 	// Load .env file if it exists (ignore error if file doesn't exist)
 	_ = godotenv.Load()
 
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to a subcommand. With no command, or only flags, it serves.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "serve":
+		return serve(args)
+	case "config":
+		if len(args) == 0 || args[0] != "check" {
+			return fmt.Errorf("unknown config command; expected \"config check\"")
+		}
+		return configCheck(args[1:], stdout)
+	case "render":
+		return render(args, stdout)
+	case "complete":
+		return complete(args, stdin, stdout)
+	case "categorize":
+		return categorize(args, stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+}
+
+// serve runs the HTTP server, and the gRPC server when GRPC_PORT is set, until
+// SIGINT or SIGTERM
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
 	// Initialize logger
 	logger, err := initLogger()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
 	defer logger.Sync()
 
@@ -54,51 +109,17 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Initialize clients
-	llmClient := llm.NewClient(cfg.CerebrasAPIKey, logger)
-	backendClient := clients.NewBackendClient(cfg.BackendServerAPI, logger)
-
-	// Initialize handlers
-	jobsHandler := handlers.NewJobsHandler(llmClient, backendClient, logger)
-	textCompletionHandler := handlers.NewTextCompletionHandler(llmClient, backendClient, logger)
-	promptsHandler := handlers.NewPromptsHandler(llmClient, backendClient, logger)
-
-	// Create router
-	router := gin.New()
-	router.RedirectTrailingSlash = true
-
-	// Global middleware
-	router.Use(gin.Recovery())
-	router.Use(middleware.ProcessTimerMiddleware(logger))
-	router.Use(middleware.CORSMiddleware(cfg))
-
-	// API routes with authentication
-	apiPrefix := cfg.Settings.APIPrefix
-	
-	// Health check endpoint (no auth required)
-	router.GET(apiPrefix+"/health", handlers.HealthCheck)
-
-	api := router.Group(apiPrefix)
-	api.Use(middleware.AuthMiddleware(cfg, logger))
-	{
-		// Jobs description
-		api.POST("/jobs/describe", jobsHandler.GenerateJobDesc)
-		
-		// Jobs categorization
-		api.POST("/jobs/categorize", jobsHandler.CategorizeJobs)
-
-		// Text completion
-		api.POST("/chat/completion", textCompletionHandler.Complete)
-
-		// Prompt generation
-		api.POST("/actions/generate-prompt", promptsHandler.GeneratePrompt)
+	application, err := app.New(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize service", zap.Error(err))
 	}
+	defer application.Close()
 
 	// Create server
 	addr := fmt.Sprintf("%s:%d", cfg.Settings.Host, cfg.Settings.Port)
 	srv := &http.Server{
 		Addr:    addr,
-		Handler: router,
+		Handler: application.Router,
 	}
 
 	// Start server in a goroutine
@@ -114,6 +135,33 @@ func main() {
 		zap.String("health_check", "http://"+addr+"/health"),
 	)
 
+	// Serve the gRPC API on its own port
+	if cfg.Settings.GRPCPort != 0 {
+		grpcAddr := fmt.Sprintf("%s:%d", cfg.Settings.Host, cfg.Settings.GRPCPort)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.Fatal("Failed to listen for gRPC", zap.Error(err))
+		}
+
+		go func() {
+			logger.Info("gRPC server starting", zap.String("address", grpcAddr))
+			if err := application.GRPC.Serve(lis); err != nil {
+				logger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
+	// Reload prompt templates on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := application.Registry.Reload(); err != nil {
+				logger.Error("Failed to reload prompt templates, keeping current set", zap.Error(err))
+			}
+		}
+	}()
+
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -129,7 +177,21 @@ func main() {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Let open gRPC calls finish within the same deadline
+	stopped := make(chan struct{})
+	go func() {
+		application.GRPC.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Error("gRPC server forced to shutdown")
+		application.GRPC.Stop()
+	}
+
 	logger.Info("Server exited")
+	return nil
 }
 
 // initLogger initializes the zap logger
@@ -164,3 +226,11 @@ func initLogger() (*zap.Logger, error) {
 
 	return logger, nil
 }
+
+// commandLogger logs warnings and errors only, so command output stays readable
+func commandLogger() (*zap.Logger, error) {
+	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	config.DisableStacktrace = true
+	return config.Build()
+}
@@ -0,0 +1,222 @@
+// Package app wires the service together: clients, stores, background workers,
+// handlers and the HTTP router, all built from a config.Config.
+package app
+
+import (
+	"fmt"
+
+	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/conversations"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/grpcapi"
+	"github.com/dokoola/llm-go/internal/handlers"
+	"github.com/dokoola/llm-go/internal/idempotency"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/openapi"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/dokoola/llm-go/internal/tasks"
+	"github.com/dokoola/llm-go/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// App is the wired service
+type App struct {
+	Config   *config.Config
+	Router   *gin.Engine
+	Registry *prompts.Registry
+	LLM      *llm.Client
+	Backend  *clients.BackendClient
+	// GRPC serves the same service layer to internal callers; serve it on its own port
+	GRPC *grpc.Server
+
+	dispatcher  *webhooks.Dispatcher
+	taskManager *tasks.Manager
+}
+
+// NewLLMClient builds the LLM client described by cfg
+func NewLLMClient(cfg *config.Config, logger *zap.Logger) *llm.Client {
+	llmClient := llm.NewClient(cfg.CerebrasAPIKey, logger)
+	if cfg.LLMAPIURL != "" {
+		llmClient.SetAPIURL(cfg.LLMAPIURL)
+	}
+	if cfg.LLMCacheSize > 0 {
+		llmClient.SetCache(llm.NewCache(cfg.LLMCacheSize, cfg.LLMCacheTTL))
+	}
+	return llmClient
+}
+
+// SpecInfo describes the service for its OpenAPI document
+func SpecInfo(cfg *config.Config) openapi.Info {
+	return openapi.Info{
+		Title:            cfg.Settings.AppName,
+		Version:          cfg.Settings.AppVersion,
+		APIPrefix:        cfg.Settings.APIPrefix,
+		V2Prefix:         cfg.Settings.APIV2Prefix,
+		ServiceKeyHeader: cfg.ServiceKeyName,
+		ClientNameHeader: cfg.ClientNameHeader,
+		SecretHashHeader: cfg.SecretHashHeader,
+	}
+}
+
+// New builds the service from configuration and starts its task workers. Close
+// stops them.
+func New(cfg *config.Config, logger *zap.Logger) (*App, error) {
+	// Initialize clients
+	llmClient := NewLLMClient(cfg, logger)
+	backendClient := clients.NewBackendClient(cfg.BackendServerAPI, logger)
+	generationStore := generations.NewStore(cfg.GenerationStoreSize, logger)
+	idempotencyStore := idempotency.NewStore(cfg.IdempotencyStoreSize, cfg.IdempotencyTTL)
+	conversationStore := conversations.NewStore(cfg.ConversationStoreSize, cfg.ConversationTTL, cfg.ConversationMaxTokens, logger)
+
+	// Load and validate prompt templates; a broken template fails startup
+	promptRegistry, err := prompts.NewRegistry(cfg.PromptTemplatesDir, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+
+	// Start async task workers, delivering results to callback URLs when requested
+	dispatcher := webhooks.NewDispatcher(cfg.AllowedServices, cfg.WebhookMaxAttempts, cfg.WebhookBackoff, logger)
+
+	taskManager := tasks.NewManager(cfg.TaskWorkers, cfg.TaskQueueSize, cfg.TaskTTL, logger)
+	taskManager.OnFinish(dispatcher.NotifyTask)
+	taskManager.Start()
+
+	// Handlers share the service layer with async tasks and the command line
+	svc := service.New(llmClient, backendClient, promptRegistry, generationStore, conversationStore, logger)
+
+	// Initialize handlers
+	jobsHandler := handlers.NewJobsHandler(svc, taskManager, dispatcher, logger)
+	textCompletionHandler := handlers.NewTextCompletionHandler(svc, logger)
+	conversationsHandler := handlers.NewConversationsHandler(svc, logger)
+	promptsHandler := handlers.NewPromptsHandler(svc, logger)
+	templatesHandler := handlers.NewTemplatesHandler(promptRegistry, logger)
+	generationsHandler := handlers.NewGenerationsHandler(generationStore, logger)
+	tasksHandler := handlers.NewTasksHandler(taskManager, logger)
+	webhooksHandler := handlers.NewWebhooksHandler(dispatcher, logger)
+
+	docsHandler, err := handlers.NewDocsHandler(openapi.Spec(SpecInfo(cfg)), "/openapi.json", logger)
+	if err != nil {
+		taskManager.Stop()
+		dispatcher.Stop()
+		return nil, err
+	}
+
+	// Create router
+	router := gin.New()
+	router.RedirectTrailingSlash = true
+
+	// Global middleware
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.RecoveryMiddleware(logger))
+	router.Use(middleware.ProcessTimerMiddleware(logger))
+	router.Use(middleware.CORSMiddleware(cfg))
+
+	apiPrefix := cfg.Settings.APIPrefix
+	v2Prefix := cfg.Settings.APIV2Prefix
+
+	// v1 keeps its original shapes and points callers at the same route in v2
+	deprecated := middleware.DeprecationMiddleware(apiPrefix, v2Prefix, cfg.Settings.V1Sunset)
+
+	// Health check endpoint (no auth required)
+	router.GET(apiPrefix+"/health", deprecated, handlers.HealthCheck)
+	router.GET(v2Prefix+"/health", handlers.HealthCheck)
+
+	// API contract and docs (no auth required)
+	router.GET("/openapi.json", docsHandler.Spec)
+	router.GET("/docs", docsHandler.Page)
+
+	// Generation endpoints accept an Idempotency-Key header to dedupe retries
+	idempotent := middleware.IdempotencyMiddleware(idempotencyStore, logger)
+
+	// Routes whose shapes are the same in every API version
+	shared := func(group *gin.RouterGroup) {
+		// Prompt template catalog and input schemas
+		group.GET("/templates", templatesHandler.List)
+		group.GET("/templates/:name/schema", templatesHandler.Schema)
+
+		// Targeted edits of generated content
+		group.POST("/actions/refine", idempotent, promptsHandler.Refine)
+
+		// Multi-turn conversations
+		group.POST("/chat/conversations", conversationsHandler.Create)
+		group.GET("/chat/conversations/:id", conversationsHandler.Get)
+		group.DELETE("/chat/conversations/:id", conversationsHandler.Delete)
+		group.POST("/chat/conversations/:id/messages", idempotent, conversationsHandler.SendMessage)
+
+		// Generation feedback
+		group.POST("/generations/:id/feedback", generationsHandler.SubmitFeedback)
+		group.GET("/generations/stats", generationsHandler.Stats)
+
+		// Async task status and cancellation
+		group.GET("/tasks/:id", tasksHandler.GetTask)
+		group.DELETE("/tasks/:id", tasksHandler.CancelTask)
+
+		// Admin-only endpoints
+		admin := group.Group("/admin")
+		admin.Use(middleware.AdminMiddleware(cfg, logger))
+		admin.GET("/webhooks/dead-letters", webhooksHandler.DeadLetters)
+		admin.POST("/templates/reload", templatesHandler.Reload)
+	}
+
+	// API routes with authentication
+	api := router.Group(apiPrefix)
+	api.Use(deprecated)
+	api.Use(middleware.AuthMiddleware(cfg, logger))
+	{
+		// Jobs description
+		api.POST("/jobs/describe", idempotent, jobsHandler.GenerateJobDesc)
+
+		// Jobs categorization
+		api.POST("/jobs/categorize", idempotent, jobsHandler.CategorizeJobs)
+
+		// Text completion
+		api.POST("/chat/completion", idempotent, textCompletionHandler.Complete)
+
+		// Prompt generation
+		api.POST("/actions/generate-prompt", idempotent, promptsHandler.GeneratePrompt)
+
+		// Prompt preview: the exact LLM messages, without calling the LLM
+		api.POST("/actions/render-prompt", promptsHandler.RenderPrompt)
+
+		shared(api)
+	}
+
+	// v2 takes every input in the body (or the X-User-ID header) and reports the
+	// LLM usage of each generation
+	v2 := router.Group(v2Prefix)
+	v2.Use(middleware.AuthMiddleware(cfg, logger))
+	{
+		v2.POST("/jobs/describe", idempotent, jobsHandler.DescribeJobsV2)
+		v2.POST("/jobs/categorize", idempotent, jobsHandler.CategorizeJobsV2)
+		v2.POST("/chat/completion", idempotent, textCompletionHandler.CompleteV2)
+		v2.POST("/actions/generate-prompt", idempotent, promptsHandler.GeneratePromptV2)
+		v2.POST("/actions/render-prompt", promptsHandler.RenderPromptV2)
+
+		shared(v2)
+	}
+
+	// Unknown routes get the error envelope too
+	router.NoRoute(middleware.NotFound)
+
+	return &App{
+		Config:      cfg,
+		Router:      router,
+		Registry:    promptRegistry,
+		LLM:         llmClient,
+		Backend:     backendClient,
+		GRPC:        grpcapi.NewServer(cfg, svc, logger),
+		dispatcher:  dispatcher,
+		taskManager: taskManager,
+	}, nil
+}
+
+// Close stops the task workers and webhook deliveries
+func (a *App) Close() {
+	a.taskManager.Stop()
+	a.dispatcher.Stop()
+}
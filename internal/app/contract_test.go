@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/openapi"
+)
+
+// undocumentedRoutes are registered but not part of the API contract
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	env := newTestEnv(t)
+
+	registered := map[string]bool{}
+	for _, route := range env.app.Router.Routes() {
+		key := route.Method + " " + route.Path
+		if !undocumentedRoutes[key] {
+			registered[key] = true
+		}
+	}
+
+	documented := map[string]bool{}
+	for _, op := range openapi.Operations {
+		documented[op.Method+" "+opPrefix(env, op)+op.Path] = true
+	}
+
+	var missing, extra []string
+	for key := range registered {
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	if len(missing) > 0 {
+		t.Errorf("routes missing from openapi.Operations: %v", missing)
+	}
+	if len(extra) > 0 {
+		t.Errorf("openapi.Operations documents routes that aren't registered: %v", extra)
+	}
+}
+
+func TestRouteAuthMatchesSpec(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, op := range openapi.Operations {
+		path := opPrefix(env, op) + strings.ReplaceAll(op.Path, ":id", "x")
+		path = strings.ReplaceAll(path, ":name", "talent_bio")
+
+		// A non-admin service is turned away from admin routes only, and nobody needs
+		// credentials for public routes
+		w := env.do(t, op.Method, path, testServiceKey, op.Request)
+		if forbidden := w.Code == http.StatusForbidden; forbidden != op.Admin {
+			t.Errorf("%s %s as a service: status %d, documented admin = %v", op.Method, path, w.Code, op.Admin)
+		}
+		w = env.do(t, op.Method, path, "", op.Request)
+		if forbidden := w.Code == http.StatusForbidden; forbidden == op.Public {
+			t.Errorf("%s %s without credentials: status %d, documented public = %v", op.Method, path, w.Code, op.Public)
+		}
+	}
+}
+
+// opPrefix is the API prefix of the version an operation belongs to
+func opPrefix(env *testEnv, op openapi.Operation) string {
+	if op.V2 {
+		return env.app.Config.Settings.APIV2Prefix
+	}
+	return env.app.Config.Settings.APIPrefix
+}
+
+func TestServesSpecAndDocs(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(t, http.MethodGet, "/openapi.json", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var served map[string]interface{}
+	decode(t, w, &served)
+	want, _ := json.Marshal(openapi.Spec(SpecInfo(env.app.Config)))
+	got, _ := json.Marshal(served)
+	if string(got) != string(want) {
+		t.Error("served document differs from openapi.Spec")
+	}
+	if served["openapi"] != openapi.Version {
+		t.Errorf("openapi = %v", served["openapi"])
+	}
+
+	w = env.do(t, http.MethodGet, "/docs", "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `spec-url="/openapi.json"`) {
+		t.Errorf("docs page: status %d\n%s", w.Code, w.Body.String())
+	}
+}
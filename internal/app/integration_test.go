@@ -0,0 +1,474 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/clients/backendtest"
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	testServiceKey = "web"
+	testAdminKey   = "ops"
+)
+
+// testEnv is the real router wired against a fake backend and a fake LLM
+type testEnv struct {
+	app     *App
+	llm     *llmtest.Server
+	backend *backendtest.Server
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	env := &testEnv{
+		llm:     llmtest.NewServer(t),
+		backend: backendtest.NewServer(t),
+	}
+
+	cfg := &config.Config{
+		Settings: config.Settings{AppName: "test", APIPrefix: "/api/v1", APIV2Prefix: "/api/v2"},
+		AllowedServices: map[string]config.ServiceConfig{
+			testServiceKey: {ClientName: "dokoola-web", SecretHash: "web-secret"},
+			testAdminKey:   {ClientName: "dokoola-ops", SecretHash: "ops-secret", Admin: true},
+		},
+		CerebrasAPIKey:        "test-key",
+		BackendServerAPI:      env.backend.URL(),
+		ServiceKeyName:        "X-Service-Key",
+		ClientNameHeader:      "X-Client-Name",
+		SecretHashHeader:      "X-Secret-Hash",
+		GenerationStoreSize:   100,
+		TaskWorkers:           1,
+		TaskQueueSize:         10,
+		TaskTTL:               time.Minute,
+		WebhookMaxAttempts:    1,
+		WebhookBackoff:        time.Millisecond,
+		IdempotencyStoreSize:  100,
+		IdempotencyTTL:        time.Minute,
+		ConversationStoreSize: 10,
+		ConversationTTL:       time.Minute,
+		ConversationMaxTokens: 1000,
+		LLMCacheSize:          100,
+		LLMCacheTTL:           time.Minute,
+		LLMAPIURL:             env.llm.URL(),
+	}
+
+	a, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(a.Close)
+	env.app = a
+	return env
+}
+
+// do sends a request as the given service; an empty key sends no credentials
+func (e *testEnv) do(t *testing.T, method, path, serviceKey string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return e.doWithHeaders(t, method, path, serviceKey, body, nil)
+}
+
+// doWithHeaders is do with extra request headers
+func (e *testEnv) doWithHeaders(t *testing.T, method, path, serviceKey string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	switch serviceKey {
+	case testServiceKey:
+		req.Header.Set("X-Service-Key", testServiceKey)
+		req.Header.Set("X-Client-Name", "dokoola-web")
+		req.Header.Set("X-Secret-Hash", "web-secret")
+	case testAdminKey:
+		req.Header.Set("X-Service-Key", testAdminKey)
+		req.Header.Set("X-Client-Name", "dokoola-ops")
+		req.Header.Set("X-Secret-Hash", "ops-secret")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	e.app.Router.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestIntegrationHealthCheck(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(t, http.MethodGet, "/api/v1/health", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestIntegrationAuth(t *testing.T) {
+	env := newTestEnv(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		key     string
+		want    int
+	}{
+		{name: "missing headers", path: "/api/v1/templates", want: http.StatusForbidden},
+		{name: "unknown service", path: "/api/v1/templates", want: http.StatusForbidden, headers: map[string]string{
+			"X-Service-Key": "nope", "X-Client-Name": "dokoola-web", "X-Secret-Hash": "web-secret",
+		}},
+		{name: "wrong secret", path: "/api/v1/templates", want: http.StatusForbidden, headers: map[string]string{
+			"X-Service-Key": testServiceKey, "X-Client-Name": "dokoola-web", "X-Secret-Hash": "ops-secret",
+		}},
+		{name: "valid service", path: "/api/v1/templates", key: testServiceKey, want: http.StatusOK},
+		{name: "admin route as service", path: "/api/v1/admin/webhooks/dead-letters", key: testServiceKey, want: http.StatusForbidden},
+		{name: "admin route as admin", path: "/api/v1/admin/webhooks/dead-letters", key: testAdminKey, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			var w *httptest.ResponseRecorder
+			if tt.headers != nil {
+				w = httptest.NewRecorder()
+				env.app.Router.ServeHTTP(w, req)
+			} else {
+				w = env.do(t, http.MethodGet, tt.path, tt.key, nil)
+			}
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	if len(env.llm.Requests()) != 0 || len(env.backend.Requests()) != 0 {
+		t.Error("auth checks should not reach the LLM or the backend")
+	}
+}
+
+func TestIntegrationCategorizeJobs(t *testing.T) {
+	env := newTestEnv(t)
+	env.backend.SetLatency(10 * time.Millisecond)
+	env.llm.Respond(func(req llmtest.Request) llmtest.Response {
+		if strings.Contains(req.UserPrompt(), "React") {
+			return llmtest.Reply(`"frontend"`)
+		}
+		return llmtest.Reply("graphic-design")
+	})
+
+	body := models.JobCategorizationRequest{Data: []models.JobData{
+		{PublicID: "job-1", Description: "Build a React dashboard"},
+		{PublicID: "job-2", Description: "Design a logo"},
+	}}
+	w := env.do(t, http.MethodPost, "/api/v1/jobs/categorize", testServiceKey, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+
+	var resp models.JobCategorizationResponse
+	decode(t, w, &resp)
+	if !resp.Success || len(resp.Data) != 2 {
+		t.Fatalf("response = %+v", resp)
+	}
+	if resp.Data[0].Category != "frontend" || resp.Data[1].Category != "graphic-design" {
+		t.Errorf("categories = %+v", resp.Data)
+	}
+
+	// The prompt lists the backend's categories
+	if prompt := env.llm.Requests()[0].UserPrompt(); !strings.Contains(prompt, "frontend") || !strings.Contains(prompt, "content-writing") {
+		t.Errorf("prompt does not list the backend categories:\n%s", prompt)
+	}
+}
+
+func TestIntegrationCategorizeBackendFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.backend.Fail(backendtest.RouteCategories, http.StatusBadGateway, 1)
+
+	body := models.JobCategorizationRequest{Data: []models.JobData{{PublicID: "job-1", Description: "Build an API"}}}
+	w := env.do(t, http.MethodPost, "/api/v1/jobs/categorize", testServiceKey, body)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500 (%s)", w.Code, w.Body.String())
+	}
+	assertErrorCode(t, w, models.ErrUpstreamError)
+	if len(env.llm.Requests()) != 0 {
+		t.Error("expected no LLM calls without categories")
+	}
+
+	// The failure was scripted once, so a retry succeeds
+	w = env.do(t, http.MethodPost, "/api/v1/jobs/categorize", testServiceKey, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("retry status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+}
+
+func TestIntegrationCategorizeInvalidRequest(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(t, http.MethodPost, "/api/v1/jobs/categorize", testServiceKey, map[string]interface{}{"data": []map[string]string{{"public_id": "job-1"}}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (%s)", w.Code, w.Body.String())
+	}
+	assertErrorCode(t, w, models.ErrInvalidRequest)
+}
+
+func generatePromptBody() models.PromptGenerationRequest {
+	return models.PromptGenerationRequest{
+		TemplateName: models.PromptTalentBio,
+		Length:       models.LengthShort,
+		Data: map[string]interface{}{
+			"profile": map[string]interface{}{"name": "Ada Obi", "title": "Backend Engineer", "skills": "Go, PostgreSQL"},
+		},
+	}
+}
+
+func TestIntegrationGeneratePrompt(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+
+	w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id="+backendtest.TalentID, testServiceKey, generatePromptBody())
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+
+	var resp models.PromptGenerationResponse
+	decode(t, w, &resp)
+	if !resp.Success || resp.Completion == nil || *resp.Completion != "I build **reliable** Go services." {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+	if resp.GenerationID == "" || resp.TemplateVersion == "" {
+		t.Errorf("expected generation id and template version, got %+v", resp)
+	}
+
+	if got := env.backend.Requests(); len(got) != 1 || got[0] != "/users/"+backendtest.TalentID+"/llm/" {
+		t.Errorf("backend requests = %v", got)
+	}
+	if prompt := env.llm.Requests()[0].UserPrompt(); !strings.Contains(prompt, "Ada Obi") {
+		t.Errorf("prompt does not include the profile:\n%s", prompt)
+	}
+}
+
+func TestIntegrationGeneratePromptErrors(t *testing.T) {
+	t.Run("unknown user", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id=ghost", testServiceKey, generatePromptBody())
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrUserNotFound)
+		if len(env.llm.Requests()) != 0 {
+			t.Error("expected no LLM calls for an unknown user")
+		}
+	})
+
+	t.Run("backend down", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.backend.Fail(backendtest.RouteUsers, http.StatusServiceUnavailable, 0)
+
+		w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id="+backendtest.TalentID, testServiceKey, generatePromptBody())
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrUserNotFound)
+	})
+
+	t.Run("invalid tone", func(t *testing.T) {
+		env := newTestEnv(t)
+		body := generatePromptBody()
+		body.Tone = "sarcastic"
+
+		w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id="+backendtest.TalentID, testServiceKey, body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrInvalidRequest)
+	})
+
+	t.Run("LLM rate limited", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.RateLimited() })
+
+		w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id="+backendtest.TalentID, testServiceKey, generatePromptBody())
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrUpstreamRateLimited)
+	})
+
+	t.Run("LLM error", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.llm.Respond(func(llmtest.Request) llmtest.Response {
+			return llmtest.Error(http.StatusUnauthorized, "invalid api key")
+		})
+
+		w := env.do(t, http.MethodPost, "/api/v1/actions/generate-prompt?user_id="+backendtest.TalentID, testServiceKey, generatePromptBody())
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want 500 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrUpstreamError)
+	})
+}
+
+func TestIntegrationRefine(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Python services."))
+
+	w := env.do(t, http.MethodPost, "/api/v2/actions/generate-prompt", testServiceKey, generatePromptV2Body(backendtest.TalentID))
+	var generated models.CompletionV2Response
+	decode(t, w, &generated)
+	if w.Code != http.StatusOK || generated.Data.GenerationID == "" {
+		t.Fatalf("generate status = %d (%s)", w.Code, w.Body.String())
+	}
+
+	w = env.do(t, http.MethodPost, "/api/v1/actions/refine", testServiceKey,
+		models.RefineRequest{GenerationID: generated.Data.GenerationID, Instruction: "Emphasize Python"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("refine status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var refined models.CompletionV2Response
+	decode(t, w, &refined)
+	if refined.Data.Completion != "I build **reliable** Python services." || refined.Data.GenerationID == generated.Data.GenerationID || refined.Usage.LLMCalls != 1 {
+		t.Errorf("refine response = %s", w.Body.String())
+	}
+
+	// Without a generation_id the text and template are required
+	w = env.do(t, http.MethodPost, "/api/v2/actions/refine", testServiceKey, models.RefineRequest{Instruction: "Emphasize Python"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing content status = %d, want 400 (%s)", w.Code, w.Body.String())
+	}
+
+	w = env.do(t, http.MethodPost, "/api/v2/actions/refine", testServiceKey, models.RefineRequest{GenerationID: "gen_missing", Instruction: "Emphasize Python"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown generation status = %d, want 404 (%s)", w.Code, w.Body.String())
+	}
+
+	// Other services can neither refine nor rate the generation
+	w = env.do(t, http.MethodPost, "/api/v2/actions/refine", testAdminKey, models.RefineRequest{GenerationID: generated.Data.GenerationID, Instruction: "Emphasize Python"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("other service refine status = %d, want 404 (%s)", w.Code, w.Body.String())
+	}
+	w = env.do(t, http.MethodPost, "/api/v1/generations/"+generated.Data.GenerationID+"/feedback", testAdminKey, models.GenerationFeedbackRequest{Rating: models.FeedbackUp})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("other service feedback status = %d, want 404 (%s)", w.Code, w.Body.String())
+	}
+	w = env.do(t, http.MethodPost, "/api/v1/generations/"+generated.Data.GenerationID+"/feedback", testServiceKey, models.GenerationFeedbackRequest{Rating: models.FeedbackUp})
+	if w.Code != http.StatusOK {
+		t.Errorf("feedback status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+}
+
+func TestIntegrationConversation(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+	env.llm.Enqueue(llmtest.Reply("I build Go services."))
+
+	w := env.doWithHeaders(t, http.MethodPost, "/api/v2/chat/conversations", testServiceKey,
+		models.ConversationCreateRequest{Locale: "en"}, map[string]string{"X-User-ID": backendtest.TalentID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var created models.ConversationResponse
+	decode(t, w, &created)
+	if created.Data == nil || created.Data.UserID != backendtest.TalentID {
+		t.Fatalf("create response = %s", w.Body.String())
+	}
+	path := "/api/v2/chat/conversations/" + created.Data.ID
+
+	for _, text := range []string{"Write a bio", "Make it shorter"} {
+		w = env.do(t, http.MethodPost, path+"/messages", testServiceKey, models.ConversationMessageRequest{Text: text})
+		if w.Code != http.StatusOK {
+			t.Fatalf("message status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+	}
+	var reply models.ConversationMessageResponse
+	decode(t, w, &reply)
+	if reply.Data.Completion != "I build Go services." || reply.Usage.LLMCalls != 1 {
+		t.Errorf("reply = %s", w.Body.String())
+	}
+
+	// Conversations are served by v1 too, and are private to the service that made them
+	w = env.do(t, http.MethodGet, "/api/v1/chat/conversations/"+created.Data.ID, testServiceKey, nil)
+	var fetched models.ConversationResponse
+	decode(t, w, &fetched)
+	if w.Code != http.StatusOK || len(fetched.Data.Messages) != 4 {
+		t.Errorf("get status = %d, response = %s", w.Code, w.Body.String())
+	}
+	if w = env.do(t, http.MethodGet, path, testAdminKey, nil); w.Code != http.StatusNotFound {
+		t.Errorf("get by another service status = %d, want 404", w.Code)
+	}
+
+	if w = env.do(t, http.MethodDelete, path, testServiceKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	w = env.do(t, http.MethodPost, path+"/messages", testServiceKey, models.ConversationMessageRequest{Text: "Hello"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("message after delete status = %d, want 404", w.Code)
+	}
+	var errResp models.ErrorResponse
+	decode(t, w, &errResp)
+	if errResp.Error.Code != models.ErrNotFound {
+		t.Errorf("error = %s", w.Body.String())
+	}
+}
+
+func TestIntegrationUnknownRoute(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(t, http.MethodGet, "/api/v1/nope", testServiceKey, nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (%s)", w.Code, w.Body.String())
+	}
+	assertErrorCode(t, w, models.ErrNotFound)
+}
+
+// assertErrorCode checks that w is an error envelope with the code, tagged with
+// the request ID of the response
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, code models.ErrorCodeEnum) {
+	t.Helper()
+	var resp models.ErrorResponse
+	decode(t, w, &resp)
+	if resp.Success || resp.Error.Code != code {
+		t.Errorf("error code = %q, want %q (%s)", resp.Error.Code, code, w.Body.String())
+	}
+	if resp.Error.Retryable != code.Retryable() {
+		t.Errorf("retryable = %v for %s", resp.Error.Retryable, code)
+	}
+	if id := w.Header().Get(middleware.RequestIDHeader); id == "" || resp.Error.RequestID != id {
+		t.Errorf("request_id = %q, header = %q", resp.Error.RequestID, id)
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/clients/backendtest"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+func generatePromptV2Body(userID string) models.PromptGenerationV2Request {
+	v1 := generatePromptBody()
+	return models.PromptGenerationV2Request{
+		TemplateName: v1.TemplateName,
+		Data:         v1.Data,
+		UserID:       userID,
+		Length:       v1.Length,
+	}
+}
+
+func TestV2GeneratePrompt(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+
+	w := env.do(t, http.MethodPost, "/api/v2/actions/generate-prompt", testServiceKey, generatePromptV2Body(backendtest.TalentID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if w.Header().Get(middleware.DeprecationHeader) != "" {
+		t.Error("v2 responses should not be deprecated")
+	}
+
+	var resp models.CompletionV2Response
+	decode(t, w, &resp)
+	if !resp.Success || resp.Data.Completion != "I build **reliable** Go services." || resp.Data.GenerationID == "" {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+	if resp.Usage.LLMCalls != 1 || resp.Usage.PromptTokens == 0 || resp.Usage.TotalTokens != resp.Usage.PromptTokens+resp.Usage.CompletionTokens {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if resp.Usage.Model == "" {
+		t.Error("expected the model in usage")
+	}
+
+	if got := env.backend.Requests(); len(got) != 1 || got[0] != "/users/"+backendtest.TalentID+"/llm/" {
+		t.Errorf("backend requests = %v", got)
+	}
+}
+
+func TestV2UserID(t *testing.T) {
+	t.Run("from header", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		w := env.doWithHeaders(t, http.MethodPost, "/api/v2/actions/render-prompt", testServiceKey, generatePromptV2Body(""),
+			map[string]string{middleware.UserIDHeader: backendtest.TalentID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+		}
+
+		var resp models.RenderPromptV2Response
+		decode(t, w, &resp)
+		if len(resp.Data.Messages) == 0 || resp.Usage.LLMCalls != 0 {
+			t.Errorf("response = %s", w.Body.String())
+		}
+		if got := env.backend.Requests(); len(got) != 1 {
+			t.Errorf("expected the header's user to be fetched, backend requests = %v", got)
+		}
+	})
+
+	t.Run("body and header disagree", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		w := env.doWithHeaders(t, http.MethodPost, "/api/v2/actions/generate-prompt", testServiceKey, generatePromptV2Body(backendtest.TalentID),
+			map[string]string{middleware.UserIDHeader: backendtest.ClientID})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrInvalidRequest)
+		if len(env.backend.Requests()) != 0 || len(env.llm.Requests()) != 0 {
+			t.Error("expected no upstream calls")
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		w := env.do(t, http.MethodPost, "/api/v2/chat/completion", testServiceKey, models.TextCompletionV2Request{Text: "Hello", UserID: "ghost"})
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404 (%s)", w.Code, w.Body.String())
+		}
+		assertErrorCode(t, w, models.ErrUserNotFound)
+	})
+}
+
+func TestV2CategorizeJobs(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply("graphic-design") })
+
+	// The second job has the same prompt, so it is served from the cache
+	body := models.JobCategorizationV2Request{Jobs: []models.JobData{
+		{PublicID: "job-1", Description: "Design a logo"},
+		{PublicID: "job-2", Description: "Design a logo"},
+	}}
+	w := env.do(t, http.MethodPost, "/api/v2/jobs/categorize", testServiceKey, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+
+	var resp models.JobCategorizationV2Response
+	decode(t, w, &resp)
+	if len(resp.Data) != 2 || resp.Data[1].Category != "graphic-design" {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+	if resp.Usage.LLMCalls != 1 || resp.Usage.CachedCalls != 1 {
+		t.Errorf("usage = %+v, want one upstream and one cached call", resp.Usage)
+	}
+
+	// no_cache skips the cache
+	body.NoCache = true
+	w = env.do(t, http.MethodPost, "/api/v2/jobs/categorize", testServiceKey, body)
+	decode(t, w, &resp)
+	if resp.Usage.LLMCalls != 2 || resp.Usage.CachedCalls != 0 {
+		t.Errorf("usage with no_cache = %+v", resp.Usage)
+	}
+
+	// A bare array, the v1 shape, is rejected
+	w = env.do(t, http.MethodPost, "/api/v2/jobs/describe", testServiceKey, []models.JobDescribeRequest{{Title: "Logo", Description: "Design a logo", Category: "graphic-design"}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bare array: status = %d, want 400", w.Code)
+	}
+}
+
+func TestV1Deprecation(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do(t, http.MethodGet, "/api/v1/templates", testServiceKey, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if w.Header().Get(middleware.DeprecationHeader) != "true" {
+		t.Errorf("Deprecation = %q", w.Header().Get(middleware.DeprecationHeader))
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2/templates>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+	if w.Header().Get(middleware.SunsetHeader) != "" {
+		t.Error("expected no Sunset header without a sunset date")
+	}
+
+	// Rejected requests are marked too
+	w = env.do(t, http.MethodGet, "/api/v1/templates", "", nil)
+	if w.Code != http.StatusForbidden || w.Header().Get(middleware.DeprecationHeader) != "true" {
+		t.Errorf("unauthenticated v1 request: status %d, Deprecation %q", w.Code, w.Header().Get(middleware.DeprecationHeader))
+	}
+
+	w = env.do(t, http.MethodGet, "/api/v2/templates", testServiceKey, nil)
+	if w.Code != http.StatusOK || w.Header().Get(middleware.DeprecationHeader) != "" {
+		t.Errorf("v2 templates: status %d, Deprecation %q", w.Code, w.Header().Get(middleware.DeprecationHeader))
+	}
+}
@@ -0,0 +1,209 @@
+// Package backendtest provides an in-process fake of the Dokoola backend API for
+// tests: users, job categories, and scriptable failures and latency.
+package backendtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Route names a backend endpoint for failures
+type Route string
+
+const (
+	RouteUsers      Route = "users"
+	RouteCategories Route = "categories"
+)
+
+// Seeded users, available on every new server
+const (
+	TalentID = "talent-1"
+	ClientID = "client-1"
+)
+
+// DefaultUsers are the users a new server knows
+func DefaultUsers() map[string]models.AuthUser {
+	return map[string]models.AuthUser{
+		TalentID: {Name: "Ada Obi", PublicID: TalentID, IsActive: true, CompleteProfile: true, IsTalent: true},
+		ClientID: {Name: "Kora Pay", PublicID: ClientID, IsActive: true, IsClient: true},
+	}
+}
+
+// DefaultCategories are the job categories a new server returns
+func DefaultCategories() []models.JobCategory {
+	web := "web-development"
+	webDescription := "Web Development"
+	return []models.JobCategory{
+		{Slug: web, Description: webDescription},
+		{Slug: "frontend", Description: "Frontend Development", ParentSlug: &web, ParentDescription: &webDescription},
+		{Slug: "backend", Description: "Backend Development", ParentSlug: &web, ParentDescription: &webDescription},
+		{Slug: "graphic-design", Description: "Graphic Design"},
+		{Slug: "content-writing", Description: "Content Writing"},
+	}
+}
+
+// failure is a scripted error for a route
+type failure struct {
+	status int
+	// remaining is how many more requests fail; negative fails forever
+	remaining int
+}
+
+// Server is a fake backend API
+type Server struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	users      map[string]models.AuthUser
+	categories []models.JobCategory
+	failures   map[Route]*failure
+	latency    time.Duration
+	requests   []string
+}
+
+// NewServer starts a fake backend seeded with DefaultUsers and DefaultCategories.
+// It is closed when the test finishes.
+func NewServer(t interface{ Cleanup(func()) }) *Server {
+	s := &Server{
+		users:      DefaultUsers(),
+		categories: DefaultCategories(),
+		failures:   map[Route]*failure{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// URL is the base URL to configure the backend client with
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// AddUser adds or replaces a user
+func (s *Server) AddUser(user models.AuthUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.PublicID] = user
+}
+
+// SetCategories replaces the job categories
+func (s *Server) SetCategories(categories []models.JobCategory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = categories
+}
+
+// Fail makes the next times requests to a route return status. A times of zero or
+// less fails every request until Recover is called.
+func (s *Server) Fail(route Route, status, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if times <= 0 {
+		times = -1
+	}
+	s.failures[route] = &failure{status: status, remaining: times}
+}
+
+// Recover clears the failures scripted for a route
+func (s *Server) Recover(route Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, route)
+}
+
+// SetLatency delays every response
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// Requests returns the paths requested so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// fail reports whether a request to route should fail, and with which status
+func (s *Server) fail(route Route) (int, bool) {
+	f, ok := s.failures[route]
+	if !ok || f.remaining == 0 {
+		return 0, false
+	}
+	if f.remaining > 0 {
+		f.remaining--
+	}
+	return f.status, true
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	latency := s.latency
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"detail": "Method not allowed"})
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "categories":
+		s.handleCategories(w)
+	case strings.HasPrefix(path, "users/") && strings.HasSuffix(path, "/llm"):
+		s.handleUser(w, strings.TrimSuffix(strings.TrimPrefix(path, "users/"), "/llm"))
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not found"})
+	}
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, userID string) {
+	s.mu.Lock()
+	status, failed := s.fail(RouteUsers)
+	user, ok := s.users[userID]
+	s.mu.Unlock()
+
+	switch {
+	case failed:
+		writeJSON(w, status, map[string]string{"detail": http.StatusText(status)})
+	case !ok:
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "User not found"})
+	default:
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+func (s *Server) handleCategories(w http.ResponseWriter) {
+	s.mu.Lock()
+	status, failed := s.fail(RouteCategories)
+	categories := s.categories
+	s.mu.Unlock()
+
+	if failed {
+		writeJSON(w, status, map[string]string{"detail": http.StatusText(status)})
+		return
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package backendtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+func get(t *testing.T, url string) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerUsers(t *testing.T) {
+	server := NewServer(t)
+	server.AddUser(models.AuthUser{Name: "Staff", PublicID: "staff-1", IsStaff: true})
+
+	resp := get(t, server.URL()+"/users/staff-1/llm/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var user models.AuthUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Staff" || !user.IsStaff {
+		t.Errorf("user = %+v", user)
+	}
+
+	if resp := get(t, server.URL()+"/users/"+TalentID+"/llm/"); resp.StatusCode != http.StatusOK {
+		t.Errorf("seeded user status = %d, want 200", resp.StatusCode)
+	}
+	if resp := get(t, server.URL()+"/users/ghost/llm/"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown user status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestServerFailAndRecover(t *testing.T) {
+	server := NewServer(t)
+	server.Fail(RouteCategories, http.StatusBadGateway, 2)
+
+	for i := 0; i < 2; i++ {
+		if resp := get(t, server.URL()+"/categories?scraper=true"); resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("request %d status = %d, want 502", i, resp.StatusCode)
+		}
+	}
+
+	resp := get(t, server.URL()+"/categories?scraper=true")
+	var categories []models.JobCategory
+	if err := json.NewDecoder(resp.Body).Decode(&categories); err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != len(DefaultCategories()) {
+		t.Errorf("got %d categories, want %d", len(categories), len(DefaultCategories()))
+	}
+
+	// Users are unaffected by category failures; unbounded failures last until Recover
+	server.Fail(RouteUsers, http.StatusInternalServerError, 0)
+	for i := 0; i < 3; i++ {
+		if resp := get(t, server.URL()+"/users/"+TalentID+"/llm/"); resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("request %d status = %d, want 500", i, resp.StatusCode)
+		}
+	}
+	server.Recover(RouteUsers)
+	if resp := get(t, server.URL()+"/users/"+TalentID+"/llm/"); resp.StatusCode != http.StatusOK {
+		t.Errorf("status after Recover = %d, want 200", resp.StatusCode)
+	}
+
+	if got := len(server.Requests()); got != 7 {
+		t.Errorf("recorded %d requests, want 7", got)
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	server := NewServer(t)
+	server.SetLatency(50 * time.Millisecond)
+
+	start := time.Now()
+	get(t, server.URL()+"/categories")
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the response to be delayed, took %v", elapsed)
+	}
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 )
@@ -18,6 +20,13 @@ type Settings struct {
 	Host       string
 	Port       int
 	ENV        string
+
+	// APIV2Prefix is where the v2 API is served; v1 responses point callers at it
+	APIV2Prefix string
+	// V1Sunset is the HTTP date after which v1 may be removed, sent as the Sunset header
+	V1Sunset string
+	// GRPCPort serves the gRPC API on Host when set; 0 disables it
+	GRPCPort int
 }
 
 // ServiceConfig holds configuration for an allowed service
@@ -25,6 +34,12 @@ type ServiceConfig struct {
 	Host       string
 	ClientName string
 	SecretHash string
+	Admin      bool
+
+	// CallbackHosts lists the hosts this service may receive webhooks on
+	CallbackHosts []string
+	// CallbackSecret signs webhook payloads; falls back to SecretHash when empty
+	CallbackSecret string
 }
 
 // Config holds all configuration
@@ -37,54 +52,133 @@ type Config struct {
 	ServiceKeyName   string
 	ClientNameHeader string
 	SecretHashHeader string
+
+	// GenerationStoreSize caps how many generations are kept for feedback
+	GenerationStoreSize int
+
+	// Async task queue settings
+	TaskWorkers   int
+	TaskQueueSize int
+	TaskTTL       time.Duration
+
+	// Webhook delivery settings
+	WebhookMaxAttempts int
+	WebhookBackoff     time.Duration
+
+	// Idempotency-Key replays: how many responses are kept and for how long
+	IdempotencyStoreSize int
+	IdempotencyTTL       time.Duration
+
+	// Conversation sessions: how many are kept, how long an idle one lives, and the
+	// estimated prompt tokens of history sent before older turns are summarized
+	ConversationStoreSize int
+	ConversationTTL       time.Duration
+	ConversationMaxTokens int
+
+	// LLM response cache bounds; a size of 0 disables the cache
+	LLMCacheSize int
+	LLMCacheTTL  time.Duration
+
+	// PromptTemplatesDir overrides the embedded prompt templates when set
+	PromptTemplatesDir string
+
+	// LLMAPIURL overrides the chat completions endpoint, e.g. to point at a local fake
+	LLMAPIURL string
 }
 
 // LoadConfig loads configuration from environment variables and config.ini
 func LoadConfig() (*Config, error) {
-	cfg := &Config{
+	cfg := LoadEnv()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Load allowed services from config.ini
+	services, err := loadServicesFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services from config.ini: %w", err)
+	}
+
+	cfg.AllowedServices = services
+	cfg.AllowedOrigins = extractOrigins(services)
+
+	return cfg, nil
+}
+
+// LoadEnv reads configuration from environment variables only, without
+// validating it or loading services from config.ini. One-shot commands use it to
+// pick up the settings they need.
+func LoadEnv() *Config {
+	return &Config{
 		Settings: Settings{
-			AppName:    getEnv("APP_NAME", "Dokoola LLM Service"),
-			AppVersion: getEnv("APP_VERSION", "0.1.0"),
-			APIPrefix:  getEnv("API_PREFIX", "/api/v1"),
-			Host:       getEnv("HOST", "0.0.0.0"),
-			Port:       getEnvInt("PORT", 8000),
-			Debug:      getEnvBool("DEBUG", false) != false || os.Getenv("DEBUG") != "false",
-			ENV:        getEnv("ENV", "production"),
+			AppName:     getEnv("APP_NAME", "Dokoola LLM Service"),
+			AppVersion:  getEnv("APP_VERSION", "0.1.0"),
+			APIPrefix:   getEnv("API_PREFIX", "/api/v1"),
+			APIV2Prefix: getEnv("API_V2_PREFIX", "/api/v2"),
+			V1Sunset:    strings.TrimSpace(os.Getenv("API_V1_SUNSET")),
+			Host:        getEnv("HOST", "0.0.0.0"),
+			Port:        getEnvInt("PORT", 8000),
+			GRPCPort:    getEnvInt("GRPC_PORT", 0),
+			Debug:       getEnvBool("DEBUG", false) != false || os.Getenv("DEBUG") != "false",
+			ENV:         getEnv("ENV", "production"),
 		},
 		CerebrasAPIKey:   strings.TrimSpace(os.Getenv("LLM_API_KEY")),
 		BackendServerAPI: strings.TrimSpace(os.Getenv("BACKEND_SERVER_API")),
 		ServiceKeyName:   strings.TrimSpace(os.Getenv("X_SERVICE_KEY_NAME")),
 		ClientNameHeader: strings.TrimSpace(os.Getenv("X_SERVICE_CLIENT_NAME")),
 		SecretHashHeader: strings.TrimSpace(os.Getenv("X_SERVICE_SECRET_NAME")),
+
+		GenerationStoreSize: getEnvInt("GENERATION_STORE_SIZE", 10000),
+
+		TaskWorkers:   getEnvInt("TASK_WORKERS", 4),
+		TaskQueueSize: getEnvInt("TASK_QUEUE_SIZE", 100),
+		TaskTTL:       time.Duration(getEnvInt("TASK_TTL_MINUTES", 60)) * time.Minute,
+
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookBackoff:     time.Duration(getEnvInt("WEBHOOK_BACKOFF_SECONDS", 2)) * time.Second,
+
+		IdempotencyStoreSize: getEnvInt("IDEMPOTENCY_STORE_SIZE", 10000),
+		IdempotencyTTL:       time.Duration(getEnvInt("IDEMPOTENCY_TTL_MINUTES", 60)) * time.Minute,
+
+		ConversationStoreSize: getEnvInt("CONVERSATION_STORE_SIZE", 1000),
+		ConversationTTL:       time.Duration(getEnvInt("CONVERSATION_TTL_MINUTES", 60)) * time.Minute,
+		ConversationMaxTokens: getEnvInt("CONVERSATION_MAX_TOKENS", 8000),
+
+		LLMCacheSize: getEnvInt("LLM_CACHE_SIZE", 1000),
+		LLMCacheTTL:  time.Duration(getEnvInt("LLM_CACHE_TTL_MINUTES", 60)) * time.Minute,
+
+		PromptTemplatesDir: strings.TrimSpace(os.Getenv("PROMPT_TEMPLATES_DIR")),
+
+		LLMAPIURL: strings.TrimSpace(os.Getenv("LLM_API_URL")),
 	}
+}
 
-	// Validate required environment variables
+// Validate checks that the required environment variables are set
+func (cfg *Config) Validate() error {
 	if cfg.CerebrasAPIKey == "" {
-		return nil, fmt.Errorf("LLM_API_KEY environment variable is required")
+		return fmt.Errorf("LLM_API_KEY environment variable is required")
 	}
 	if cfg.BackendServerAPI == "" {
-		return nil, fmt.Errorf("BACKEND_SERVER_API environment variable is required")
+		return fmt.Errorf("BACKEND_SERVER_API environment variable is required")
 	}
 	if cfg.ServiceKeyName == "" {
-		return nil, fmt.Errorf("X_SERVICE_KEY_NAME environment variable is required")
+		return fmt.Errorf("X_SERVICE_KEY_NAME environment variable is required")
 	}
 	if cfg.ClientNameHeader == "" {
-		return nil, fmt.Errorf("X_SERVICE_CLIENT_NAME environment variable is required")
+		return fmt.Errorf("X_SERVICE_CLIENT_NAME environment variable is required")
 	}
 	if cfg.SecretHashHeader == "" {
-		return nil, fmt.Errorf("X_SERVICE_SECRET_NAME environment variable is required")
+		return fmt.Errorf("X_SERVICE_SECRET_NAME environment variable is required")
 	}
-
-	// Load allowed services from config.ini
-	services, err := loadServicesFromConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load services from config.ini: %w", err)
+	if cfg.Settings.GRPCPort != 0 && cfg.Settings.GRPCPort == cfg.Settings.Port {
+		return fmt.Errorf("GRPC_PORT must differ from PORT (%d)", cfg.Settings.Port)
 	}
-
-	cfg.AllowedServices = services
-	cfg.AllowedOrigins = extractOrigins(services)
-
-	return cfg, nil
+	if cfg.Settings.V1Sunset != "" {
+		if _, err := http.ParseTime(cfg.Settings.V1Sunset); err != nil {
+			return fmt.Errorf("API_V1_SUNSET must be an HTTP date such as %q: %w", "Wed, 31 Dec 2025 23:59:59 GMT", err)
+		}
+	}
+	return nil
 }
 
 // loadServicesFromConfig loads allowed services from config.ini file
@@ -112,9 +206,12 @@ func loadServicesFromConfig() (map[string]ServiceConfig, error) {
 			serviceKey := name[8:] // Remove "SERVICE_" prefix to get "DKL..."
 
 			services[serviceKey] = ServiceConfig{
-				Host:       section.Key("host").String(),
-				ClientName: section.Key("client_name").String(),
-				SecretHash: section.Key("secret_hash").String(),
+				Host:           section.Key("host").String(),
+				ClientName:     section.Key("client_name").String(),
+				SecretHash:     section.Key("secret_hash").String(),
+				Admin:          section.Key("admin").MustBool(false),
+				CallbackHosts:  splitList(section.Key("callback_hosts").String()),
+				CallbackSecret: section.Key("callback_secret").String(),
 			}
 		}
 	}
@@ -135,6 +232,17 @@ func extractOrigins(services map[string]ServiceConfig) []string {
 	return origins
 }
 
+// splitList splits a comma-separated config value into trimmed, non-empty items
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Helper functions to get environment variables with defaults
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
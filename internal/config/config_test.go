@@ -238,3 +238,74 @@ func TestConfigStructure(t *testing.T) {
 		t.Errorf("expected CerebrasAPIKey 'key123', got %q", cfg.CerebrasAPIKey)
 	}
 }
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "empty", value: "", expected: []string{}},
+		{name: "single", value: "hooks.dokoola.com", expected: []string{"hooks.dokoola.com"}},
+		{name: "trims and skips blanks", value: " a.com , ,b.com ", expected: []string{"a.com", "b.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitList(tt.value)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, result)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEnvAndValidate(t *testing.T) {
+	t.Setenv("LLM_API_KEY", " key123 ")
+	t.Setenv("BACKEND_SERVER_API", "https://backend.example.com")
+	t.Setenv("X_SERVICE_KEY_NAME", "X-Service-Key")
+	t.Setenv("X_SERVICE_CLIENT_NAME", "X-Client-Name")
+	t.Setenv("X_SERVICE_SECRET_NAME", "")
+
+	cfg := LoadEnv()
+	if cfg.CerebrasAPIKey != "key123" {
+		t.Errorf("expected CerebrasAPIKey 'key123', got %q", cfg.CerebrasAPIKey)
+	}
+	if cfg.AllowedServices != nil {
+		t.Error("expected LoadEnv not to load services")
+	}
+
+	if err := cfg.Validate(); err == nil || err.Error() != "X_SERVICE_SECRET_NAME environment variable is required" {
+		t.Errorf("expected a missing X_SERVICE_SECRET_NAME error, got %v", err)
+	}
+
+	cfg.SecretHashHeader = "X-Secret-Hash"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a valid config, got %v", err)
+	}
+	if cfg.Settings.APIV2Prefix != "/api/v2" {
+		t.Errorf("expected APIV2Prefix '/api/v2', got %q", cfg.Settings.APIV2Prefix)
+	}
+
+	cfg.Settings.V1Sunset = "next year"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an invalid API_V1_SUNSET error")
+	}
+	cfg.Settings.V1Sunset = "Wed, 31 Dec 2025 23:59:59 GMT"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a valid sunset date, got %v", err)
+	}
+
+	if cfg.Settings.GRPCPort != 0 {
+		t.Errorf("expected gRPC to be disabled by default, got port %d", cfg.Settings.GRPCPort)
+	}
+	cfg.Settings.GRPCPort = cfg.Settings.Port
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error when GRPC_PORT equals PORT")
+	}
+}
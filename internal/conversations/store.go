@@ -0,0 +1,192 @@
+// Package conversations keeps chat sessions in memory between turns, so a user can
+// refine a completion ("make it shorter") without resending the history.
+package conversations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound is returned when a conversation ID is unknown, expired, evicted, or
+	// owned by another service
+	ErrNotFound = errors.New("conversations: not found")
+	// ErrConflict is returned when saving a conversation that changed since it was read
+	ErrConflict = errors.New("conversations: updated concurrently")
+)
+
+// entry is a stored conversation and the service that owns it
+type entry struct {
+	owner string
+	conv  models.Conversation
+}
+
+// Store keeps conversations until they have been idle for ttl. Once maxEntries is
+// reached the least recently updated conversation is evicted.
+type Store struct {
+	mu           sync.Mutex
+	items        map[string]*entry
+	maxEntries   int
+	ttl          time.Duration
+	windowTokens int
+	lastSweep    time.Time
+	logger       *zap.Logger
+}
+
+// NewStore creates a new conversation store. windowTokens is the estimated prompt
+// token budget for a conversation's history.
+func NewStore(maxEntries int, ttl time.Duration, windowTokens int, logger *zap.Logger) *Store {
+	return &Store{
+		items:        make(map[string]*entry),
+		maxEntries:   maxEntries,
+		ttl:          ttl,
+		windowTokens: windowTokens,
+		lastSweep:    time.Now(),
+		logger:       logger,
+	}
+}
+
+// WindowTokens returns the estimated prompt token budget for a conversation's history
+func (s *Store) WindowTokens() int {
+	return s.windowTokens
+}
+
+// Create starts an empty conversation owned by the given service
+func (s *Store) Create(owner, userID, locale string) models.Conversation {
+	now := time.Now()
+	conv := models.Conversation{
+		ID:        newID(),
+		UserID:    userID,
+		Locale:    locale,
+		Messages:  []models.ConversationMessage{},
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	s.items[conv.ID] = &entry{owner: owner, conv: conv}
+
+	// Evict the least recently updated conversations once over capacity
+	for s.maxEntries > 0 && len(s.items) > s.maxEntries {
+		s.evictOldest()
+	}
+
+	return clone(conv)
+}
+
+// Get returns a copy of a conversation owned by the given service
+func (s *Store) Get(owner, id string) (models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(owner, id, time.Now())
+	if err != nil {
+		return models.Conversation{}, err
+	}
+	return clone(e.conv), nil
+}
+
+// Save replaces a conversation with an updated copy read from Get, and extends its
+// lifetime. ErrConflict is returned if another update was saved in between, so two
+// turns sent at once can't overwrite each other.
+func (s *Store) Save(owner string, conv models.Conversation) (models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	e, err := s.lookup(owner, conv.ID, now)
+	if err != nil {
+		return models.Conversation{}, err
+	}
+	if !e.conv.UpdatedAt.Equal(conv.UpdatedAt) {
+		return models.Conversation{}, ErrConflict
+	}
+
+	conv.UpdatedAt = now
+	conv.ExpiresAt = now.Add(s.ttl)
+	e.conv = clone(conv)
+
+	return clone(conv), nil
+}
+
+// Delete removes a conversation owned by the given service and returns it
+func (s *Store) Delete(owner, id string) (models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(owner, id, time.Now())
+	if err != nil {
+		return models.Conversation{}, err
+	}
+	delete(s.items, id)
+	return e.conv, nil
+}
+
+// lookup finds a live conversation, dropping it if it has expired. Callers must hold
+// the lock.
+func (s *Store) lookup(owner, id string, now time.Time) (*entry, error) {
+	e, ok := s.items[id]
+	if !ok || e.owner != owner {
+		return nil, ErrNotFound
+	}
+	if now.After(e.conv.ExpiresAt) {
+		delete(s.items, id)
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// evictOldest removes the least recently updated conversation. Callers must hold
+// the lock.
+func (s *Store) evictOldest() {
+	var oldest *entry
+	for _, e := range s.items {
+		if oldest == nil || e.conv.UpdatedAt.Before(oldest.conv.UpdatedAt) {
+			oldest = e
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	delete(s.items, oldest.conv.ID)
+
+	s.logger.Debug("Conversation evicted", zap.String("conversation_id", oldest.conv.ID))
+}
+
+// sweep removes expired conversations at most once per minute. Callers must hold
+// the lock.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for id, e := range s.items {
+		if now.After(e.conv.ExpiresAt) {
+			delete(s.items, id)
+		}
+	}
+}
+
+// clone copies a conversation so callers can't modify the stored messages
+func clone(conv models.Conversation) models.Conversation {
+	conv.Messages = append([]models.ConversationMessage{}, conv.Messages...)
+	return conv
+}
+
+// newID generates a random conversation ID
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "conv_" + hex.EncodeToString(b)
+}
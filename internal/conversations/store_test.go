@@ -0,0 +1,106 @@
+package conversations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestStoreCreateGetSave(t *testing.T) {
+	store := NewStore(10, time.Hour, 1000, zap.NewNop())
+
+	conv := store.Create("DKL_WEB", "user-1", "en")
+	if conv.ID == "" || conv.UserID != "user-1" || conv.Locale != "en" {
+		t.Fatalf("unexpected conversation: %+v", conv)
+	}
+
+	got, err := store.Get("DKL_WEB", conv.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got.Messages = append(got.Messages, models.ConversationMessage{Role: models.ConversationRoleUser, Content: "Hi"})
+
+	saved, err := store.Save("DKL_WEB", got)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(saved.Messages) != 1 || !saved.UpdatedAt.After(conv.UpdatedAt) || !saved.ExpiresAt.After(conv.ExpiresAt) {
+		t.Errorf("unexpected saved conversation: %+v", saved)
+	}
+
+	// Changing a returned copy doesn't change the stored conversation
+	saved.Messages[0].Content = "changed"
+	if got, _ := store.Get("DKL_WEB", conv.ID); got.Messages[0].Content != "Hi" {
+		t.Errorf("stored message = %q", got.Messages[0].Content)
+	}
+}
+
+func TestStoreSaveConflict(t *testing.T) {
+	store := NewStore(10, time.Hour, 1000, zap.NewNop())
+	conv := store.Create("DKL_WEB", "", "en")
+
+	first, _ := store.Get("DKL_WEB", conv.ID)
+	second, _ := store.Get("DKL_WEB", conv.ID)
+
+	if _, err := store.Save("DKL_WEB", first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.Save("DKL_WEB", second); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a stale copy error = %v, want ErrConflict", err)
+	}
+}
+
+func TestStoreScopedToOwner(t *testing.T) {
+	store := NewStore(10, time.Hour, 1000, zap.NewNop())
+	conv := store.Create("DKL_WEB", "", "en")
+
+	if _, err := store.Get("DKL_OTHER", conv.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() by another service error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Delete("DKL_OTHER", conv.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() by another service error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := store.Delete("DKL_WEB", conv.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get("DKL_WEB", conv.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreExpiresIdleConversations(t *testing.T) {
+	store := NewStore(10, time.Millisecond, 1000, zap.NewNop())
+	conv := store.Create("DKL_WEB", "", "en")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := store.Get("DKL_WEB", conv.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of an expired conversation error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUpdated(t *testing.T) {
+	store := NewStore(2, time.Hour, 1000, zap.NewNop())
+
+	first := store.Create("DKL_WEB", "", "en")
+	second := store.Create("DKL_WEB", "", "en")
+
+	// Touching the first conversation makes the second the least recently updated
+	got, _ := store.Get("DKL_WEB", first.ID)
+	if _, err := store.Save("DKL_WEB", got); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	store.Create("DKL_WEB", "", "en")
+
+	if _, err := store.Get("DKL_WEB", second.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the idle conversation to be evicted, got %v", err)
+	}
+	if _, err := store.Get("DKL_WEB", first.ID); err != nil {
+		t.Errorf("expected the active conversation to be kept, got %v", err)
+	}
+}
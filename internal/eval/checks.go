@@ -0,0 +1,88 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dokoola/llm-go/internal/postprocess"
+)
+
+// CheckResult is the outcome of one automatic check
+type CheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// constraintRules are the template constraints every output is checked against
+var constraintRules = []string{
+	postprocess.RuleMaxLength,
+	postprocess.RuleBannedCharacter,
+	postprocess.RuleBannedPhrase,
+	postprocess.RulePreamble,
+}
+
+// runChecks checks a raw completion against the template's post-processing
+// constraints and the case's expectations
+func runChecks(c Case, chain postprocess.Chain, completion string) []CheckResult {
+	// The chain reports what production would have to fix or re-prompt for
+	violations := map[string][]string{}
+	for _, v := range chain.Run(completion, false).Violations {
+		violations[v.Rule] = append(violations[v.Rule], v.Message)
+	}
+
+	checks := make([]CheckResult, 0, len(constraintRules)+len(c.Expect.Mentions)+3)
+	for _, rule := range constraintRules {
+		messages := violations[rule]
+		checks = append(checks, CheckResult{
+			Name:   rule,
+			Passed: len(messages) == 0,
+			Detail: strings.Join(messages, "; "),
+		})
+	}
+
+	if max := c.Expect.MaxChars; max > 0 {
+		length := utf8.RuneCountInString(completion)
+		check := CheckResult{Name: "max_chars", Passed: length <= max}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("%d characters exceeds %d", length, max)
+		}
+		checks = append(checks, check)
+	}
+
+	if len(c.Expect.ForbiddenChars) > 0 {
+		check := CheckResult{Name: "forbidden_chars", Passed: true}
+		var found []string
+		for _, char := range c.Expect.ForbiddenChars {
+			if strings.Contains(completion, char) {
+				found = append(found, fmt.Sprintf("%q", char))
+			}
+		}
+		if len(found) > 0 {
+			check.Passed = false
+			check.Detail = "contains " + strings.Join(found, ", ")
+		}
+		checks = append(checks, check)
+	}
+
+	lower := strings.ToLower(completion)
+	for _, mention := range c.Expect.Mentions {
+		check := CheckResult{Name: "mentions " + mention, Passed: strings.Contains(lower, strings.ToLower(mention))}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("%q not mentioned", mention)
+		}
+		checks = append(checks, check)
+	}
+
+	if c.Expect.JSON {
+		check := CheckResult{Name: "json", Passed: json.Valid([]byte(strings.TrimSpace(completion)))}
+		if !check.Passed {
+			check.Detail = "output is not valid JSON"
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
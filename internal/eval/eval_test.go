@@ -0,0 +1,201 @@
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// fakeProvider returns a canned completion per version
+type fakeProvider map[string]string
+
+func (p fakeProvider) Complete(req Request) (string, error) {
+	completion, ok := p[req.Version]
+	if !ok {
+		return "", fmt.Errorf("no completion for %s", req.Version)
+	}
+	return completion, nil
+}
+
+type fakeJudge struct{}
+
+func (fakeJudge) Score(rubric, prompt, completion string) (Score, error) {
+	return Score{Value: float64(len(completion)%5 + 1), Reason: "ok"}, nil
+}
+
+// newVersionedRegistry loads the embedded templates with a second talent_bio version
+func newVersionedRegistry(t *testing.T) *prompts.Registry {
+	t.Helper()
+	dir := t.TempDir()
+
+	defaults, err := os.ReadDir("../prompts/templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range defaults {
+		raw, err := os.ReadFile(filepath.Join("../prompts/templates", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := `{"description": "bio", "max_chars": {"short": 300, "medium": 500, "detailed": 800},
+		"versions": [{"name": "v1", "file": "talent_bio.tmpl", "weight": 50}, {"name": "v2", "file": "talent_bio.v2.tmpl", "weight": 50}]}`
+	files := map[string]string{
+		"talent_bio.json":    manifest,
+		"talent_bio.v2.tmpl": `{{define "user"}}Write a bio for {{.FullName}} in {{.MaxChars}} characters.{{end}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := prompts.NewRegistry(dir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return registry
+}
+
+func TestRunChecks(t *testing.T) {
+	registry := newVersionedRegistry(t)
+	tmpl, _ := registry.Get(models.PromptTalentBio)
+
+	c := Case{
+		Template: models.PromptTalentBio,
+		Name:     "checks",
+		Data:     map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+		Length:   models.LengthShort,
+		Expect: Expectations{
+			Mentions:       []string{"go", "Kubernetes"},
+			ForbiddenChars: []string{"#"},
+			JSON:           true,
+		},
+	}
+	rendered, err := tmpl.Render(prompts.RenderOptions{Version: "v1", Length: c.Length}, c.Data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	completion := "Here is your bio:\n\n# I build Go services — reliably. " + strings.Repeat("x", 300)
+	got := map[string]bool{}
+	for _, check := range runChecks(c, rendered.PostProcess, completion) {
+		got[check.Name] = check.Passed
+		if !check.Passed && check.Detail == "" {
+			t.Errorf("failed check %s has no detail", check.Name)
+		}
+	}
+
+	want := map[string]bool{
+		"max_length":          false,
+		"banned_character":    false,
+		"banned_phrase":       true,
+		"preamble":            false,
+		"forbidden_chars":     false,
+		"mentions go":         true,
+		"mentions Kubernetes": false,
+		"json":                false,
+	}
+	for name, passed := range want {
+		if p, ok := got[name]; !ok || p != passed {
+			t.Errorf("check %s passed = %v (present %v), want %v", name, p, ok, passed)
+		}
+	}
+}
+
+func TestRunnerComparesVersions(t *testing.T) {
+	registry := newVersionedRegistry(t)
+	provider := fakeProvider{
+		"v1": "I build Go services that scale. Let's talk.",
+		"v2": "As an AI language model, I build Go services.",
+	}
+	cases := []Case{{
+		Template: models.PromptTalentBio,
+		Name:     "engineer",
+		Data:     map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+		Expect:   Expectations{Mentions: []string{"Go"}},
+	}, {
+		Template: models.PromptClientAboutUs,
+		Name:     "no_v2",
+		Data:     map[string]interface{}{"profile": map[string]interface{}{"name": "Acme"}},
+	}}
+
+	report := NewRunner(registry, provider, fakeJudge{}, zap.NewNop()).Run(cases, []string{"v1", "v2"})
+
+	if len(report.Regressions) != 1 || report.Regressions[0] != "talent_bio/engineer" {
+		t.Errorf("Regressions = %v, want [talent_bio/engineer]", report.Regressions)
+	}
+
+	v1, v2 := report.Summaries[0], report.Summaries[1]
+	if v1.Passed != 2 || v1.Errors != 0 {
+		t.Errorf("v1 summary = %+v", v1)
+	}
+	// client_about_us has no v2, so it errors rather than counting as a regression
+	if v2.Passed != 0 || v2.Errors != 1 {
+		t.Errorf("v2 summary = %+v", v2)
+	}
+	if v1.AvgScore == nil {
+		t.Error("expected judge scores")
+	}
+
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for _, want := range []string{"# Prompt evaluation: v1 vs v2", "- talent_bio/engineer", "**FAIL** 1 sentence(s) contain", "unknown version v2"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	req := Request{Case: Case{Template: models.PromptTalentBio, Name: "engineer"}, Version: "v1"}
+
+	recorder := Recorder{Provider: fakeProvider{"v1": "recorded bio"}, Dir: dir}
+	if _, err := recorder.Complete(req); err != nil {
+		t.Fatalf("Recorder.Complete() error = %v", err)
+	}
+
+	got, err := RecordedProvider{Dir: dir}.Complete(req)
+	if err != nil || got != "recorded bio" {
+		t.Errorf("RecordedProvider.Complete() = %q, %v", got, err)
+	}
+
+	req.Version = "v2"
+	if _, err := (RecordedProvider{Dir: dir}).Complete(req); err == nil {
+		t.Error("expected an error for a missing recording")
+	}
+}
+
+func TestFixturesRender(t *testing.T) {
+	// The shipped golden dataset must stay valid for the embedded templates
+	cases, err := LoadCases("../../eval/fixtures", "")
+	if err != nil {
+		t.Fatalf("LoadCases() error = %v", err)
+	}
+
+	registry, err := prompts.DefaultRegistry()
+	if err != nil {
+		t.Fatalf("DefaultRegistry() error = %v", err)
+	}
+
+	report := NewRunner(registry, fakeProvider{"v1": "ok"}, nil, zap.NewNop()).Run(cases, []string{"v1"})
+	for _, cr := range report.Cases {
+		if err := cr.Results[0].Error; err != "" {
+			t.Errorf("%s: %s", cr.ID, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Case is one fixture input for a template. Fixtures live in
+// <dir>/<template>/<case>.json; the case name defaults to the file name.
+type Case struct {
+	Template models.PromptTemplateEnum      `json:"-"`
+	Name     string                         `json:"name"`
+	Data     map[string]interface{}         `json:"data"`
+	Tone     models.ModelTuneEnum           `json:"tone,omitempty"`
+	Length   models.ModelResponseLengthEnum `json:"length,omitempty"`
+	Locale   string                         `json:"locale,omitempty"`
+	Expect   Expectations                   `json:"expect"`
+	// Rubric is what the judge scores the output against; DefaultRubric when empty
+	Rubric string `json:"rubric,omitempty"`
+}
+
+// ID identifies a case across templates
+func (c Case) ID() string {
+	return string(c.Template) + "/" + c.Name
+}
+
+// Expectations are case-specific checks on top of the template's own constraints
+type Expectations struct {
+	// Mentions must each appear in the output, ignoring case (e.g. skills, the budget)
+	Mentions []string `json:"mentions,omitempty"`
+	// MaxChars is a length limit for cases whose template sets none
+	MaxChars int `json:"max_chars,omitempty"`
+	// ForbiddenChars must not appear in the output
+	ForbiddenChars []string `json:"forbidden_chars,omitempty"`
+	// JSON requires the output to be valid JSON
+	JSON bool `json:"json,omitempty"`
+}
+
+// LoadCases reads every fixture under dir, optionally limited to one template.
+// Cases are sorted by template, then name.
+func LoadCases(dir string, template models.PromptTemplateEnum) ([]Case, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	for _, path := range paths {
+		name := models.PromptTemplateEnum(filepath.Base(filepath.Dir(path)))
+		if template != "" && name != template {
+			continue
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read fixture %s: %w", path, err)
+		}
+
+		c := Case{}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+		}
+		c.Template = name
+		if c.Name == "" {
+			c.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		if c.Data == nil {
+			return nil, fmt.Errorf("fixture %s: data is required", path)
+		}
+
+		cases = append(cases, c)
+	}
+
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+
+	sort.Slice(cases, func(i, j int) bool { return cases[i].ID() < cases[j].ID() })
+	return cases, nil
+}
@@ -0,0 +1,142 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/llm"
+)
+
+// Request is a rendered case sent to a provider
+type Request struct {
+	Case    Case
+	Version string
+	Prompt  string
+	Options llm.Options
+}
+
+// Provider produces the completion for a rendered case
+type Provider interface {
+	Complete(req Request) (string, error)
+}
+
+// LLMProvider gets completions from the configured LLM
+type LLMProvider struct {
+	Client *llm.Client
+}
+
+func (p LLMProvider) Complete(req Request) (string, error) {
+	return p.Client.CompleteWithOptions(context.Background(), req.Prompt, nil, req.Options)
+}
+
+// ErrNoRecording is returned when a recorded provider has no response for a case
+var ErrNoRecording = errors.New("eval: no recorded response")
+
+// RecordedProvider replays responses saved as <dir>/<template>/<case>.<version>.txt,
+// so a dataset can be re-checked without calling the LLM
+type RecordedProvider struct {
+	Dir string
+}
+
+func (p RecordedProvider) Complete(req Request) (string, error) {
+	raw, err := os.ReadFile(recordingPath(p.Dir, req))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w for %s version %s", ErrNoRecording, req.Case.ID(), req.Version)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// Recorder saves every completion from a provider in the layout RecordedProvider reads
+type Recorder struct {
+	Provider Provider
+	Dir      string
+}
+
+func (r Recorder) Complete(req Request) (string, error) {
+	completion, err := r.Provider.Complete(req)
+	if err != nil {
+		return "", err
+	}
+
+	path := recordingPath(r.Dir, req)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(completion), 0o644); err != nil {
+		return "", err
+	}
+	return completion, nil
+}
+
+// recordingPath is where the response for a case and version is stored
+func recordingPath(dir string, req Request) string {
+	return filepath.Join(dir, string(req.Case.Template), req.Case.Name+"."+req.Version+".txt")
+}
+
+// DefaultRubric is used to judge cases that don't set their own
+const DefaultRubric = `Score how well the output does the job the prompt asks for:
+5 - ready to publish: specific, accurate to the input, natural and within every stated constraint
+4 - good, with minor wording or emphasis issues
+3 - usable after edits; generic in places or misses a requested detail
+2 - largely generic, inaccurate or ignores key instructions
+1 - unusable`
+
+// Score is a judge's rating of an output, from 1 to 5
+type Score struct {
+	Value  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// Judge rates an output against a rubric
+type Judge interface {
+	Score(rubric, prompt, completion string) (Score, error)
+}
+
+// LLMJudge asks the LLM to grade outputs
+type LLMJudge struct {
+	Client *llm.Client
+}
+
+var jsonObjectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+func (j LLMJudge) Score(rubric, prompt, completion string) (Score, error) {
+	judgePrompt := fmt.Sprintf(`You are grading the output of a writing assistant.
+
+Rubric:
+%s
+
+=== PROMPT ===
+%s
+
+=== OUTPUT ===
+%s
+
+Reply with only a JSON object: {"score": <1-5>, "reason": "<one sentence>"}`, rubric, prompt, completion)
+
+	opts := llm.DefaultOptions()
+	opts.Temperature = 0
+	opts.MaxTokens = 1024
+
+	reply, err := j.Client.CompleteWithOptions(context.Background(), judgePrompt, nil, opts)
+	if err != nil {
+		return Score{}, err
+	}
+
+	var score Score
+	if err := json.Unmarshal([]byte(jsonObjectPattern.FindString(strings.TrimSpace(reply))), &score); err != nil {
+		return Score{}, fmt.Errorf("failed to parse judge reply %q: %w", reply, err)
+	}
+	if score.Value < 1 || score.Value > 5 {
+		return Score{}, fmt.Errorf("judge score %v out of range", score.Value)
+	}
+	return score, nil
+}
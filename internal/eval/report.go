@@ -0,0 +1,253 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report compares fixture results across template versions
+type Report struct {
+	// Versions are the compared versions as requested; "current" is each
+	// template's highest-weight version
+	Versions  []string     `json:"versions"`
+	Judged    bool         `json:"judged"`
+	Summaries []Summary    `json:"summaries"`
+	Cases     []CaseReport `json:"cases"`
+	// Regressions are cases that pass on the first version and fail on the second;
+	// Improvements the other way round
+	Regressions  []string `json:"regressions,omitempty"`
+	Improvements []string `json:"improvements,omitempty"`
+}
+
+// CaseReport holds a case's result for each version, in the report's version order
+type CaseReport struct {
+	ID      string    `json:"id"`
+	Results []*Result `json:"results"`
+}
+
+// Summary aggregates a version's results
+type Summary struct {
+	Version      string   `json:"version"`
+	Cases        int      `json:"cases"`
+	Passed       int      `json:"passed"`
+	Errors       int      `json:"errors"`
+	ChecksPassed int      `json:"checks_passed"`
+	ChecksTotal  int      `json:"checks_total"`
+	AvgChars     float64  `json:"avg_chars"`
+	AvgScore     *float64 `json:"avg_score,omitempty"`
+}
+
+func newReport(versions []string, judged bool) *Report {
+	labels := make([]string, len(versions))
+	for i, v := range versions {
+		labels[i] = v
+		if v == "" {
+			labels[i] = "current"
+		}
+	}
+	return &Report{Versions: labels, Judged: judged}
+}
+
+// add records a case's results and updates the summaries and comparison
+func (r *Report) add(c Case, results []*Result) {
+	r.Cases = append(r.Cases, CaseReport{ID: c.ID(), Results: results})
+
+	if len(results) == 2 && results[0].Error == "" && results[1].Error == "" {
+		switch {
+		case results[0].Passed() && !results[1].Passed():
+			r.Regressions = append(r.Regressions, c.ID())
+		case !results[0].Passed() && results[1].Passed():
+			r.Improvements = append(r.Improvements, c.ID())
+		}
+	}
+
+	r.summarize()
+}
+
+// summarize recomputes the per-version summaries
+func (r *Report) summarize() {
+	r.Summaries = make([]Summary, len(r.Versions))
+	for i, version := range r.Versions {
+		s := Summary{Version: version}
+		chars, scores, scored := 0, 0.0, 0
+
+		for _, cr := range r.Cases {
+			result := cr.Results[i]
+			s.Cases++
+			if result.Error != "" {
+				s.Errors++
+				continue
+			}
+			if result.Passed() {
+				s.Passed++
+			}
+			for _, check := range result.Checks {
+				s.ChecksTotal++
+				if check.Passed {
+					s.ChecksPassed++
+				}
+			}
+			chars += result.Chars
+			if result.Score != nil {
+				scores += result.Score.Value
+				scored++
+			}
+		}
+
+		if ran := s.Cases - s.Errors; ran > 0 {
+			s.AvgChars = float64(chars) / float64(ran)
+		}
+		if scored > 0 {
+			avg := scores / float64(scored)
+			s.AvgScore = &avg
+		}
+		r.Summaries[i] = s
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the report as a markdown document
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Prompt evaluation: %s\n\n", strings.Join(r.Versions, " vs "))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString(tableRow(append([]string{""}, r.Versions...)))
+	b.WriteString(tableRule(len(r.Versions) + 1))
+	b.WriteString(r.summaryRow("Cases passing every check", func(s Summary) string { return fmt.Sprintf("%d/%d", s.Passed, s.Cases) }))
+	b.WriteString(r.summaryRow("Checks passed", func(s Summary) string { return fmt.Sprintf("%d/%d", s.ChecksPassed, s.ChecksTotal) }))
+	b.WriteString(r.summaryRow("Errors", func(s Summary) string { return fmt.Sprintf("%d", s.Errors) }))
+	b.WriteString(r.summaryRow("Average length (chars)", func(s Summary) string { return fmt.Sprintf("%.0f", s.AvgChars) }))
+	if r.Judged {
+		b.WriteString(r.summaryRow("Average judge score", func(s Summary) string {
+			if s.AvgScore == nil {
+				return "-"
+			}
+			return fmt.Sprintf("%.2f", *s.AvgScore)
+		}))
+	}
+
+	if len(r.Versions) == 2 {
+		fmt.Fprintf(&b, "\n## Regressions (pass on %s, fail on %s)\n\n", r.Versions[0], r.Versions[1])
+		writeList(&b, r.Regressions)
+		fmt.Fprintf(&b, "\n## Improvements (fail on %s, pass on %s)\n\n", r.Versions[0], r.Versions[1])
+		writeList(&b, r.Improvements)
+	}
+
+	b.WriteString("\n## Cases\n")
+	for _, cr := range r.Cases {
+		fmt.Fprintf(&b, "\n### %s\n\n", cr.ID)
+		r.writeCase(&b, cr)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCase writes a table with one row per check and one column per version
+func (r *Report) writeCase(b *strings.Builder, cr CaseReport) {
+	header := []string{"Check"}
+	for i, result := range cr.Results {
+		label := r.Versions[i]
+		if result.Version != label {
+			label = fmt.Sprintf("%s (%s)", label, result.Version)
+		}
+		header = append(header, label)
+	}
+	b.WriteString(tableRow(header))
+	b.WriteString(tableRule(len(header)))
+
+	// Checks are the same for every version that ran; take the names from the first
+	var names []string
+	for _, result := range cr.Results {
+		if result.Error == "" {
+			for _, check := range result.Checks {
+				names = append(names, check.Name)
+			}
+			break
+		}
+	}
+
+	for i, name := range names {
+		row := []string{name}
+		for _, result := range cr.Results {
+			switch {
+			case result.Error != "":
+				row = append(row, "error")
+			case result.Checks[i].Passed:
+				row = append(row, "pass")
+			default:
+				row = append(row, "**FAIL** "+escapeCell(result.Checks[i].Detail))
+			}
+		}
+		b.WriteString(tableRow(row))
+	}
+
+	row := []string{"Length (chars)"}
+	for _, result := range cr.Results {
+		if result.Error != "" {
+			row = append(row, "-")
+			continue
+		}
+		row = append(row, fmt.Sprintf("%d", result.Chars))
+	}
+	b.WriteString(tableRow(row))
+
+	if r.Judged {
+		row := []string{"Judge score"}
+		for _, result := range cr.Results {
+			if result.Score == nil {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.0f: %s", result.Score.Value, escapeCell(result.Score.Reason)))
+		}
+		b.WriteString(tableRow(row))
+	}
+
+	for i, result := range cr.Results {
+		if result.Error != "" {
+			fmt.Fprintf(b, "\n%s error: %s\n", r.Versions[i], result.Error)
+		}
+	}
+}
+
+func (r *Report) summaryRow(label string, value func(Summary) string) string {
+	row := []string{label}
+	for _, s := range r.Summaries {
+		row = append(row, value(s))
+	}
+	return tableRow(row)
+}
+
+func tableRow(cells []string) string {
+	return "| " + strings.Join(cells, " | ") + " |\n"
+}
+
+func tableRule(columns int) string {
+	return "|" + strings.Repeat("---|", columns) + "\n"
+}
+
+func writeList(b *strings.Builder, items []string) {
+	if len(items) == 0 {
+		b.WriteString("None\n")
+		return
+	}
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+}
+
+// escapeCell keeps text from breaking a markdown table row
+func escapeCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"unicode/utf8"
+
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// Result is the outcome of one case on one template version
+type Result struct {
+	// Version is the template version that was rendered
+	Version    string        `json:"version"`
+	Completion string        `json:"completion,omitempty"`
+	Chars      int           `json:"chars"`
+	Checks     []CheckResult `json:"checks,omitempty"`
+	Score      *Score        `json:"score,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Passed reports whether the case ran and every check passed
+func (r *Result) Passed() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner renders fixture cases and checks the completions
+type Runner struct {
+	registry *prompts.Registry
+	provider Provider
+	// judge is optional; without one, outputs are not scored
+	judge  Judge
+	logger *zap.Logger
+}
+
+// NewRunner creates a new runner. judge may be nil.
+func NewRunner(registry *prompts.Registry, provider Provider, judge Judge, logger *zap.Logger) *Runner {
+	return &Runner{
+		registry: registry,
+		provider: provider,
+		judge:    judge,
+		logger:   logger,
+	}
+}
+
+// Run evaluates every case against each version. An empty version name runs the
+// template's current version.
+func (r *Runner) Run(cases []Case, versions []string) *Report {
+	report := newReport(versions, r.judge != nil)
+
+	for _, c := range cases {
+		results := make([]*Result, len(versions))
+		for i, version := range versions {
+			results[i] = r.runCase(c, version)
+		}
+		report.add(c, results)
+	}
+
+	return report
+}
+
+// runCase renders a case with one version, gets its completion and checks it
+func (r *Runner) runCase(c Case, version string) *Result {
+	result := &Result{Version: version}
+
+	t, ok := r.registry.Get(c.Template)
+	if !ok {
+		result.Error = "unknown template: " + string(c.Template)
+		return result
+	}
+	if version == "" {
+		result.Version = t.Current().Name
+	}
+
+	loc, err := locale.Parse(c.Locale)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	rendered, err := t.Render(prompts.RenderOptions{
+		Version: result.Version,
+		Tone:    c.Tone,
+		Length:  c.Length,
+		Locale:  loc,
+	}, c.Data, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	opts := rendered.Params.Apply(llm.DefaultOptions())
+	opts.SystemPrompt = rendered.System
+
+	completion, err := r.provider.Complete(Request{Case: c, Version: result.Version, Prompt: rendered.User, Options: opts})
+	if err != nil {
+		r.logger.Warn("Eval completion failed", zap.String("case", c.ID()), zap.String("version", result.Version), zap.Error(err))
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Completion = completion
+	result.Chars = utf8.RuneCountInString(completion)
+	result.Checks = runChecks(c, rendered.PostProcess, completion)
+
+	if r.judge != nil {
+		rubric := c.Rubric
+		if rubric == "" {
+			rubric = DefaultRubric
+		}
+		score, err := r.judge.Score(rubric, rendered.User, completion)
+		if err != nil {
+			r.logger.Warn("Eval judge failed", zap.String("case", c.ID()), zap.String("version", result.Version), zap.Error(err))
+		} else {
+			result.Score = &score
+		}
+	}
+
+	return result
+}
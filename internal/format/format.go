@@ -0,0 +1,305 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Default is the format generated content is returned in when a request does not
+// ask for one
+const Default = models.FormatMarkdown
+
+// Formats returns the supported output formats
+func Formats() []models.OutputFormatEnum {
+	return []models.OutputFormatEnum{models.FormatMarkdown, models.FormatHTML, models.FormatText}
+}
+
+// Parse validates a requested format. An empty value returns the default format.
+func Parse(value string) (models.OutputFormatEnum, error) {
+	if value == "" {
+		return Default, nil
+	}
+	for _, f := range Formats() {
+		if string(f) == value {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q: must be one of markdown, html, text", value)
+}
+
+// markdown renders model output to HTML. Raw HTML is passed through so that models
+// answering in HTML are handled too; everything is sanitized afterwards.
+var markdown = goldmark.New(goldmark.WithRendererOptions(gmhtml.WithUnsafe()))
+
+// policy is the allowlist of HTML that survives sanitization: basic rich text that
+// every editor we feed understands, and links with safe URLs only
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "strong", "b", "em", "i", "code", "pre", "blockquote",
+		"ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	return p
+}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// Convert turns model output, markdown that may contain HTML, into the requested
+// format. The text is always rendered and sanitized first, so no format can carry
+// HTML outside the allowlist.
+func Convert(text string, to models.OutputFormatEnum) string {
+	sanitized := ToHTML(text)
+
+	switch to {
+	case models.FormatHTML:
+		return sanitized
+	case models.FormatText:
+		return fromHTML(sanitized, false)
+	default:
+		return fromHTML(sanitized, true)
+	}
+}
+
+// ToHTML renders markdown to allowlist-sanitized HTML
+func ToHTML(text string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		// Rendering only fails on writer errors; fall back to escaping the source
+		return "<p>" + html.EscapeString(text) + "</p>"
+	}
+	return strings.TrimSpace(policy.Sanitize(buf.String()))
+}
+
+// fromHTML writes sanitized HTML back out as markdown or plain text
+func fromHTML(source string, md bool) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(source), body)
+	if err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	text := strings.Join(blocks(body, md), "\n\n")
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
+
+// blocks renders a node's children as a list of blocks. Inline content between block
+// elements is gathered into paragraphs.
+func blocks(parent *html.Node, md bool) []string {
+	var out []string
+	var para strings.Builder
+
+	flush := func() {
+		if s := strings.TrimSpace(para.String()); s != "" {
+			out = append(out, s)
+		}
+		para.Reset()
+	}
+
+	for c := parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && isBlock(c.DataAtom) {
+			flush()
+			if b := block(c, md); b != "" {
+				out = append(out, b)
+			}
+			continue
+		}
+		para.WriteString(inline(c, md))
+	}
+	flush()
+
+	return out
+}
+
+// isBlock reports whether an element starts a new block
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Ul, atom.Ol, atom.Blockquote, atom.Pre, atom.Hr,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// block renders a block element
+func block(n *html.Node, md bool) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(children(n, md))
+		if !md || text == "" {
+			return text
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + text
+	case atom.Ul, atom.Ol:
+		return list(n, md)
+	case atom.Blockquote:
+		text := strings.Join(blocks(n, md), "\n\n")
+		if !md {
+			return text
+		}
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case atom.Pre:
+		text := strings.TrimRight(textContent(n), "\n")
+		if !md {
+			return text
+		}
+		return "```\n" + text + "\n```"
+	case atom.Hr:
+		if !md {
+			return ""
+		}
+		return "---"
+	default:
+		return strings.TrimSpace(children(n, md))
+	}
+}
+
+// list renders a list, indenting continuation lines and nested lists under each item
+func list(n *html.Node, md bool) string {
+	var items []string
+	i := 0
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		i++
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(i) + ". "
+		}
+		item := strings.Join(blocks(li, md), "\n")
+		items = append(items, marker+strings.ReplaceAll(item, "\n", "\n"+strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// children renders a node's children as inline content
+func children(n *html.Node, md bool) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(inline(c, md))
+	}
+	return b.String()
+}
+
+var spacePattern = regexp.MustCompile(`\s+`)
+
+// inline renders inline content
+func inline(n *html.Node, md bool) string {
+	switch n.Type {
+	case html.TextNode:
+		text := spacePattern.ReplaceAllString(n.Data, " ")
+		if md {
+			return escapeMarkdown(text)
+		}
+		return text
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		if md {
+			return "  \n"
+		}
+		return "\n"
+	case atom.Strong, atom.B:
+		return wrap(children(n, md), "**", md)
+	case atom.Em, atom.I:
+		return wrap(children(n, md), "*", md)
+	case atom.Code:
+		return wrap(textContent(n), "`", md)
+	case atom.A:
+		text := children(n, md)
+		href := attr(n, "href")
+		if !md || href == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	default:
+		if isBlock(n.DataAtom) {
+			return block(n, md)
+		}
+		return children(n, md)
+	}
+}
+
+// wrap surrounds text with a markdown delimiter, keeping surrounding spaces outside
+// it so the emphasis still parses
+func wrap(text, delim string, md bool) string {
+	if !md || strings.TrimSpace(text) == "" {
+		return text
+	}
+	trimmed := strings.TrimSpace(text)
+	start := strings.Index(text, trimmed)
+	return text[:start] + delim + trimmed + delim + text[start+len(trimmed):]
+}
+
+// textContent returns the text inside a node without any markup
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// attr returns the value of an attribute
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// escapeMarkdown escapes characters that would otherwise be read as markup. Intraword
+// underscores are left alone since markdown doesn't treat them as emphasis.
+func escapeMarkdown(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '\\', '*', '`', '[', ']', '<':
+			b.WriteRune('\\')
+		case '_':
+			if i == 0 || i == len(runes)-1 || !isWordRune(runes[i-1]) || !isWordRune(runes[i+1]) {
+				b.WriteRune('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
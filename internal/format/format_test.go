@@ -0,0 +1,95 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+const sample = `## About me
+
+I build **fast** APIs in *Go* and love snake_case.
+
+- Payments
+- Search with ` + "`Elasticsearch`" + `
+
+Read more at [my site](https://example.com).`
+
+func TestConvertMarkdown(t *testing.T) {
+	got := Convert(sample, models.FormatMarkdown)
+	if got != sample {
+		t.Errorf("markdown round trip changed the text:\n%s", got)
+	}
+}
+
+func TestConvertHTML(t *testing.T) {
+	got := Convert(sample, models.FormatHTML)
+
+	for _, want := range []string{
+		"<h2>About me</h2>",
+		"<strong>fast</strong>",
+		"<li>Payments</li>",
+		"<code>Elasticsearch</code>",
+		`<a href="https://example.com" rel="nofollow">my site</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestConvertText(t *testing.T) {
+	got := Convert(sample, models.FormatText)
+	want := "About me\n\nI build fast APIs in Go and love snake_case.\n\n- Payments\n- Search with Elasticsearch\n\nRead more at my site."
+	if got != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertSanitizesHTML(t *testing.T) {
+	in := `<p onclick="steal()">Hello <b>there</b></p><script>alert(1)</script>` +
+		"\n\n[click](javascript:alert(1)) <img src=x onerror=alert(1)>"
+
+	for _, f := range Formats() {
+		got := Convert(in, f)
+		for _, banned := range []string{"script", "alert", "onclick", "<img", "javascript"} {
+			if strings.Contains(got, banned) {
+				t.Errorf("%s output contains %q:\n%s", f, banned, got)
+			}
+		}
+		if !strings.Contains(got, "Hello") || !strings.Contains(got, "click") {
+			t.Errorf("%s output lost text:\n%s", f, got)
+		}
+	}
+}
+
+func TestConvertHTMLToMarkdown(t *testing.T) {
+	in := "<p>We need a <strong>senior</strong> engineer.</p>\n<ul><li>Go</li><li>SQL</li></ul>"
+	want := "We need a **senior** engineer.\n\n- Go\n- SQL"
+
+	if got := Convert(in, models.FormatMarkdown); got != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertEscapesMarkdown(t *testing.T) {
+	// Escaped markup in the source must not turn into markup, or raw HTML, on the way out
+	in := `Use \*stars\* and \<b\>tags\</b\>`
+	want := `Use \*stars\* and \<b>tags\</b>`
+	if got := Convert(in, models.FormatMarkdown); got != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if f, err := Parse(""); err != nil || f != Default {
+		t.Errorf("Parse(\"\") = %q, %v", f, err)
+	}
+	if f, err := Parse("html"); err != nil || f != models.FormatHTML {
+		t.Errorf("Parse(html) = %q, %v", f, err)
+	}
+	if _, err := Parse("rtf"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
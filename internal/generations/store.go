@@ -0,0 +1,213 @@
+package generations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound is returned when a generation ID is unknown, has been evicted, or is
+	// owned by another service
+	ErrNotFound = errors.New("generations: not found")
+)
+
+// Generation is a single piece of generated content and the latest feedback it
+// received. Only the Owner service, the one it was generated for, can read or rate it.
+type Generation struct {
+	ID           string
+	Owner        string
+	TemplateName models.PromptTemplateEnum
+	Version      string
+	UserID       string
+	// Tone, Length, Locale and Format are the style the completion was generated in
+	Tone       models.ModelTuneEnum
+	Length     models.ModelResponseLengthEnum
+	Locale     string
+	Format     models.OutputFormatEnum
+	Completion string
+	CreatedAt  time.Time
+	Feedback   *models.GenerationFeedback
+}
+
+// statsKey identifies the stats bucket for a template version
+type statsKey struct {
+	template models.PromptTemplateEnum
+	version  string
+}
+
+// Store keeps recent generations in memory along with per-version outcome stats.
+// Once maxEntries is reached the oldest generations are evicted; the aggregated
+// stats are kept separately so they survive eviction.
+type Store struct {
+	mu         sync.RWMutex
+	items      map[string]*Generation
+	order      []string
+	stats      map[statsKey]*models.TemplateFeedbackStats
+	maxEntries int
+	logger     *zap.Logger
+}
+
+// NewStore creates a new generation store
+func NewStore(maxEntries int, logger *zap.Logger) *Store {
+	return &Store{
+		items:      make(map[string]*Generation),
+		stats:      make(map[statsKey]*models.TemplateFeedbackStats),
+		maxEntries: maxEntries,
+		logger:     logger,
+	}
+}
+
+// Record stores a new generation and returns its ID. The ID, creation time and
+// feedback are set by the store.
+func (s *Store) Record(gen Generation) string {
+	gen.ID = newID()
+	gen.CreatedAt = time.Now()
+	gen.Feedback = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[gen.ID] = &gen
+	s.order = append(s.order, gen.ID)
+	s.statsFor(gen.TemplateName, gen.Version).Generations++
+
+	// Evict the oldest generations once over capacity
+	for s.maxEntries > 0 && len(s.order) > s.maxEntries {
+		delete(s.items, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	return gen.ID
+}
+
+// Get returns a copy of a generation owned by the given service
+func (s *Store) Get(owner, id string) (*Generation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	gen, ok := s.items[id]
+	if !ok || gen.Owner != owner {
+		return nil, ErrNotFound
+	}
+
+	cp := *gen
+	if gen.Feedback != nil {
+		feedback := *gen.Feedback
+		cp.Feedback = &feedback
+	}
+	return &cp, nil
+}
+
+// AddFeedback rates a generation owned by the given service and updates the template
+// stats. A generation holds one rating: new feedback replaces the previous one and
+// its counts, so resubmitting can't inflate the stats.
+func (s *Store) AddFeedback(owner, id string, feedback models.GenerationFeedback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gen, ok := s.items[id]
+	if !ok || gen.Owner != owner {
+		return ErrNotFound
+	}
+
+	if feedback.CreatedAt.IsZero() {
+		feedback.CreatedAt = time.Now()
+	}
+
+	stats := s.statsFor(gen.TemplateName, gen.Version)
+	if gen.Feedback != nil {
+		tally(stats, gen, *gen.Feedback, -1)
+	}
+	gen.Feedback = &feedback
+	tally(stats, gen, feedback, 1)
+
+	s.logger.Debug("Generation feedback recorded",
+		zap.String("generation_id", id),
+		zap.String("template", string(gen.TemplateName)),
+		zap.String("template_version", gen.Version),
+		zap.String("rating", string(feedback.Rating)),
+	)
+
+	return nil
+}
+
+// Stats returns aggregated feedback stats for every template version, sorted by
+// template name and version
+func (s *Store) Stats() []models.TemplateFeedbackStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.TemplateFeedbackStats, 0, len(s.stats))
+	for _, stats := range s.stats {
+		cp := *stats
+		cp.Reasons = make(map[models.FeedbackReasonEnum]int, len(stats.Reasons))
+		for reason, count := range stats.Reasons {
+			cp.Reasons[reason] = count
+		}
+		result = append(result, cp)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TemplateName != result[j].TemplateName {
+			return result[i].TemplateName < result[j].TemplateName
+		}
+		return result[i].Version < result[j].Version
+	})
+
+	return result
+}
+
+// tally adds (delta 1) or removes (delta -1) one rating of gen from its template stats
+func tally(stats *models.TemplateFeedbackStats, gen *Generation, feedback models.GenerationFeedback, delta int) {
+	stats.FeedbackCount += delta
+	switch feedback.Rating {
+	case models.FeedbackUp:
+		stats.ThumbsUp += delta
+	case models.FeedbackDown:
+		stats.ThumbsDown += delta
+	}
+	if feedback.EditedText != nil && *feedback.EditedText != gen.Completion {
+		stats.EditedCount += delta
+	}
+	for _, reason := range feedback.Reasons {
+		stats.Reasons[reason] += delta
+		if stats.Reasons[reason] == 0 {
+			delete(stats.Reasons, reason)
+		}
+	}
+
+	stats.ApprovalRate = 0
+	if stats.FeedbackCount > 0 {
+		stats.ApprovalRate = float64(stats.ThumbsUp) / float64(stats.FeedbackCount)
+	}
+}
+
+// statsFor returns the stats entry for a template version, creating it if needed.
+// Callers must hold the write lock.
+func (s *Store) statsFor(templateName models.PromptTemplateEnum, version string) *models.TemplateFeedbackStats {
+	key := statsKey{template: templateName, version: version}
+	stats, ok := s.stats[key]
+	if !ok {
+		stats = &models.TemplateFeedbackStats{
+			TemplateName: templateName,
+			Version:      version,
+			Reasons:      make(map[models.FeedbackReasonEnum]int),
+		}
+		s.stats[key] = stats
+	}
+	return stats
+}
+
+// newID generates a random generation ID
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "gen_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,185 @@
+package generations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestStoreRecordAndGet(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	id := store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, UserID: "user-1", Length: models.LengthShort, Locale: "fr", Completion: "My bio"})
+	if id == "" {
+		t.Fatal("expected non-empty generation ID")
+	}
+
+	gen, err := store.Get("svc", id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gen.TemplateName != models.PromptTalentBio {
+		t.Errorf("expected template %q, got %q", models.PromptTalentBio, gen.TemplateName)
+	}
+
+	if gen.Completion != "My bio" {
+		t.Errorf("expected completion 'My bio', got %q", gen.Completion)
+	}
+
+	if gen.ID != id || gen.CreatedAt.IsZero() || gen.Length != models.LengthShort || gen.Locale != "fr" {
+		t.Errorf("unexpected generation: %+v", gen)
+	}
+}
+
+func TestStoreEvictsOldest(t *testing.T) {
+	store := NewStore(2, zap.NewNop())
+
+	first := store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, Completion: "one"})
+	store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, Completion: "two"})
+	store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, Completion: "three"})
+
+	if _, err := store.Get("svc", first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for evicted generation, got %v", err)
+	}
+
+	stats := store.Stats()
+	if len(stats) != 1 || stats[0].Generations != 3 {
+		t.Errorf("expected stats to survive eviction, got %+v", stats)
+	}
+}
+
+func TestStoreAddFeedbackUnknownID(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	err := store.AddFeedback("svc", "gen_missing", models.GenerationFeedback{Rating: models.FeedbackUp})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStoreOtherOwner(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	id := store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, UserID: "user-1", Completion: "My bio"})
+
+	if _, err := store.Get("other", id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another service, got %v", err)
+	}
+	if err := store.AddFeedback("other", id, models.GenerationFeedback{Rating: models.FeedbackUp}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for feedback from another service, got %v", err)
+	}
+
+	gen, _ := store.Get("svc", id)
+	if gen.Feedback != nil {
+		t.Errorf("expected no feedback from another service, got %+v", gen.Feedback)
+	}
+}
+
+func TestStoreStatsAggregation(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	bio := store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, Completion: "bio"})
+	letter := store.Record(Generation{Owner: "svc", TemplateName: models.PromptProposalCoverLetter, Completion: "letter"})
+
+	edited := "edited letter"
+	store.AddFeedback("svc", bio, models.GenerationFeedback{Rating: models.FeedbackUp})
+	store.AddFeedback("svc", letter, models.GenerationFeedback{
+		Rating:     models.FeedbackDown,
+		EditedText: &edited,
+		Reasons:    []models.FeedbackReasonEnum{models.ReasonTooLong, models.ReasonGeneric},
+	})
+	letter2 := store.Record(Generation{Owner: "svc", TemplateName: models.PromptProposalCoverLetter, Completion: "letter two"})
+	store.AddFeedback("svc", letter2, models.GenerationFeedback{Rating: models.FeedbackUp})
+
+	stats := store.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 template stats, got %d", len(stats))
+	}
+
+	// Sorted by template name: proposal_cover_letter, talent_bio
+	letterStats := stats[0]
+	if letterStats.TemplateName != models.PromptProposalCoverLetter {
+		t.Fatalf("expected first stats for %q, got %q", models.PromptProposalCoverLetter, letterStats.TemplateName)
+	}
+
+	if letterStats.FeedbackCount != 2 || letterStats.ThumbsUp != 1 || letterStats.ThumbsDown != 1 {
+		t.Errorf("unexpected counts: %+v", letterStats)
+	}
+
+	if letterStats.EditedCount != 1 {
+		t.Errorf("expected 1 edited, got %d", letterStats.EditedCount)
+	}
+
+	if letterStats.Reasons[models.ReasonTooLong] != 1 {
+		t.Errorf("expected too_long reason count 1, got %d", letterStats.Reasons[models.ReasonTooLong])
+	}
+
+	if letterStats.ApprovalRate != 0.5 {
+		t.Errorf("expected approval rate 0.5, got %f", letterStats.ApprovalRate)
+	}
+
+	gen, _ := store.Get("svc", letter)
+	if gen.Feedback == nil || gen.Feedback.Rating != models.FeedbackDown {
+		t.Errorf("expected feedback stored with generation, got %+v", gen.Feedback)
+	}
+}
+
+func TestStoreFeedbackReplacesRating(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	id := store.Record(Generation{Owner: "svc", TemplateName: models.PromptTalentBio, Completion: "bio"})
+
+	edited := "edited bio"
+	store.AddFeedback("svc", id, models.GenerationFeedback{
+		Rating:     models.FeedbackDown,
+		EditedText: &edited,
+		Reasons:    []models.FeedbackReasonEnum{models.ReasonTooLong},
+	})
+	for i := 0; i < 3; i++ {
+		store.AddFeedback("svc", id, models.GenerationFeedback{Rating: models.FeedbackUp})
+	}
+
+	stats := store.Stats()[0]
+	if stats.FeedbackCount != 1 || stats.ThumbsUp != 1 || stats.ThumbsDown != 0 || stats.EditedCount != 0 {
+		t.Errorf("expected only the latest rating to count, got %+v", stats)
+	}
+	if len(stats.Reasons) != 0 || stats.ApprovalRate != 1 {
+		t.Errorf("expected the replaced rating's reasons to be removed, got %+v", stats)
+	}
+
+	gen, _ := store.Get("svc", id)
+	if gen.Feedback == nil || gen.Feedback.Rating != models.FeedbackUp {
+		t.Errorf("expected the latest feedback, got %+v", gen.Feedback)
+	}
+}
+
+func TestStoreStatsPerVersion(t *testing.T) {
+	store := NewStore(10, zap.NewNop())
+
+	v1 := store.Record(Generation{Owner: "svc", TemplateName: models.PromptProposalCoverLetter, Version: "v1", UserID: "user-1", Completion: "letter one"})
+	v2 := store.Record(Generation{Owner: "svc", TemplateName: models.PromptProposalCoverLetter, Version: "v2", UserID: "user-2", Completion: "letter two"})
+	store.Record(Generation{Owner: "svc", TemplateName: models.PromptProposalCoverLetter, Version: "v2", UserID: "user-3", Completion: "letter three"})
+
+	store.AddFeedback("svc", v1, models.GenerationFeedback{Rating: models.FeedbackDown})
+	store.AddFeedback("svc", v2, models.GenerationFeedback{Rating: models.FeedbackUp})
+
+	stats := store.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 version stats, got %d", len(stats))
+	}
+
+	if stats[0].Version != "v1" || stats[0].Generations != 1 || stats[0].ThumbsDown != 1 {
+		t.Errorf("unexpected v1 stats: %+v", stats[0])
+	}
+	if stats[1].Version != "v2" || stats[1].Generations != 2 || stats[1].ApprovalRate != 1 {
+		t.Errorf("unexpected v2 stats: %+v", stats[1])
+	}
+
+	gen, _ := store.Get("svc", v2)
+	if gen.Version != "v2" {
+		t.Errorf("expected version v2 stored with generation, got %q", gen.Version)
+	}
+}
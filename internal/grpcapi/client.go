@@ -0,0 +1,81 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"google.golang.org/grpc"
+)
+
+// Client calls the LLM gRPC service. Service credentials are sent as outgoing
+// metadata on the context, e.g. with metadata.AppendToOutgoingContext.
+type Client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient creates a client on an open connection
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+// CategorizeJobs assigns each job the slug of its most relevant category
+func (c *Client) CategorizeJobs(ctx context.Context, req *models.JobCategorizationV2Request, opts ...grpc.CallOption) (*models.JobCategorizationV2Response, error) {
+	resp := new(models.JobCategorizationV2Response)
+	return resp, c.invoke(ctx, "CategorizeJobs", req, resp, opts)
+}
+
+// DescribeJobs writes detailed and short descriptions for a batch of jobs
+func (c *Client) DescribeJobs(ctx context.Context, req *models.JobDescribeV2Request, opts ...grpc.CallOption) (*models.JobDescribeV2Response, error) {
+	resp := new(models.JobDescribeV2Response)
+	return resp, c.invoke(ctx, "DescribeJobs", req, resp, opts)
+}
+
+// GenerateFromTemplate generates content from a prompt template for a user
+func (c *Client) GenerateFromTemplate(ctx context.Context, req *models.PromptGenerationV2Request, opts ...grpc.CallOption) (*models.CompletionV2Response, error) {
+	resp := new(models.CompletionV2Response)
+	return resp, c.invoke(ctx, "GenerateFromTemplate", req, resp, opts)
+}
+
+// Complete gets a completion for free-form text
+func (c *Client) Complete(ctx context.Context, req *models.TextCompletionV2Request, opts ...grpc.CallOption) (*models.CompletionV2Response, error) {
+	resp := new(models.CompletionV2Response)
+	return resp, c.invoke(ctx, "Complete", req, resp, opts)
+}
+
+// StreamCompletion starts a streamed completion; read it with Recv until Done
+func (c *Client) StreamCompletion(ctx context.Context, req *models.TextCompletionV2Request, opts ...grpc.CallOption) (*CompletionStream, error) {
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], "/"+ServiceName+"/StreamCompletion", callOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &CompletionStream{stream: stream}, nil
+}
+
+// CompletionStream is the receiving end of a streamed completion
+type CompletionStream struct {
+	stream grpc.ClientStream
+}
+
+// Recv returns the next chunk, or io.EOF after the last one
+func (s *CompletionStream) Recv() (*models.CompletionChunk, error) {
+	chunk := new(models.CompletionChunk)
+	if err := s.stream.RecvMsg(chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp interface{}, opts []grpc.CallOption) error {
+	return c.conn.Invoke(ctx, "/"+ServiceName+"/"+method, req, resp, callOptions(opts)...)
+}
+
+// callOptions selects the JSON codec ahead of the caller's options
+func callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+}
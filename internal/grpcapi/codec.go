@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// CodecName is the content subtype of the API's messages (application/grpc+json)
+const CodecName = "json"
+
+// codec marshals messages as JSON, so the API shares the models types with the HTTP
+// API instead of generated protobuf code
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return CodecName
+}
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
@@ -0,0 +1,65 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifies this service in ErrorInfo details
+const errorDomain = "llm.dokoola.com"
+
+// serviceErrorCodes maps service error codes to gRPC status codes
+var serviceErrorCodes = map[models.ErrorCodeEnum]codes.Code{
+	models.ErrInvalidRequest:      codes.InvalidArgument,
+	models.ErrTemplateInvalid:     codes.InvalidArgument,
+	models.ErrUserNotFound:        codes.NotFound,
+	models.ErrNotFound:            codes.NotFound,
+	models.ErrUpstreamRateLimited: codes.Unavailable,
+	models.ErrQuotaExceeded:       codes.ResourceExhausted,
+	models.ErrUpstreamError:       codes.Internal,
+}
+
+// statusError converts a service error to a gRPC status. The API error code is sent
+// as the reason of an ErrorInfo detail, and field errors as a BadRequest detail.
+func statusError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var serr *service.Error
+	if !errors.As(err, &serr) {
+		return errorStatus(codes.Internal, models.ErrInternal, err.Error(), nil)
+	}
+
+	code, ok := serviceErrorCodes[serr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	return errorStatus(code, serr.Code, serr.Message, serr.FieldErrors)
+}
+
+// errorStatus builds a status carrying the API error code and any field errors
+func errorStatus(code codes.Code, errorCode models.ErrorCodeEnum, message string, fieldErrors []models.FieldError) error {
+	st := status.New(code, message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(errorCode), Domain: errorDomain}}
+	if len(fieldErrors) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fieldErrors))
+		for i, f := range fieldErrors {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
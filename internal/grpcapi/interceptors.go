@@ -0,0 +1,148 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serviceKeyContextKey is the context key holding the authenticated service key
+type serviceKeyContextKey struct{}
+
+// serviceKey returns the service key of the authenticated caller, if any
+func serviceKey(ctx context.Context) string {
+	key, _ := ctx.Value(serviceKeyContextKey{}).(string)
+	return key
+}
+
+// authenticate checks the service credentials in the call's metadata and returns the
+// context with the caller's service key. Metadata keys are the HTTP API's
+// authentication header names, lowercased.
+func authenticate(ctx context.Context, cfg *config.Config, logger *zap.Logger, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(header string) string {
+		if values := md.Get(strings.ToLower(header)); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	serviceKey := get(cfg.ServiceKeyName)
+	clientName := get(cfg.ClientNameHeader)
+	secretHash := get(cfg.SecretHashHeader)
+
+	if serviceKey == "" || clientName == "" || secretHash == "" {
+		logger.Warn("Missing authentication metadata", zap.String("method", method), zap.String("service_key", serviceKey))
+		return nil, errorStatus(codes.Unauthenticated, models.ErrUnauthorized, "Missing required authentication metadata", nil)
+	}
+
+	service, exists := cfg.AllowedServices[serviceKey]
+	if !exists || service.ClientName != clientName || service.SecretHash != secretHash {
+		logger.Warn("Invalid service credentials",
+			zap.String("method", method),
+			zap.String("service_key", serviceKey),
+			zap.String("client_name", clientName),
+		)
+		return nil, errorStatus(codes.Unauthenticated, models.ErrUnauthorized, "Invalid service credentials", nil)
+	}
+
+	return context.WithValue(ctx, serviceKeyContextKey{}, serviceKey), nil
+}
+
+// authUnaryInterceptor rejects unary calls without valid service credentials
+func authUnaryInterceptor(cfg *config.Config, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, cfg, logger, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStreamInterceptor rejects streams without valid service credentials
+func authStreamInterceptor(cfg *config.Config, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), cfg, logger, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream is a stream whose context carries the caller's service key
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// loggingUnaryInterceptor logs each call's method, status code and duration
+func loggingUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// loggingStreamInterceptor logs each stream's method, status code and duration
+func loggingStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(logger *zap.Logger, method string, start time.Time, err error) {
+	duration := time.Since(start)
+	logger.Info("[GRPC]",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Float64("duration_ms", float64(duration.Nanoseconds())/1e6),
+	)
+}
+
+// recoveryUnaryInterceptor turns panics into internal_error statuses
+func recoveryUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoveryStreamInterceptor turns panics into internal_error statuses
+func recoveryStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(logger *zap.Logger, method string, r interface{}) error {
+	logger.Error("Panic while handling call", zap.String("method", method), zap.Any("error", r))
+	return errorStatus(codes.Internal, models.ErrInternal, "Internal server error", nil)
+}
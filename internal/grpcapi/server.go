@@ -0,0 +1,224 @@
+// Package grpcapi serves the service layer over gRPC for internal callers such as the
+// Dokoola backend. Messages are the v2 models encoded as JSON, and callers
+// authenticate with the same service credentials as the HTTP API, sent as metadata.
+// api/grpc.md is the wire contract for clients outside this module.
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the fully qualified gRPC service name
+const ServiceName = "dokoola.llm.v1.LLM"
+
+// userIDMetadataKey carries the user a request is for, like the X-User-ID header
+const userIDMetadataKey = "x-user-id"
+
+// Server implements the LLM gRPC service on top of the service layer
+type Server struct {
+	service *service.Service
+	logger  *zap.Logger
+}
+
+// NewServer creates a gRPC server with the LLM service registered. Every call is
+// authenticated against cfg's allowed services.
+func NewServer(cfg *config.Config, svc *service.Service, logger *zap.Logger) *grpc.Server {
+	srv := grpc.NewServer(
+		// Any client content subtype is decoded as JSON, so callers whose gRPC library
+		// can't set application/grpc+json only need JSON (de)serializers
+		grpc.ForceServerCodec(codec{}),
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor(logger), loggingUnaryInterceptor(logger), authUnaryInterceptor(cfg, logger)),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor(logger), loggingStreamInterceptor(logger), authStreamInterceptor(cfg, logger)),
+	)
+	srv.RegisterService(&serviceDesc, &Server{service: svc, logger: logger})
+	return srv
+}
+
+// CategorizeJobs assigns each job the slug of its most relevant category
+func (s *Server) CategorizeJobs(ctx context.Context, req *models.JobCategorizationV2Request) (*models.JobCategorizationV2Response, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	usage := &llm.Usage{}
+	results, err := s.service.CategorizeJobs(ctx, service.CategorizeInput{Jobs: req.Jobs, NoCache: req.NoCache, Usage: usage})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &models.JobCategorizationV2Response{Success: true, Data: results, Usage: usage.Totals()}, nil
+}
+
+// DescribeJobs writes detailed and short descriptions for a batch of jobs
+func (s *Server) DescribeJobs(ctx context.Context, req *models.JobDescribeV2Request) (*models.JobDescribeV2Response, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	usage := &llm.Usage{}
+	payload, err := s.service.DescribeJobs(ctx, service.DescribeInput{Jobs: req.Jobs, Format: req.Format, Usage: usage})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &models.JobDescribeV2Response{Success: true, Data: payload, Usage: usage.Totals()}, nil
+}
+
+// GenerateFromTemplate generates content from a prompt template for a user
+func (s *Server) GenerateFromTemplate(ctx context.Context, req *models.PromptGenerationV2Request) (*models.CompletionV2Response, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	userID, err := requestUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &llm.Usage{}
+	data, err := s.service.GenerateFromTemplate(ctx, service.GenerateInput{Owner: serviceKey(ctx), Request: req.PromptGenerationRequest(), UserID: userID, Usage: usage})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &models.CompletionV2Response{Success: true, Data: *data, Usage: usage.Totals()}, nil
+}
+
+// Complete gets a completion for free-form text
+func (s *Server) Complete(ctx context.Context, req *models.TextCompletionV2Request) (*models.CompletionV2Response, error) {
+	in, err := completeInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &llm.Usage{}
+	in.Usage = usage
+	data, err := s.service.Complete(ctx, in)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &models.CompletionV2Response{Success: true, Data: *data, Usage: usage.Totals()}, nil
+}
+
+// StreamCompletion sends a completion as it is generated: one message per content
+// delta, then a final message with the completion data and usage
+func (s *Server) StreamCompletion(req *models.TextCompletionV2Request, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+
+	in, err := completeInput(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	usage := &llm.Usage{}
+	in.Usage = usage
+	data, err := s.service.StreamComplete(ctx, in, func(delta string) error {
+		return stream.SendMsg(&models.CompletionChunk{Delta: delta})
+	})
+	if err != nil {
+		return statusError(err)
+	}
+
+	totals := usage.Totals()
+	return stream.SendMsg(&models.CompletionChunk{Done: true, Data: data, Usage: &totals})
+}
+
+// completeInput validates a completion request and resolves its user
+func completeInput(ctx context.Context, req *models.TextCompletionV2Request) (service.CompleteInput, error) {
+	if err := validate(req); err != nil {
+		return service.CompleteInput{}, err
+	}
+	userID, err := requestUserID(ctx, req.UserID)
+	if err != nil {
+		return service.CompleteInput{}, err
+	}
+	return service.CompleteInput{Owner: serviceKey(ctx), Text: req.Text, UserID: userID, Locale: req.Locale, Format: req.Format}, nil
+}
+
+// validate applies the binding rules the HTTP API checks requests against
+func validate(req interface{}) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return errorStatus(codes.InvalidArgument, models.ErrInvalidRequest, "Invalid request: "+err.Error(), nil)
+	}
+	return nil
+}
+
+// requestUserID takes the user from the request or the x-user-id metadata. Sending
+// both is allowed only when they agree.
+func requestUserID(ctx context.Context, requestUserID string) (string, error) {
+	var metadataUserID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(userIDMetadataKey); len(values) > 0 {
+			metadataUserID = values[0]
+		}
+	}
+
+	if requestUserID != "" && metadataUserID != "" && requestUserID != metadataUserID {
+		return "", errorStatus(codes.InvalidArgument, models.ErrInvalidRequest,
+			"Invalid request: user_id "+requestUserID+" does not match the "+userIDMetadataKey+" metadata "+metadataUserID, nil)
+	}
+	if requestUserID != "" {
+		return requestUserID, nil
+	}
+	return metadataUserID, nil
+}
+
+// llmService is the interface the service descriptor's handlers call
+type llmService interface {
+	CategorizeJobs(context.Context, *models.JobCategorizationV2Request) (*models.JobCategorizationV2Response, error)
+	DescribeJobs(context.Context, *models.JobDescribeV2Request) (*models.JobDescribeV2Response, error)
+	GenerateFromTemplate(context.Context, *models.PromptGenerationV2Request) (*models.CompletionV2Response, error)
+	Complete(context.Context, *models.TextCompletionV2Request) (*models.CompletionV2Response, error)
+	StreamCompletion(*models.TextCompletionV2Request, grpc.ServerStream) error
+}
+
+// unaryHandler adapts a typed unary method to the descriptor's handler signature
+func unaryHandler[Req any, Resp any](method string, call func(llmService, context.Context, *Req) (*Resp, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid request: %s", err.Error())
+		}
+		if interceptor == nil {
+			return call(srv.(llmService), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + method}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(llmService), ctx, req.(*Req))
+		})
+	}
+}
+
+func streamCompletionHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(models.TextCompletionV2Request)
+	if err := stream.RecvMsg(req); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid request: %s", err.Error())
+	}
+	return srv.(llmService).StreamCompletion(req, stream)
+}
+
+// serviceDesc describes the service as protoc would for a .proto definition
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*llmService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "CategorizeJobs", Handler: unaryHandler("CategorizeJobs", llmService.CategorizeJobs)},
+		{MethodName: "DescribeJobs", Handler: unaryHandler("DescribeJobs", llmService.DescribeJobs)},
+		{MethodName: "GenerateFromTemplate", Handler: unaryHandler("GenerateFromTemplate", llmService.GenerateFromTemplate)},
+		{MethodName: "Complete", Handler: unaryHandler("Complete", llmService.Complete)},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "StreamCompletion", Handler: streamCompletionHandler, ServerStreams: true},
+	},
+}
@@ -0,0 +1,350 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/clients/backendtest"
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/dokoola/llm-go/internal/service"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testServiceKey = "DKL_WEB"
+
+type testEnv struct {
+	llm         *llmtest.Server
+	backend     *backendtest.Server
+	generations *generations.Store
+	conn        *grpc.ClientConn
+	client      *Client
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	logger := zap.NewNop()
+
+	env := &testEnv{
+		llm:         llmtest.NewServer(t),
+		backend:     backendtest.NewServer(t),
+		generations: generations.NewStore(10, logger),
+	}
+
+	cfg := &config.Config{
+		AllowedServices: map[string]config.ServiceConfig{
+			testServiceKey: {ClientName: "dokoola-web", SecretHash: "web-secret"},
+		},
+		ServiceKeyName:   "X-Service-Key",
+		ClientNameHeader: "X-Client-Name",
+		SecretHashHeader: "X-Secret-Hash",
+	}
+
+	llmClient := llm.NewClient("test-key", logger)
+	llmClient.SetAPIURL(env.llm.URL())
+
+	registry, err := prompts.NewRegistry("", logger)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+	svc := service.New(llmClient, clients.NewBackendClient(env.backend.URL(), logger), registry, env.generations, nil, logger)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := NewServer(cfg, svc, logger)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	env.conn = conn
+	env.client = NewClient(conn)
+	return env
+}
+
+// authed adds valid service credentials to a context
+func authed(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-service-key", testServiceKey, "x-client-name", "dokoola-web", "x-secret-hash", "web-secret")
+}
+
+// errorReason returns the API error code carried by a status
+func errorReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestAuthentication(t *testing.T) {
+	env := newTestEnv(t)
+	req := &models.TextCompletionV2Request{Text: "Hello"}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"missing", context.Background()},
+		{"wrong secret", metadata.AppendToOutgoingContext(context.Background(), "x-service-key", testServiceKey, "x-client-name", "dokoola-web", "x-secret-hash", "nope")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.client.Complete(tt.ctx, req)
+			if status.Code(err) != codes.Unauthenticated || errorReason(err) != string(models.ErrUnauthorized) {
+				t.Errorf("Complete() error = %v, want unauthenticated", err)
+			}
+
+			stream, err := env.client.StreamCompletion(tt.ctx, req)
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("StreamCompletion() error = %v, want unauthenticated", err)
+			}
+		})
+	}
+
+	if len(env.llm.Requests()) != 0 {
+		t.Error("expected no LLM calls without credentials")
+	}
+}
+
+func TestCategorizeJobs(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply("graphic-design") })
+
+	resp, err := env.client.CategorizeJobs(authed(context.Background()), &models.JobCategorizationV2Request{
+		Jobs: []models.JobData{{PublicID: "job-1", Description: "Design a logo"}},
+	})
+	if err != nil {
+		t.Fatalf("CategorizeJobs() error = %v", err)
+	}
+	if !resp.Success || len(resp.Data) != 1 || resp.Data[0].Category != "graphic-design" || resp.Usage.LLMCalls != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	// Requests are validated like the HTTP API's
+	_, err = env.client.CategorizeJobs(authed(context.Background()), &models.JobCategorizationV2Request{})
+	if status.Code(err) != codes.InvalidArgument || errorReason(err) != string(models.ErrInvalidRequest) {
+		t.Errorf("empty request: error = %v", err)
+	}
+}
+
+func TestGenerateFromTemplate(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+
+	req := &models.PromptGenerationV2Request{
+		TemplateName: models.PromptTalentBio,
+		Data:         map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+	}
+
+	// The user can come from metadata, like the X-User-ID header
+	ctx := metadata.AppendToOutgoingContext(authed(context.Background()), "x-user-id", backendtest.TalentID)
+	resp, err := env.client.GenerateFromTemplate(ctx, req)
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+	if resp.Data.Completion != "I build **reliable** Go services." || resp.Data.GenerationID == "" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	// The generation belongs to the calling service
+	if _, err := env.generations.Get(testServiceKey, resp.Data.GenerationID); err != nil {
+		t.Errorf("expected the generation to be owned by %s: %v", testServiceKey, err)
+	}
+
+	req.UserID = "ghost"
+	_, err = env.client.GenerateFromTemplate(authed(context.Background()), req)
+	if status.Code(err) != codes.NotFound || errorReason(err) != string(models.ErrUserNotFound) {
+		t.Errorf("unknown user: error = %v", err)
+	}
+
+	// Field errors are sent as BadRequest details
+	req.UserID = backendtest.TalentID
+	req.Data = map[string]interface{}{"profile": map[string]interface{}{}}
+	_, err = env.client.GenerateFromTemplate(authed(context.Background()), req)
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			violations = br.FieldViolations
+		}
+	}
+	if status.Code(err) != codes.InvalidArgument || len(violations) == 0 {
+		t.Errorf("invalid data: error = %v, violations = %v", err, violations)
+	}
+}
+
+func TestStreamCompletion(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Response{Content: "Go and Rust", Chunks: []string{"Go ", "and ", "Rust"}})
+
+	stream, err := env.client.StreamCompletion(authed(context.Background()), &models.TextCompletionV2Request{Text: "Name two languages"})
+	if err != nil {
+		t.Fatalf("StreamCompletion() error = %v", err)
+	}
+
+	var deltas []string
+	var final *models.CompletionChunk
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		deltas = append(deltas, chunk.Delta)
+	}
+
+	if strings.Join(deltas, "") != "Go and Rust" || len(deltas) != 3 {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if final == nil || final.Data == nil || final.Data.Completion != "Go and Rust" || final.Data.GenerationID == "" {
+		t.Fatalf("final chunk = %+v", final)
+	}
+	if _, err := env.generations.Get(testServiceKey, final.Data.GenerationID); err != nil {
+		t.Errorf("expected the generation to be owned by %s: %v", testServiceKey, err)
+	}
+	if final.Usage == nil || final.Usage.LLMCalls != 1 || final.Usage.TotalTokens == 0 {
+		t.Errorf("usage = %+v", final.Usage)
+	}
+
+	// Upstream rate limits end the stream with Unavailable
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.RateLimited() })
+	stream, err = env.client.StreamCompletion(authed(context.Background()), &models.TextCompletionV2Request{Text: "again"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unavailable || errorReason(err) != string(models.ErrUpstreamRateLimited) {
+		t.Errorf("rate limited: error = %v", err)
+	}
+}
+
+// rawCodec sends and receives JSON bytes as they are, like a client with no Go types
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) { return *v.(*[]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return CodecName }
+
+func TestJSONWireFormat(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("Ship it."))
+
+	req := []byte(`{"text":"Write a tagline"}`)
+	var resp []byte
+	err := env.conn.Invoke(authed(context.Background()), "/dokoola.llm.v1.LLM/Complete", &req, &resp, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	var body struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Completion string `json:"completion"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &body); err != nil || !body.Success || body.Data.Completion != "Ship it." {
+		t.Errorf("response = %s", resp)
+	}
+
+	// A protobuf payload isn't JSON
+	req = []byte{0x0a, 0x05, 'h', 'e', 'l', 'l', 'o'}
+	err = env.conn.Invoke(authed(context.Background()), "/dokoola.llm.v1.LLM/Complete", &req, &resp, grpc.ForceCodec(rawCodec{}))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("protobuf payload: error = %v, want InvalidArgument", err)
+	}
+}
+
+// TestContractDocumentsEveryMethod keeps api/grpc.md in step with the service
+// descriptor and the OpenAPI schemas it points to
+func TestContractDocumentsEveryMethod(t *testing.T) {
+	doc, err := os.ReadFile("../../api/grpc.md")
+	if err != nil {
+		t.Fatalf("failed to read the contract: %v", err)
+	}
+	raw, err := os.ReadFile("../../api/openapi.json")
+	if err != nil {
+		t.Fatalf("failed to read the OpenAPI document: %v", err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+
+	documented := map[string][]string{}
+	for _, line := range strings.Split(string(doc), "\n") {
+		cells := strings.Split(line, "|")
+		if len(cells) != 6 || !strings.HasPrefix(strings.TrimSpace(cells[1]), "`") {
+			continue
+		}
+		var names []string
+		for _, cell := range cells[1:5] {
+			names = append(names, strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cell), "stream of")), "`"))
+		}
+		documented[names[0]] = names[2:]
+	}
+
+	methods := []string{}
+	for _, m := range serviceDesc.Methods {
+		methods = append(methods, m.MethodName)
+	}
+	for _, s := range serviceDesc.Streams {
+		methods = append(methods, s.StreamName)
+	}
+	for _, method := range methods {
+		messages, ok := documented[method]
+		if !ok {
+			t.Errorf("%s is not documented in api/grpc.md", method)
+			continue
+		}
+		for _, message := range messages {
+			if _, ok := spec.Components.Schemas[message]; !ok && message != "CompletionChunk" {
+				t.Errorf("%s: %s is not an OpenAPI schema", method, message)
+			}
+		}
+	}
+	if len(documented) != len(methods) {
+		t.Errorf("api/grpc.md documents %d methods, the service has %d", len(documented), len(methods))
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ConversationsHandler handles multi-turn chat conversations. Conversations are
+// new in both API versions, so they take the user like v2 does and report usage.
+type ConversationsHandler struct {
+	service *service.Service
+	logger  *zap.Logger
+}
+
+// NewConversationsHandler creates a new conversations handler
+func NewConversationsHandler(svc *service.Service, logger *zap.Logger) *ConversationsHandler {
+	return &ConversationsHandler{
+		service: svc,
+		logger:  logger,
+	}
+}
+
+// Create handles POST /api/v1/chat/conversations
+func (h *ConversationsHandler) Create(c *gin.Context) {
+	var req models.ConversationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	userID, ok := v2UserID(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	conv, err := h.service.CreateConversation(c.Request.Context(), service.CreateConversationInput{
+		Owner:  middleware.ServiceKey(c),
+		UserID: userID,
+		Locale: req.Locale,
+	})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationResponse{
+		Success: true,
+		Data:    conv,
+	})
+}
+
+// Get handles GET /api/v1/chat/conversations/:id
+func (h *ConversationsHandler) Get(c *gin.Context) {
+	conv, err := h.service.GetConversation(middleware.ServiceKey(c), c.Param("id"))
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationResponse{
+		Success: true,
+		Data:    conv,
+	})
+}
+
+// Delete handles DELETE /api/v1/chat/conversations/:id
+func (h *ConversationsHandler) Delete(c *gin.Context) {
+	conv, err := h.service.DeleteConversation(middleware.ServiceKey(c), c.Param("id"))
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationResponse{
+		Success: true,
+		Data:    conv,
+	})
+}
+
+// SendMessage handles POST /api/v1/chat/conversations/:id/messages
+func (h *ConversationsHandler) SendMessage(c *gin.Context) {
+	var req models.ConversationMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	usage := &llm.Usage{}
+	reply, err := h.service.SendMessage(c.Request.Context(), service.SendMessageInput{
+		Owner:          middleware.ServiceKey(c),
+		ConversationID: c.Param("id"),
+		Text:           req.Text,
+		Format:         req.Format,
+		Usage:          usage,
+	})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ConversationMessageResponse{
+		Success: true,
+		Data:    *reply,
+		Usage:   usage.Totals(),
+	})
+}
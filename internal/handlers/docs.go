@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// docsPage renders the OpenAPI document with Redoc
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>%s</title>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="%s"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// DocsHandler serves the OpenAPI document and a docs page for it
+type DocsHandler struct {
+	spec   []byte
+	page   string
+	logger *zap.Logger
+}
+
+// NewDocsHandler creates a docs handler for an OpenAPI document served at specURL
+func NewDocsHandler(spec map[string]interface{}, specURL string, logger *zap.Logger) (*DocsHandler, error) {
+	raw, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+
+	title := "API"
+	if info, ok := spec["info"].(map[string]interface{}); ok {
+		title, _ = info["title"].(string)
+	}
+
+	return &DocsHandler{
+		spec:   raw,
+		page:   fmt.Sprintf(docsPage, html.EscapeString(title), html.EscapeString(specURL)),
+		logger: logger,
+	}, nil
+}
+
+// Spec handles GET /openapi.json
+func (h *DocsHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// Page handles GET /docs
+func (h *DocsHandler) Page(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(h.page))
+}
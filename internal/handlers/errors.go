@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// serviceErrorStatus maps service error codes to HTTP statuses
+var serviceErrorStatus = map[models.ErrorCodeEnum]int{
+	models.ErrInvalidRequest:      http.StatusBadRequest,
+	models.ErrTemplateInvalid:     http.StatusBadRequest,
+	models.ErrUserNotFound:        http.StatusNotFound,
+	models.ErrNotFound:            http.StatusNotFound,
+	models.ErrConflict:            http.StatusConflict,
+	models.ErrInProgress:          http.StatusConflict,
+	models.ErrUpstreamRateLimited: http.StatusServiceUnavailable,
+	models.ErrQuotaExceeded:       http.StatusServiceUnavailable,
+}
+
+// abortServiceError responds to a failed service call with the status for its code.
+// Anything the service doesn't classify is an internal error.
+func abortServiceError(c *gin.Context, err error) {
+	var serr *service.Error
+	if !errors.As(err, &serr) {
+		middleware.AbortWithError(c, http.StatusInternalServerError, models.ErrInternal, err.Error())
+		return
+	}
+
+	status, ok := serviceErrorStatus[serr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	middleware.AbortWithError(c, status, serr.Code, serr.Message, serr.FieldErrors...)
+}
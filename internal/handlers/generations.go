@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GenerationsHandler handles feedback on previously generated content
+type GenerationsHandler struct {
+	store  *generations.Store
+	logger *zap.Logger
+}
+
+// NewGenerationsHandler creates a new generations handler
+func NewGenerationsHandler(store *generations.Store, logger *zap.Logger) *GenerationsHandler {
+	return &GenerationsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// SubmitFeedback handles POST /api/v1/generations/:id/feedback
+func (h *GenerationsHandler) SubmitFeedback(c *gin.Context) {
+	generationID := c.Param("id")
+
+	var req models.GenerationFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	err := h.store.AddFeedback(middleware.ServiceKey(c), generationID, models.GenerationFeedback{
+		Rating:     req.Rating,
+		EditedText: req.EditedText,
+		Reasons:    req.Reasons,
+		UserID:     req.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, generations.ErrNotFound) {
+			middleware.AbortWithError(c, http.StatusNotFound, models.ErrNotFound, fmt.Sprintf("Generation not found: %s", generationID))
+			return
+		}
+
+		h.logger.Error("Failed to record feedback", zap.String("generation_id", generationID), zap.Error(err))
+		middleware.AbortWithError(c, http.StatusInternalServerError, models.ErrInternal, fmt.Sprintf("Failed to record feedback: %s", err.Error()))
+		return
+	}
+
+	h.logger.Info("Generation feedback received",
+		zap.String("generation_id", generationID),
+		zap.String("rating", string(req.Rating)),
+	)
+
+	c.JSON(http.StatusOK, models.GenerationFeedbackResponse{
+		Success:      true,
+		GenerationID: generationID,
+	})
+}
+
+// Stats handles GET /api/v1/generations/stats
+func (h *GenerationsHandler) Stats(c *gin.Context) {
+	c.JSON(http.StatusOK, models.GenerationStatsResponse{
+		Success: true,
+		Data:    h.store.Stats(),
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func newGenerationsTestRouter(store *generations.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	handler := NewGenerationsHandler(store, logger)
+
+	router := gin.New()
+	router.POST("/generations/:id/feedback", handler.SubmitFeedback)
+	router.GET("/generations/stats", handler.Stats)
+	return router
+}
+
+func TestGenerationsHandlerSubmitFeedback(t *testing.T) {
+	logger, _ := initHandlersTestLogger()
+	store := generations.NewStore(10, logger)
+	id := store.Record(generations.Generation{TemplateName: models.PromptTalentBio, UserID: "user-1", Completion: "My bio"})
+	router := newGenerationsTestRouter(store)
+
+	body := `{"rating": "down", "reasons": ["too_long"], "edited_text": "Shorter bio"}`
+	req := httptest.NewRequest("POST", "/generations/"+id+"/feedback", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/generations/stats", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp models.GenerationStatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if len(resp.Data) != 1 || resp.Data[0].ThumbsDown != 1 || resp.Data[0].EditedCount != 1 {
+		t.Errorf("unexpected stats: %+v", resp.Data)
+	}
+}
+
+func TestGenerationsHandlerSubmitFeedbackErrors(t *testing.T) {
+	logger, _ := initHandlersTestLogger()
+	store := generations.NewStore(10, logger)
+	id := store.Record(generations.Generation{TemplateName: models.PromptTalentBio, Completion: "My bio"})
+	other := store.Record(generations.Generation{Owner: "DKL_OTHER", TemplateName: models.PromptTalentBio, Completion: "Their bio"})
+	router := newGenerationsTestRouter(store)
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+	}{
+		{name: "unknown generation", id: "gen_missing", body: `{"rating": "up"}`, expected: http.StatusNotFound},
+		{name: "generation of another service", id: other, body: `{"rating": "up"}`, expected: http.StatusNotFound},
+		{name: "missing rating", id: id, body: `{}`, expected: http.StatusBadRequest},
+		{name: "invalid rating", id: id, body: `{"rating": "meh"}`, expected: http.StatusBadRequest},
+		{name: "invalid reason", id: id, body: `{"rating": "down", "reasons": ["bad"]}`, expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/generations/"+tt.id+"/feedback", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
@@ -5,11 +5,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/constants"
+	"github.com/dokoola/llm-go/internal/generations"
 	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
 	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/dokoola/llm-go/internal/tasks"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -25,7 +33,7 @@ func TestJobsHandlerNewJobsHandler(t *testing.T) {
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
 
-	handler := NewJobsHandler(mockLLM, mockBackend, logger)
+	handler := NewJobsHandler(service.New(mockLLM, mockBackend, nil, nil, nil, logger), tasks.NewManager(1, 1, time.Minute, logger), nil, logger)
 
 	if handler == nil {
 		t.Error("expected non-nil handler")
@@ -43,7 +51,7 @@ func TestPromptsHandlerNewPromptsHandler(t *testing.T) {
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
 
-	handler := NewPromptsHandler(mockLLM, mockBackend, logger)
+	handler := NewPromptsHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
 
 	if handler == nil {
 		t.Error("expected non-nil handler")
@@ -61,7 +69,7 @@ func TestTextCompletionHandlerNewTextCompletionHandler(t *testing.T) {
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
 
-	handler := NewTextCompletionHandler(mockLLM, mockBackend, logger)
+	handler := NewTextCompletionHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
 
 	if handler == nil {
 		t.Error("expected non-nil handler")
@@ -79,7 +87,7 @@ func TestJobsHandlerInvalidRequest(t *testing.T) {
 
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
-	handler := NewJobsHandler(mockLLM, mockBackend, logger)
+	handler := NewJobsHandler(service.New(mockLLM, mockBackend, nil, nil, nil, logger), tasks.NewManager(1, 1, time.Minute, logger), nil, logger)
 
 	router := gin.New()
 	router.POST("/jobs/categorize", handler.CategorizeJobs)
@@ -110,7 +118,7 @@ func TestTextCompletionHandlerInvalidRequest(t *testing.T) {
 
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
-	handler := NewTextCompletionHandler(mockLLM, mockBackend, logger)
+	handler := NewTextCompletionHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
 
 	router := gin.New()
 	router.POST("/completion", handler.Complete)
@@ -134,6 +142,54 @@ func TestTextCompletionHandlerInvalidRequest(t *testing.T) {
 	}
 }
 
+func TestTextCompletionHandlerComplete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	defer logger.Sync()
+
+	server := llmtest.NewServer(t)
+	server.Enqueue(llmtest.Reply("**Go** and *Rust*"), llmtest.RateLimited(), llmtest.RateLimited(), llmtest.RateLimited())
+
+	llmClient := llm.NewClient("test-key", logger)
+	llmClient.SetAPIURL(server.URL())
+	handler := NewTextCompletionHandler(service.New(llmClient, nil, nil, generations.NewStore(10, logger), nil, logger), logger)
+
+	router := gin.New()
+	router.POST("/completion", handler.Complete)
+
+	req := httptest.NewRequest("POST", "/completion", bytes.NewBufferString(`{"text": "Name two languages", "format": "html"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.TextCompletionResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if !resp.Success || resp.Completion == nil || *resp.Completion != "<p><strong>Go</strong> and <em>Rust</em></p>" {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+	if resp.GenerationID == "" || resp.Format != models.FormatHTML {
+		t.Errorf("expected generation ID and format, got %+v", resp)
+	}
+	if got := server.Requests()[0].UserPrompt(); got != "Name two languages" {
+		t.Errorf("LLM prompt = %q", got)
+	}
+
+	// Upstream rate limiting that outlasts the retries surfaces as 503
+	req = httptest.NewRequest("POST", "/completion", bytes.NewBufferString(`{"text": "again"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 when rate limited, got %d", w.Code)
+	}
+}
+
 func TestPromptsHandlerInvalidRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger, _ := initHandlersTestLogger()
@@ -141,7 +197,7 @@ func TestPromptsHandlerInvalidRequest(t *testing.T) {
 
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
-	handler := NewPromptsHandler(mockLLM, mockBackend, logger)
+	handler := NewPromptsHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
 
 	router := gin.New()
 	router.POST("/prompts/generate", handler.GeneratePrompt)
@@ -165,6 +221,111 @@ func TestPromptsHandlerInvalidRequest(t *testing.T) {
 	}
 }
 
+func TestPromptsHandlerInvalidStyle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	defer logger.Sync()
+
+	var mockLLM *llm.Client
+	var mockBackend *clients.BackendClient
+	handler := NewPromptsHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
+
+	router := gin.New()
+	router.POST("/prompts/generate", handler.GeneratePrompt)
+
+	tests := []string{
+		`{"template_name": "talent_bio", "data": {}, "tone": "sarcastic"}`,
+		`{"template_name": "talent_bio", "data": {}, "length": "epic"}`,
+	}
+
+	for _, body := range tests {
+		req := httptest.NewRequest("POST", "/prompts/generate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestPromptsHandlerRenderPrompt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	defer logger.Sync()
+
+	registry, err := prompts.NewRegistry("", logger)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+
+	// No LLM or backend is needed: rendering never calls either without a user_id
+	var mockLLM *llm.Client
+	var mockBackend *clients.BackendClient
+	handler := NewPromptsHandler(service.New(mockLLM, mockBackend, registry, generations.NewStore(10, logger), nil, logger), logger)
+
+	router := gin.New()
+	router.POST("/actions/render-prompt", handler.RenderPrompt)
+
+	body := `{"template_name": "talent_bio", "data": {"profile": {"name": "Ada", "title": "Engineer"}}, "locale": "fr", "length": "short"}`
+	req := httptest.NewRequest("POST", "/actions/render-prompt", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.RenderPromptResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Success || resp.Data == nil {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	data := resp.Data
+	base := len(constants.SystemMessages)
+	if len(data.Messages) != base+2 {
+		t.Fatalf("expected %d messages, got %d", base+2, len(data.Messages))
+	}
+	if data.Messages[0].Content != constants.SystemMessages[0]["content"] {
+		t.Errorf("expected base system messages first, got %q", data.Messages[0].Content)
+	}
+	if !strings.Contains(data.Messages[base].Content, "French") {
+		t.Errorf("expected template system prompt with locale instruction, got %q", data.Messages[base].Content)
+	}
+	if last := data.Messages[len(data.Messages)-1]; last.Role != "user" || !strings.Contains(last.Content, "Ada") {
+		t.Errorf("expected rendered user prompt last, got %+v", last)
+	}
+	if data.Length != models.LengthShort || data.Locale != "fr" || data.TemplateVersion == "" {
+		t.Errorf("unexpected selection: %+v", data)
+	}
+	if data.EstimatedTokens <= 0 || data.Model == "" || data.Params.MaxTokens <= 0 {
+		t.Errorf("expected model, params and token estimate: %+v", data)
+	}
+
+	// Invalid data reports field errors, as generation does
+	req = httptest.NewRequest("POST", "/actions/render-prompt", bytes.NewBufferString(`{"template_name": "talent_bio", "data": {"profile": {}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid data, got %d", w.Code)
+	}
+	var errResp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("failed to parse error response: %v", err)
+	}
+	if errResp.Error.Code != models.ErrTemplateInvalid || len(errResp.Error.FieldErrors) == 0 {
+		t.Errorf("expected template_invalid with field errors, got %+v", errResp.Error)
+	}
+}
+
 func TestPromptsHandlerGeneratePromptNoneTemplate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger, _ := initHandlersTestLogger()
@@ -172,7 +333,7 @@ func TestPromptsHandlerGeneratePromptNoneTemplate(t *testing.T) {
 
 	var mockLLM *llm.Client
 	var mockBackend *clients.BackendClient
-	handler := NewPromptsHandler(mockLLM, mockBackend, logger)
+	handler := NewPromptsHandler(service.New(mockLLM, mockBackend, nil, generations.NewStore(10, logger), nil, logger), logger)
 
 	// This test checks that the handler correctly initializes
 	// In real usage, GetUser would not be called with nil backend
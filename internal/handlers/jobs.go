@@ -1,31 +1,36 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
-	"github.com/dokoola/llm-go/internal/clients"
-	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/middleware"
 	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/dokoola/llm-go/internal/tasks"
+	"github.com/dokoola/llm-go/internal/webhooks"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // JobsHandler handles job categorization requests
 type JobsHandler struct {
-	llmClient     *llm.Client
-	backendClient *clients.BackendClient
-	logger        *zap.Logger
+	service  *service.Service
+	tasks    *tasks.Manager
+	webhooks *webhooks.Dispatcher
+	logger   *zap.Logger
 }
 
 // NewJobsHandler creates a new jobs handler
-func NewJobsHandler(llmClient *llm.Client, backendClient *clients.BackendClient, logger *zap.Logger) *JobsHandler {
+func NewJobsHandler(svc *service.Service, taskManager *tasks.Manager, dispatcher *webhooks.Dispatcher, logger *zap.Logger) *JobsHandler {
 	return &JobsHandler{
-		llmClient:     llmClient,
-		backendClient: backendClient,
-		logger:        logger,
+		service:  svc,
+		tasks:    taskManager,
+		webhooks: dispatcher,
+		logger:   logger,
 	}
 }
 
@@ -35,58 +40,31 @@ func (h *JobsHandler) GenerateJobDesc(c *gin.Context) {
 	var req []models.JobDescribeRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := fmt.Sprintf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, models.JobDescribeResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
 		return
 	}	
 
-	prompt := fmt.Sprintf(`You are a job description expert for Dokoola platform.
-	
-	Analyze this job posting and provide a detailed description and a short description.
-	
-	Data
-	"""%s"""
-	
-	Instructions:
-	- Return an array of JSON object with two fields: "description" and "short_description"
-	- The "description" should be a detailed summary of the job posting
-	- The "short_description" a summary of the job (400 characters max) not a rich text, use plaintext only
-	- Do not include any other text, only the JSON object
-
-
-	- Note: If description is already matured and efficient enough like (>500chars), you don't need to add any details,
-	just generate the short description, Otherswise, generate both description and short description.
-
-	- Note: Your generation should be in first person, like the this is writting by the job poster, not a third party description of the job.
-	So avoid using third person perspective in the description.
-	
-	Job description:`, req)
-
-	// Get LLM completion
-	completion, err := h.llmClient.Complete(prompt, nil)
-	if err != nil {
-		h.logger.Error("LLM completion failed", zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to generate description: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, models.JobDescribeResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
+	// Descriptions are returned as written unless a format is requested
+	var outputFormat models.OutputFormatEnum
+	if c.Query("format") != "" {
+		f, err := format.Parse(c.Query("format"))
+		if err != nil {
+			middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+			return
+		}
+		outputFormat = f
+	}
+
+	if wantsAsync(c) {
+		h.submitTask(c, models.TaskJobDescribe, len(req), func(ctx context.Context, progress func(int)) (interface{}, error) {
+			return h.service.DescribeJobs(ctx, service.DescribeInput{Jobs: req, Format: outputFormat})
 		})
 		return
 	}
 
-	// Parse the JSON response
-	var payload []models.JobDescription
-	err = json.Unmarshal([]byte(completion), &payload)
+	payload, err := h.service.DescribeJobs(c.Request.Context(), service.DescribeInput{Jobs: req, Format: outputFormat})
 	if err != nil {
-		h.logger.Error("Failed to parse LLM response", zap.String("response", completion), zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to parse description response: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, models.JobDescribeResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		abortServiceError(c, err)
 		return
 	}
 
@@ -102,104 +80,69 @@ func (h *JobsHandler) CategorizeJobs(c *gin.Context) {
 	var req models.JobCategorizationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := fmt.Sprintf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, models.JobCategorizationResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
 		return
 	}
 
 	h.logger.Info("Received job categorization request", zap.Int("job_count", len(req.Data)))
 
-	// Fetch categories from backend
-	categories, err := h.backendClient.GetCategories()
-	if err != nil {
-		h.logger.Error("Failed to fetch categories", zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to fetch categories: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, models.JobCategorizationResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
+	// Categorization is deterministic, so completions are cached unless the caller opts out
+	noCache := noCacheRequested(c)
+
+	if wantsAsync(c) {
+		h.submitTask(c, models.TaskJobCategorize, len(req.Data), func(ctx context.Context, progress func(int)) (interface{}, error) {
+			return h.service.CategorizeJobs(ctx, service.CategorizeInput{Jobs: req.Data, NoCache: noCache, Progress: progress})
 		})
 		return
 	}
 
-	// Build categories description for prompt
-	categoriesDesc := h.buildCategoriesDescription(categories)
-
-	// Process each job
-	results := make([]models.JobResponseData, 0, len(req.Data))
-
-	for _, job := range req.Data {
-		h.logger.Debug("Processing job", zap.String("public_id", job.PublicID))
-
-		// Build categorization prompt
-		prompt := fmt.Sprintf(`You are a job categorization expert for Dokoola platform.
-
-Analyze this job posting and select the SINGLE MOST RELEVANT category from the list below.
-
-Job Description:
-"""%s"""
-
-Available Categories:
-%s
-
-Instructions:
-- Return ONLY the category slug (e.g., "web-development")
-- Choose the most specific matching category
-- If no exact match, choose the closest parent category
-- Return only the slug, nothing else
-
-Category slug:`, job.Description, categoriesDesc)
-
-		// Get LLM completion
-		completion, err := h.llmClient.Complete(prompt, nil)
-		if err != nil {
-			h.logger.Error("LLM completion failed", zap.String("public_id", job.PublicID), zap.Error(err))
-			// Use default category or empty string
-			results = append(results, models.JobResponseData{
-				PublicID: job.PublicID,
-				Category: "",
-			})
-			continue
-		}
-
-		// Extract category slug from response
-		categorySlug := strings.TrimSpace(completion)
-		categorySlug = strings.Trim(categorySlug, "\"'`")
-
-		h.logger.Debug("Job categorized",
-			zap.String("public_id", job.PublicID),
-			zap.String("category", categorySlug),
-		)
-
-		results = append(results, models.JobResponseData{
-			PublicID: job.PublicID,
-			Category: categorySlug,
-		})
+	results, err := h.service.CategorizeJobs(c.Request.Context(), service.CategorizeInput{Jobs: req.Data, NoCache: noCache})
+	if err != nil {
+		abortServiceError(c, err)
+		return
 	}
 
-	h.logger.Info("Job categorization completed", zap.Int("processed", len(results)))
-
 	c.JSON(http.StatusOK, models.JobCategorizationResponse{
 		Success: true,
 		Data:    results,
 	})
 }
 
-// buildCategoriesDescription creates a formatted string of categories
-func (h *JobsHandler) buildCategoriesDescription(categories []models.JobCategory) string {
-	var sb strings.Builder
-
-	for i, cat := range categories {
-		if cat.ParentSlug != nil {
-			sb.WriteString(fmt.Sprintf("%d. %s (%s) - Child of: %s\n",
-				i+1, cat.Slug, cat.Description, *cat.ParentSlug))
-		} else {
-			sb.WriteString(fmt.Sprintf("%d. %s (%s)\n",
-				i+1, cat.Slug, cat.Description))
+// submitTask queues work on the task manager and responds with 202 Accepted.
+// An optional callback_url query parameter must point at a host registered for the calling service.
+func (h *JobsHandler) submitTask(c *gin.Context, kind models.TaskKindEnum, total int, fn tasks.Func) {
+	serviceKey := middleware.ServiceKey(c)
+
+	callbackURL := c.Query("callback_url")
+	if callbackURL != "" {
+		if err := h.webhooks.ValidateURL(serviceKey, callbackURL); err != nil {
+			h.logger.Warn("Rejected callback URL",
+				zap.String("service_key", serviceKey),
+				zap.String("callback_url", callbackURL),
+				zap.Error(err),
+			)
+			middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+			return
 		}
 	}
 
-	return sb.String()
+	info, err := h.tasks.Submit(serviceKey, kind, total, callbackURL, fn)
+	if err != nil {
+		h.logger.Warn("Failed to queue task", zap.String("kind", string(kind)), zap.Error(err))
+		middleware.AbortWithError(c, http.StatusServiceUnavailable, models.ErrQuotaExceeded, fmt.Sprintf("Failed to queue task: %s", err.Error()))
+		return
+	}
+
+	statusURL := taskStatusURL(c, info.ID)
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, models.TaskResponse{
+		Success:   true,
+		Data:      &info,
+		StatusURL: statusURL,
+	})
+}
+
+// noCacheRequested reports whether the caller sent "Cache-Control: no-cache"
+func noCacheRequested(c *gin.Context) bool {
+	return strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache")
 }
@@ -1,31 +1,28 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/dokoola/llm-go/internal/clients"
 	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/middleware"
 	"github.com/dokoola/llm-go/internal/models"
-	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/dokoola/llm-go/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // PromptsHandler handles prompt generation requests
 type PromptsHandler struct {
-	llmClient     *llm.Client
-	backendClient *clients.BackendClient
-	logger        *zap.Logger
+	service *service.Service
+	logger  *zap.Logger
 }
 
 // NewPromptsHandler creates a new prompts handler
-func NewPromptsHandler(llmClient *llm.Client, backendClient *clients.BackendClient, logger *zap.Logger) *PromptsHandler {
+func NewPromptsHandler(svc *service.Service, logger *zap.Logger) *PromptsHandler {
 	return &PromptsHandler{
-		llmClient:     llmClient,
-		backendClient: backendClient,
-		logger:        logger,
+		service: svc,
+		logger:  logger,
 	}
 }
 
@@ -34,90 +31,84 @@ func (h *PromptsHandler) GeneratePrompt(c *gin.Context) {
 	var req models.PromptGenerationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := fmt.Sprintf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, models.PromptGenerationResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
 		return
 	}
 
-	h.logger.Info("Received prompt generation request",
-		zap.String("template", string(req.TemplateName)),
-	)
-
 	// Get user ID from query parameter (optional)
-	userID := c.Query("user_id")
-	var user *models.AuthUser
-	var err error
-
-	user, err = h.backendClient.GetUser(userID)
+	data, err := h.service.GenerateFromTemplate(c.Request.Context(), service.GenerateInput{
+		Owner:   middleware.ServiceKey(c),
+		Request: req,
+		UserID:  c.Query("user_id"),
+	})
 	if err != nil {
-		h.logger.Warn("Failed to fetch user, continuing without user context",
-			zap.String("user_id", userID),
-			zap.Error(err),
-		)
-		errorMessage := fmt.Sprintf("User not found: %s", userID)
-		c.JSON(http.StatusNotFound, models.TextCompletionResponse{
-			Success:      false,
-			ErrorMessage: &errorMessage,
-		})
+		abortServiceError(c, err)
 		return
 	}
 
-	// Build prompt from template
-	prompt, err := prompts.BuildPrompt(req.TemplateName, req.Data, user)
+	c.JSON(http.StatusOK, models.PromptGenerationResponse{
+		Success:         true,
+		Completion:      &data.Completion,
+		GenerationID:    data.GenerationID,
+		TemplateVersion: data.TemplateVersion,
+		Locale:          data.Locale,
+		Format:          data.Format,
+		Metadata:        data.Metadata,
+	})
+}
+
+// RenderPrompt handles POST /api/v1/llm/actions/render-prompt. It builds the exact
+// message array a generation would send, without calling the LLM.
+func (h *PromptsHandler) RenderPrompt(c *gin.Context) {
+	var req models.PromptGenerationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	data, err := h.service.RenderTemplate(c.Request.Context(), req, c.Query("user_id"))
 	if err != nil {
-		h.logger.Error("Failed to build prompt", zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to build prompt: %s", err.Error())
-		c.JSON(http.StatusBadRequest, models.PromptGenerationResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		abortServiceError(c, err)
 		return
 	}
 
-	// Handle "none" template - just return empty completion
-	if req.TemplateName == models.PromptNone {
-		emptyStr := ""
-		c.JSON(http.StatusOK, models.PromptGenerationResponse{
-			Success:    true,
-			Completion: &emptyStr,
-		})
+	c.JSON(http.StatusOK, models.RenderPromptResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// Refine handles POST /api/v1/actions/refine. The route is new in both API versions,
+// so it takes the user like v2 does and reports usage.
+func (h *PromptsHandler) Refine(c *gin.Context) {
+	var req models.RefineRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
 		return
 	}
 
-	h.logger.Debug("Prompt built successfully", zap.Int("prompt_length", len(prompt)))
+	userID, ok := v2UserID(c, req.UserID)
+	if !ok {
+		return
+	}
 
-	// Get LLM completion
-	completion, err := h.llmClient.Complete(prompt, user)
+	usage := &llm.Usage{}
+	data, err := h.service.Refine(c.Request.Context(), service.RefineInput{
+		Owner:   middleware.ServiceKey(c),
+		Request: req,
+		UserID:  userID,
+		Usage:   usage,
+	})
 	if err != nil {
-		// If upstream LLM is rate-limited, return 503 to caller
-		if errors.Is(err, llm.ErrRateLimited) {
-			h.logger.Warn("Upstream LLM rate limited", zap.Error(err))
-			msg := "Upstream LLM service overloaded; please try again later"
-			c.JSON(http.StatusServiceUnavailable, models.PromptGenerationResponse{
-				Success:      false,
-				ErrorMessage: &msg,
-			})
-			return
-		}
-
-		h.logger.Error("LLM completion failed", zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to generate completion: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, models.PromptGenerationResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		abortServiceError(c, err)
 		return
 	}
 
-	h.logger.Info("Prompt generation successful",
-		zap.String("template", string(req.TemplateName)),
-	)
-
-	c.JSON(http.StatusOK, models.PromptGenerationResponse{
-		Success:    true,
-		Completion: &completion,
+	c.JSON(http.StatusOK, models.CompletionV2Response{
+		Success: true,
+		Data:    *data,
+		Usage:   usage.Totals(),
 	})
 }
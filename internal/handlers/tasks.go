@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/tasks"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TasksHandler handles polling and cancellation of async tasks
+type TasksHandler struct {
+	tasks  *tasks.Manager
+	logger *zap.Logger
+}
+
+// NewTasksHandler creates a new tasks handler
+func NewTasksHandler(taskManager *tasks.Manager, logger *zap.Logger) *TasksHandler {
+	return &TasksHandler{
+		tasks:  taskManager,
+		logger: logger,
+	}
+}
+
+// GetTask handles GET /api/v1/tasks/:id
+func (h *TasksHandler) GetTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	info, err := h.tasks.Get(middleware.ServiceKey(c), taskID)
+	if err != nil {
+		middleware.AbortWithError(c, http.StatusNotFound, models.ErrNotFound, fmt.Sprintf("Task not found: %s", taskID))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Data:    &info,
+	})
+}
+
+// CancelTask handles DELETE /api/v1/tasks/:id
+func (h *TasksHandler) CancelTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	info, err := h.tasks.Cancel(middleware.ServiceKey(c), taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrFinished) {
+			middleware.AbortWithError(c, http.StatusConflict, models.ErrConflict, fmt.Sprintf("Task already %s: %s", info.Status, taskID))
+			return
+		}
+
+		middleware.AbortWithError(c, http.StatusNotFound, models.ErrNotFound, fmt.Sprintf("Task not found: %s", taskID))
+		return
+	}
+
+	h.logger.Info("Task cancelled by client", zap.String("task_id", taskID))
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Data:    &info,
+	})
+}
+
+// wantsAsync reports whether the caller asked for the request to run as an async task,
+// either with ?async=true, a callback_url, or a "Prefer: respond-async" header
+func wantsAsync(c *gin.Context) bool {
+	if c.Query("async") == "true" || c.Query("callback_url") != "" {
+		return true
+	}
+	return strings.Contains(c.GetHeader("Prefer"), "respond-async")
+}
+
+// taskStatusURL builds the polling URL for a task relative to the current API prefix
+func taskStatusURL(c *gin.Context, taskID string) string {
+	path := c.Request.URL.Path
+	prefix := ""
+	if idx := strings.LastIndex(path, "/jobs/"); idx >= 0 {
+		prefix = path[:idx]
+	}
+	return prefix + "/tasks/" + taskID
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+func newTasksTestRouter(manager *tasks.Manager, serviceKey string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	handler := NewTasksHandler(manager, logger)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.ServiceKeyContextKey, serviceKey)
+		c.Next()
+	})
+	router.GET("/tasks/:id", handler.GetTask)
+	router.DELETE("/tasks/:id", handler.CancelTask)
+	return router
+}
+
+func TestTasksHandlerGetAndCancel(t *testing.T) {
+	logger, _ := initHandlersTestLogger()
+	manager := tasks.NewManager(1, 10, time.Minute, logger)
+
+	// Workers are not started so the task stays queued
+	info, err := manager.Submit("DKL001", models.TaskJobCategorize, 2, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	router := newTasksTestRouter(manager, "DKL001")
+
+	req := httptest.NewRequest("GET", "/tasks/"+info.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp models.TaskResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data == nil || resp.Data.Status != models.TaskQueued || resp.Data.Progress.Total != 2 {
+		t.Errorf("unexpected task data: %+v", resp.Data)
+	}
+
+	req = httptest.NewRequest("DELETE", "/tasks/"+info.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 on cancel, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/tasks/"+info.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 on second cancel, got %d", w.Code)
+	}
+}
+
+func TestTasksHandlerHidesOtherServicesTasks(t *testing.T) {
+	logger, _ := initHandlersTestLogger()
+	manager := tasks.NewManager(1, 10, time.Minute, logger)
+
+	info, _ := manager.Submit("DKL001", models.TaskJobDescribe, 1, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return nil, nil
+	})
+
+	router := newTasksTestRouter(manager, "DKL002")
+
+	req := httptest.NewRequest("GET", "/tasks/"+info.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestJobsHandlerAsyncSubmission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+	manager := tasks.NewManager(1, 10, time.Minute, logger)
+	handler := NewJobsHandler(nil, manager, nil, logger)
+
+	router := gin.New()
+	router.POST("/api/v1/jobs/categorize", handler.CategorizeJobs)
+
+	body := `{"data": [{"public_id": "job-1", "description": "Build a website"}]}`
+	req := httptest.NewRequest("POST", "/api/v1/jobs/categorize?async=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.TaskResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if resp.Data == nil || resp.Data.Kind != models.TaskJobCategorize {
+		t.Fatalf("unexpected task data: %+v", resp.Data)
+	}
+
+	expectedURL := "/api/v1/tasks/" + resp.Data.ID
+	if resp.StatusURL != expectedURL || w.Header().Get("Location") != expectedURL {
+		t.Errorf("expected status URL %q, got %q", expectedURL, resp.StatusURL)
+	}
+}
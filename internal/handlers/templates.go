@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TemplatesHandler manages the prompt template registry
+type TemplatesHandler struct {
+	registry *prompts.Registry
+	logger   *zap.Logger
+}
+
+// NewTemplatesHandler creates a new templates handler
+func NewTemplatesHandler(registry *prompts.Registry, logger *zap.Logger) *TemplatesHandler {
+	return &TemplatesHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// Reload handles POST /api/v1/admin/templates/reload
+func (h *TemplatesHandler) Reload(c *gin.Context) {
+	if err := h.registry.Reload(); err != nil {
+		h.logger.Error("Failed to reload prompt templates", zap.Error(err))
+		middleware.AbortWithError(c, http.StatusUnprocessableEntity, models.ErrTemplateInvalid, "Template reload failed: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TemplatesReloadResponse{
+		Success:   true,
+		Templates: h.registry.Names(),
+	})
+}
+
+// List handles GET /api/v1/templates
+func (h *TemplatesHandler) List(c *gin.Context) {
+	catalog := []models.TemplateInfo{}
+
+	for _, name := range h.registry.Names() {
+		t, ok := h.registry.Get(name)
+		if !ok {
+			continue
+		}
+
+		info := models.TemplateInfo{
+			Name:           name,
+			Description:    t.Description,
+			RequiredFields: []models.TemplateField{},
+			OptionalFields: []models.TemplateField{},
+			Tones:          prompts.Tones(),
+			Lengths:        prompts.Lengths(),
+			Formats:        format.Formats(),
+			DefaultTone:    prompts.DefaultTone,
+			DefaultLength:  prompts.DefaultLength,
+			DefaultFormat:  format.Default,
+			MaxChars:       t.MaxChars,
+			CurrentVersion: t.Current().Name,
+		}
+
+		fields, _ := prompts.InputFields(name)
+		for _, f := range fields {
+			if f.Required {
+				info.RequiredFields = append(info.RequiredFields, f)
+			} else {
+				info.OptionalFields = append(info.OptionalFields, f)
+			}
+		}
+
+		for _, v := range t.Versions {
+			info.Versions = append(info.Versions, models.TemplateVersionInfo{Name: v.Name, Weight: v.Weight})
+		}
+
+		catalog = append(catalog, info)
+	}
+
+	c.JSON(http.StatusOK, models.TemplateCatalogResponse{
+		Success: true,
+		Data:    catalog,
+	})
+}
+
+// Schema handles GET /api/v1/templates/:name/schema
+func (h *TemplatesHandler) Schema(c *gin.Context) {
+	name := models.PromptTemplateEnum(c.Param("name"))
+
+	schema, ok := prompts.InputSchema(name)
+	if !ok {
+		middleware.AbortWithError(c, http.StatusNotFound, models.ErrNotFound, fmt.Sprintf("Template not found: %s", name))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TemplateSchemaResponse{
+		Success: true,
+		Data:    schema,
+	})
+}
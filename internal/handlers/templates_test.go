@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"github.com/gin-gonic/gin"
+)
+
+func newTemplatesTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger, _ := initHandlersTestLogger()
+
+	registry, err := prompts.NewRegistry("", logger)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+	handler := NewTemplatesHandler(registry, logger)
+
+	router := gin.New()
+	router.GET("/templates", handler.List)
+	router.GET("/templates/:name/schema", handler.Schema)
+	router.POST("/admin/templates/reload", handler.Reload)
+	return router
+}
+
+func TestTemplatesHandlerList(t *testing.T) {
+	router := newTemplatesTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/templates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp models.TemplateCatalogResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Success || len(resp.Data) != 4 {
+		t.Fatalf("expected 4 templates, got %+v", resp)
+	}
+
+	var bio *models.TemplateInfo
+	for i := range resp.Data {
+		if resp.Data[i].Name == models.PromptTalentBio {
+			bio = &resp.Data[i]
+		}
+	}
+	if bio == nil {
+		t.Fatal("talent_bio missing from catalog")
+	}
+
+	if bio.Description == "" || bio.CurrentVersion != "v1" || len(bio.Versions) != 1 {
+		t.Errorf("unexpected template info: %+v", bio)
+	}
+	if len(bio.RequiredFields) != 1 || bio.RequiredFields[0].Path != "profile.title" {
+		t.Errorf("unexpected required fields: %+v", bio.RequiredFields)
+	}
+	if len(bio.Tones) != 7 || len(bio.Lengths) != 3 || len(bio.Formats) != 3 {
+		t.Errorf("unexpected style options: %v %v %v", bio.Tones, bio.Lengths, bio.Formats)
+	}
+	if bio.MaxChars[models.LengthShort] == 0 {
+		t.Errorf("expected max_chars for talent_bio, got %v", bio.MaxChars)
+	}
+}
+
+func TestTemplatesHandlerSchema(t *testing.T) {
+	router := newTemplatesTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/templates/talent_bio/schema", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp models.TemplateSchemaResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Success || resp.Data["title"] != "talent_bio" || resp.Data["type"] != "object" {
+		t.Errorf("unexpected schema response: %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/templates/unknown/schema", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for unknown template, got %d", w.Code)
+	}
+}
+
+func TestTemplatesHandlerReload(t *testing.T) {
+	router := newTemplatesTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/templates/reload", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp models.TemplatesReloadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Success || len(resp.Templates) != 4 {
+		t.Errorf("unexpected reload response: %+v", resp)
+	}
+}
@@ -1,30 +1,27 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/dokoola/llm-go/internal/clients"
-	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/middleware"
 	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // TextCompletionHandler handles text completion requests
 type TextCompletionHandler struct {
-	llmClient     *llm.Client
-	backendClient *clients.BackendClient
-	logger        *zap.Logger
+	service *service.Service
+	logger  *zap.Logger
 }
 
 // NewTextCompletionHandler creates a new text completion handler
-func NewTextCompletionHandler(llmClient *llm.Client, backendClient *clients.BackendClient, logger *zap.Logger) *TextCompletionHandler {
+func NewTextCompletionHandler(svc *service.Service, logger *zap.Logger) *TextCompletionHandler {
 	return &TextCompletionHandler{
-		llmClient:     llmClient,
-		backendClient: backendClient,
-		logger:        logger,
+		service: svc,
+		logger:  logger,
 	}
 }
 
@@ -33,63 +30,28 @@ func (h *TextCompletionHandler) Complete(c *gin.Context) {
 	var req models.TextCompletionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorMsg := fmt.Sprintf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, models.TextCompletionResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
 		return
 	}
 
-	h.logger.Info("Received text completion request")
-
 	// Get user ID from query parameter (optional for text completion)
-	userID := c.Query("user_id")
-	var user *models.AuthUser
-	var err error
-
-	if userID != "" {
-		user, err = h.backendClient.GetUser(userID)
-		if err != nil {
-			h.logger.Warn("Failed to fetch user, continuing without user context",
-				zap.String("user_id", userID),
-				zap.Error(err),
-			)
-			errorMessage := fmt.Sprintf("User not found: %s", userID)
-			c.JSON(http.StatusNotFound, models.TextCompletionResponse{
-				Success:      false,
-				ErrorMessage: &errorMessage,
-			})
-			return
-		}
-	}
-
-	// Get LLM completion
-	completion, err := h.llmClient.Complete(req.Text, user)
+	data, err := h.service.Complete(c.Request.Context(), service.CompleteInput{
+		Owner:  middleware.ServiceKey(c),
+		Text:   req.Text,
+		UserID: c.Query("user_id"),
+		Locale: req.Locale,
+		Format: req.Format,
+	})
 	if err != nil {
-		if errors.Is(err, llm.ErrRateLimited) {
-			h.logger.Warn("Upstream LLM rate limited", zap.Error(err))
-			msg := "Upstream LLM service overloaded; please try again later"
-			c.JSON(http.StatusServiceUnavailable, models.TextCompletionResponse{
-				Success:      false,
-				ErrorMessage: &msg,
-			})
-			return
-		}
-
-		h.logger.Error("LLM completion failed", zap.Error(err))
-		errorMsg := fmt.Sprintf("Failed to generate completion: %s", err.Error())
-		c.JSON(http.StatusInternalServerError, models.TextCompletionResponse{
-			Success:      false,
-			ErrorMessage: &errorMsg,
-		})
+		abortServiceError(c, err)
 		return
 	}
 
-	h.logger.Info("Text completion successful")
-
 	c.JSON(http.StatusOK, models.TextCompletionResponse{
-		Success:    true,
-		Completion: &completion,
+		Success:      true,
+		Completion:   &data.Completion,
+		GenerationID: data.GenerationID,
+		Locale:       data.Locale,
+		Format:       data.Format,
 	})
 }
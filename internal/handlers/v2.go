@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/middleware"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DescribeJobsV2 handles POST /api/v2/jobs/describe
+func (h *JobsHandler) DescribeJobsV2(c *gin.Context) {
+	var req models.JobDescribeV2Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	if wantsAsync(c) {
+		h.submitTask(c, models.TaskJobDescribe, len(req.Jobs), func(ctx context.Context, progress func(int)) (interface{}, error) {
+			return h.service.DescribeJobs(ctx, service.DescribeInput{Jobs: req.Jobs, Format: req.Format})
+		})
+		return
+	}
+
+	usage := &llm.Usage{}
+	payload, err := h.service.DescribeJobs(c.Request.Context(), service.DescribeInput{Jobs: req.Jobs, Format: req.Format, Usage: usage})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.JobDescribeV2Response{
+		Success: true,
+		Data:    payload,
+		Usage:   usage.Totals(),
+	})
+}
+
+// CategorizeJobsV2 handles POST /api/v2/jobs/categorize
+func (h *JobsHandler) CategorizeJobsV2(c *gin.Context) {
+	var req models.JobCategorizationV2Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	h.logger.Info("Received job categorization request", zap.Int("job_count", len(req.Jobs)))
+
+	noCache := req.NoCache || noCacheRequested(c)
+
+	if wantsAsync(c) {
+		h.submitTask(c, models.TaskJobCategorize, len(req.Jobs), func(ctx context.Context, progress func(int)) (interface{}, error) {
+			return h.service.CategorizeJobs(ctx, service.CategorizeInput{Jobs: req.Jobs, NoCache: noCache, Progress: progress})
+		})
+		return
+	}
+
+	usage := &llm.Usage{}
+	results, err := h.service.CategorizeJobs(c.Request.Context(), service.CategorizeInput{Jobs: req.Jobs, NoCache: noCache, Usage: usage})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.JobCategorizationV2Response{
+		Success: true,
+		Data:    results,
+		Usage:   usage.Totals(),
+	})
+}
+
+// CompleteV2 handles POST /api/v2/chat/completion
+func (h *TextCompletionHandler) CompleteV2(c *gin.Context) {
+	var req models.TextCompletionV2Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	userID, ok := v2UserID(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	usage := &llm.Usage{}
+	data, err := h.service.Complete(c.Request.Context(), service.CompleteInput{
+		Owner:  middleware.ServiceKey(c),
+		Text:   req.Text,
+		UserID: userID,
+		Locale: req.Locale,
+		Format: req.Format,
+		Usage:  usage,
+	})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CompletionV2Response{
+		Success: true,
+		Data:    *data,
+		Usage:   usage.Totals(),
+	})
+}
+
+// GeneratePromptV2 handles POST /api/v2/actions/generate-prompt
+func (h *PromptsHandler) GeneratePromptV2(c *gin.Context) {
+	var req models.PromptGenerationV2Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	userID, ok := v2UserID(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	usage := &llm.Usage{}
+	data, err := h.service.GenerateFromTemplate(c.Request.Context(), service.GenerateInput{
+		Owner:   middleware.ServiceKey(c),
+		Request: req.PromptGenerationRequest(),
+		UserID:  userID,
+		Usage:   usage,
+	})
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CompletionV2Response{
+		Success: true,
+		Data:    *data,
+		Usage:   usage.Totals(),
+	})
+}
+
+// RenderPromptV2 handles POST /api/v2/actions/render-prompt
+func (h *PromptsHandler) RenderPromptV2(c *gin.Context) {
+	var req models.PromptGenerationV2Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: %s", err.Error()))
+		return
+	}
+
+	userID, ok := v2UserID(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.RenderTemplate(c.Request.Context(), req.PromptGenerationRequest(), userID)
+	if err != nil {
+		abortServiceError(c, err)
+		return
+	}
+
+	// A preview doesn't call the LLM, so its usage is empty
+	usage := &llm.Usage{}
+	c.JSON(http.StatusOK, models.RenderPromptV2Response{
+		Success: true,
+		Data:    *data,
+		Usage:   usage.Totals(),
+	})
+}
+
+// v2UserID takes the user from the body or the X-User-ID header. Sending both is
+// allowed only when they agree; otherwise it responds with the error and returns false.
+func v2UserID(c *gin.Context, bodyUserID string) (string, bool) {
+	headerUserID := c.GetHeader(middleware.UserIDHeader)
+	if bodyUserID != "" && headerUserID != "" && bodyUserID != headerUserID {
+		middleware.AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest,
+			fmt.Sprintf("Invalid request: user_id %q does not match the %s header %q", bodyUserID, middleware.UserIDHeader, headerUserID))
+		return "", false
+	}
+	if bodyUserID != "" {
+		return bodyUserID, true
+	}
+	return headerUserID, true
+}
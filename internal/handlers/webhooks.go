@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebhooksHandler exposes webhook delivery state to admin services
+type WebhooksHandler struct {
+	dispatcher *webhooks.Dispatcher
+	logger     *zap.Logger
+}
+
+// NewWebhooksHandler creates a new webhooks handler
+func NewWebhooksHandler(dispatcher *webhooks.Dispatcher, logger *zap.Logger) *WebhooksHandler {
+	return &WebhooksHandler{
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// DeadLetters handles GET /api/v1/admin/webhooks/dead-letters
+func (h *WebhooksHandler) DeadLetters(c *gin.Context) {
+	c.JSON(http.StatusOK, models.WebhookDeadLettersResponse{
+		Success: true,
+		Data:    h.dispatcher.DeadLetters(),
+	})
+}
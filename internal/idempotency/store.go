@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBodyMismatch is returned when a key is reused with a different request body
+	ErrBodyMismatch = errors.New("idempotency: key reused with a different request body")
+)
+
+// Response is a stored response replayed for duplicate requests
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// entry tracks a single idempotency key. done is closed once the first request
+// has finished; response is nil if its result was not stored.
+type entry struct {
+	key       string
+	bodyHash  string
+	done      chan struct{}
+	response  *Response
+	expiresAt time.Time
+}
+
+// Store remembers responses by idempotency key for a fixed window. Once maxEntries
+// is reached the least recently used stored responses are evicted; keys still in
+// flight are never evicted.
+type Store struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	ttl        time.Duration
+	lastSweep  time.Time
+}
+
+// NewStore creates an idempotency store holding at most maxEntries responses for ttl
+func NewStore(maxEntries int, ttl time.Duration) *Store {
+	return &Store{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		lastSweep:  time.Now(),
+	}
+}
+
+// Begin claims a key for a request with the given body hash. If the key is new the
+// caller owns it and must call Complete. Otherwise the stored (or in-flight) result is
+// awaited and returned; if the in-flight request's result isn't stored, the key is
+// claimed again. ErrBodyMismatch is returned if the body differs.
+func (s *Store) Begin(ctx context.Context, key, bodyHash string) (*Response, bool, error) {
+	for {
+		response, owner, retry, err := s.begin(ctx, key, bodyHash)
+		if !retry {
+			return response, owner, err
+		}
+	}
+}
+
+// begin makes one attempt at Begin. It reports retry when the request it waited for
+// released the key without storing a response.
+func (s *Store) begin(ctx context.Context, key, bodyHash string) (*Response, bool, bool, error) {
+	s.mu.Lock()
+	now := time.Now()
+	s.sweep(now)
+
+	elem, ok := s.entries[key]
+	if ok && elem.Value.(*entry).expired(now) {
+		s.removeElement(elem)
+		ok = false
+	}
+
+	if !ok {
+		s.entries[key] = s.order.PushFront(&entry{
+			key:      key,
+			bodyHash: bodyHash,
+			done:     make(chan struct{}),
+		})
+		s.evict()
+		s.mu.Unlock()
+		return nil, true, false, nil
+	}
+	s.order.MoveToFront(elem)
+	e := elem.Value.(*entry)
+	s.mu.Unlock()
+
+	if e.bodyHash != bodyHash {
+		return nil, false, false, ErrBodyMismatch
+	}
+
+	// Wait for the in-flight request to finish
+	select {
+	case <-e.done:
+		return e.response, false, e.response == nil, nil
+	case <-ctx.Done():
+		return nil, false, false, ctx.Err()
+	}
+}
+
+// Complete stores the response for a key claimed with Begin and releases waiters.
+// When persist is false (e.g. upstream 5xx or a panic) the response is dropped and
+// the key released, so waiters and later retries run the request again.
+func (s *Store) Complete(key string, response *Response, persist bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e := elem.Value.(*entry)
+
+	if !persist {
+		s.removeElement(elem)
+		close(e.done)
+		return
+	}
+
+	e.response = response
+	e.expiresAt = time.Now().Add(s.ttl)
+	close(e.done)
+}
+
+// sweep removes expired entries at most once per minute. Callers must hold the lock.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for _, elem := range s.entries {
+		if elem.Value.(*entry).expired(now) {
+			s.removeElement(elem)
+		}
+	}
+}
+
+// evict drops the least recently used stored responses while over capacity. Callers
+// must hold the lock.
+func (s *Store) evict() {
+	elem := s.order.Back()
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries && elem != nil {
+		prev := elem.Prev()
+		if !elem.Value.(*entry).expiresAt.IsZero() {
+			s.removeElement(elem)
+		}
+		elem = prev
+	}
+}
+
+// Len returns the number of keys held, including those in flight
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// removeElement drops an entry. Callers must hold the lock.
+func (s *Store) removeElement(elem *list.Element) {
+	delete(s.entries, elem.Value.(*entry).key)
+	s.order.Remove(elem)
+}
+
+// expired reports whether a stored response has outlived its ttl
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
@@ -0,0 +1,178 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStoreFirstRequestOwnsKey(t *testing.T) {
+	store := NewStore(100, time.Minute)
+
+	resp, owner, err := store.Begin(context.Background(), "k1", "hash-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !owner || resp != nil {
+		t.Errorf("expected first request to own the key, got owner=%v resp=%v", owner, resp)
+	}
+}
+
+func TestStoreReplaysCompletedResponse(t *testing.T) {
+	store := NewStore(100, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+	store.Complete("k1", &Response{StatusCode: 200, Body: []byte("first")}, true)
+
+	resp, owner, err := store.Begin(ctx, "k1", "hash-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if owner {
+		t.Error("expected duplicate not to own the key")
+	}
+
+	if resp == nil || string(resp.Body) != "first" {
+		t.Errorf("expected replayed body 'first', got %v", resp)
+	}
+}
+
+func TestStoreRejectsBodyMismatch(t *testing.T) {
+	store := NewStore(100, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+
+	if _, _, err := store.Begin(ctx, "k1", "hash-b"); !errors.Is(err, ErrBodyMismatch) {
+		t.Errorf("expected ErrBodyMismatch, got %v", err)
+	}
+}
+
+func TestStoreConcurrentDuplicateWaits(t *testing.T) {
+	store := NewStore(100, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+
+	result := make(chan *Response, 1)
+	go func() {
+		resp, _, _ := store.Begin(ctx, "k1", "hash-a")
+		result <- resp
+	}()
+
+	select {
+	case <-result:
+		t.Fatal("duplicate returned before the first request completed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	store.Complete("k1", &Response{StatusCode: 201, Body: []byte("done")}, true)
+
+	select {
+	case resp := <-result:
+		if resp == nil || resp.StatusCode != 201 {
+			t.Errorf("expected waiter to receive stored response, got %v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released")
+	}
+}
+
+func TestStoreNonPersistedResponseReleasesKey(t *testing.T) {
+	store := NewStore(100, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+	store.Complete("k1", &Response{StatusCode: 503}, false)
+
+	_, owner, err := store.Begin(ctx, "k1", "hash-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !owner {
+		t.Error("expected retry after a server error to run again")
+	}
+}
+
+func TestStoreExpiredKeyRunsAgain(t *testing.T) {
+	store := NewStore(100, time.Millisecond)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+	store.Complete("k1", &Response{StatusCode: 200}, true)
+	time.Sleep(5 * time.Millisecond)
+
+	_, owner, _ := store.Begin(ctx, "k1", "hash-b")
+	if !owner {
+		t.Error("expected expired key to be claimable again")
+	}
+}
+
+func TestStoreWaiterRunsAgainAfterUnstoredResponse(t *testing.T) {
+	store := NewStore(100, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+
+	type result struct {
+		resp  *Response
+		owner bool
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		resp, owner, _ := store.Begin(ctx, "k1", "hash-a")
+		waiter <- result{resp, owner}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	store.Complete("k1", &Response{StatusCode: 502}, false)
+
+	select {
+	case r := <-waiter:
+		if !r.owner || r.resp != nil {
+			t.Errorf("expected the waiter to own the key instead of a replay, got owner=%v resp=%v", r.owner, r.resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released")
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewStore(2, time.Minute)
+	ctx := context.Background()
+
+	for _, key := range []string{"k1", "k2"} {
+		store.Begin(ctx, key, "hash-a")
+		store.Complete(key, &Response{StatusCode: 200}, true)
+	}
+	// Replaying k1 makes k2 the least recently used
+	store.Begin(ctx, "k1", "hash-a")
+	store.Begin(ctx, "k3", "hash-a")
+
+	if got := store.Len(); got != 2 {
+		t.Errorf("expected 2 entries after eviction, got %d", got)
+	}
+	if _, owner, _ := store.Begin(ctx, "k1", "hash-a"); owner {
+		t.Error("expected k1 to still be stored")
+	}
+	if _, owner, _ := store.Begin(ctx, "k2", "hash-a"); !owner {
+		t.Error("expected k2 to be evicted")
+	}
+}
+
+func TestStoreKeepsInFlightKeysOverCapacity(t *testing.T) {
+	store := NewStore(1, time.Minute)
+	ctx := context.Background()
+
+	store.Begin(ctx, "k1", "hash-a")
+	store.Begin(ctx, "k2", "hash-a")
+
+	if got := store.Len(); got != 2 {
+		t.Errorf("expected in-flight keys to be kept, got %d entries", got)
+	}
+}
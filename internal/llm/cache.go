@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Cache is a content-addressed LRU cache of completions with a TTL. Entries are
+// keyed on the full upstream request (model, parameters and message list).
+type Cache struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	ttl        time.Duration
+
+	hits   int
+	misses int
+}
+
+// cacheEntry is a single cached completion
+type cacheEntry struct {
+	key        string
+	completion string
+	expiresAt  time.Time
+}
+
+// NewCache creates a cache holding at most maxEntries completions for ttl
+func NewCache(maxEntries int, ttl time.Duration) *Cache {
+	return &Cache{
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+	}
+}
+
+// CacheKey derives the cache key for an upstream request
+func CacheKey(req ChatCompletionRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a cached completion if present and not expired
+func (c *Cache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return "", false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses++
+		return "", false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return entry.completion, true
+}
+
+// Set stores a completion, evicting the least recently used entry when full
+func (c *Cache) Set(key, completion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.completion = completion
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheEntry{
+		key:        key,
+		completion: completion,
+		expiresAt:  time.Now().Add(c.ttl),
+	})
+	c.items[key] = elem
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the hit and miss counts since the cache was created
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// removeElement drops an entry. Callers must hold the lock.
+func (c *Cache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	delete(c.items, entry.key)
+	c.order.Remove(elem)
+}
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// failingTransport fails every request so tests can assert no upstream call is made
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("unexpected upstream call")
+}
+
+func TestCacheKeyDependsOnRequest(t *testing.T) {
+	base := ChatCompletionRequest{
+		Model:       "gpt-test",
+		Messages:    []Message{{Role: "user", Content: "Categorize this"}},
+		MaxTokens:   100,
+		Temperature: 0,
+		TopP:        1,
+	}
+
+	if CacheKey(base) != CacheKey(base) {
+		t.Error("expected identical requests to share a cache key")
+	}
+
+	changedTemp := base
+	changedTemp.Temperature = 0.6
+	if CacheKey(base) == CacheKey(changedTemp) {
+		t.Error("expected temperature to change the cache key")
+	}
+
+	changedMessages := base
+	changedMessages.Messages = []Message{{Role: "user", Content: "Something else"}}
+	if CacheKey(base) == CacheKey(changedMessages) {
+		t.Error("expected messages to change the cache key")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(2, time.Minute)
+
+	cache.Set("a", "1")
+	cache.Set("b", "2")
+	cache.Get("a")
+	cache.Set("c", "3")
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+
+	if v, ok := cache.Get("a"); !ok || v != "1" {
+		t.Errorf("expected 'a' to remain cached, got %q, %v", v, ok)
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	cache := NewCache(10, time.Millisecond)
+
+	cache.Set("a", "1")
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected expired entry to be missing")
+	}
+
+	hits, misses := cache.Stats()
+	if hits != 0 || misses != 1 {
+		t.Errorf("expected 0 hits and 1 miss, got %d and %d", hits, misses)
+	}
+}
+
+func TestCompleteWithOptionsServesFromCache(t *testing.T) {
+	logger, _ := initTestLogger()
+	client := NewClient("test-key", logger)
+	client.httpClient = &http.Client{Transport: failingTransport{}}
+	client.SetCache(NewCache(10, time.Minute))
+
+	opts := DefaultOptions()
+	opts.Temperature = 0
+	opts.Cache = true
+
+	key := CacheKey(ChatCompletionRequest{
+		Model:       modelName,
+		Messages:    client.buildMessages("Categorize this", nil),
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+	})
+	client.cache.Set(key, "web-development")
+
+	completion, err := client.CompleteWithOptions(context.Background(), "Categorize this", nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if completion != "web-development" {
+		t.Errorf("expected cached completion, got %q", completion)
+	}
+
+	// Without opting in, the cache is bypassed and the upstream call fails
+	opts.Cache = false
+	if _, err := client.CompleteWithOptions(context.Background(), "Categorize this", nil, opts); err == nil {
+		t.Error("expected upstream call when cache is not requested")
+	}
+}
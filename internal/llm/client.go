@@ -2,6 +2,7 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,8 +17,9 @@ import (
 )
 
 const (
-	cerebrasAPIURL = "https://api.cerebras.ai/v1/chat/completions"
-	modelName      = "gpt-oss-120b"
+	// DefaultAPIURL is the Cerebras chat completions endpoint
+	DefaultAPIURL = "https://api.cerebras.ai/v1/chat/completions"
+	modelName     = "gpt-oss-120b"
 	maxTokens      = 40960
 	temperature    = 0.6
 	topP           = 0.95
@@ -31,6 +33,9 @@ var (
 const (
 	llmMaxRetries   = 3
 	llmBackoffBaseMs = 500
+	// requestTimeout bounds a whole upstream request, including reading a stream, so a
+	// stuck upstream can't hold a worker forever
+	requestTimeout = 5 * time.Minute
 )
 
 // Message represents a chat message
@@ -79,10 +84,37 @@ type ErrorResponse struct {
 	} `json:"error"`
 }
 
+// Options controls generation parameters for a single completion
+type Options struct {
+	MaxTokens   int
+	Temperature float64
+	TopP        float64
+
+	// SystemPrompt is an extra system message added after the base system messages
+	SystemPrompt string
+
+	// Cache serves and stores the completion in the client's response cache, if one is set
+	Cache bool
+
+	// Usage, when set, records the call and the tokens it used
+	Usage *Usage
+}
+
+// DefaultOptions returns the default generation parameters
+func DefaultOptions() Options {
+	return Options{
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		TopP:        topP,
+	}
+}
+
 // Client handles LLM API requests
 type Client struct {
 	apiKey     string
+	apiURL     string
 	httpClient *http.Client
+	cache      *Cache
 	logger     *zap.Logger
 }
 
@@ -90,23 +122,82 @@ type Client struct {
 func NewClient(apiKey string, logger *zap.Logger) *Client {
 	return &Client{
 		apiKey:     apiKey,
-		httpClient: &http.Client{},
+		apiURL:     DefaultAPIURL,
+		httpClient: &http.Client{Timeout: requestTimeout},
 		logger:     logger,
 	}
 }
 
+// SetCache enables the response cache for completions requested with Options.Cache
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// SetAPIURL points the client at another OpenAI-compatible chat completions endpoint
+func (c *Client) SetAPIURL(url string) {
+	c.apiURL = url
+}
+
+// SetHTTPClient replaces the HTTP client used for API requests, e.g. to add a
+// recording transport in tests
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
 // Complete sends a completion request to the LLM API
-func (c *Client) Complete(userPrompt string, user *models.AuthUser) (string, error) {
+func (c *Client) Complete(ctx context.Context, userPrompt string, user *models.AuthUser) (string, error) {
+	return c.CompleteWithOptions(ctx, userPrompt, user, DefaultOptions())
+}
+
+// CompleteWithOptions sends a completion request with explicit generation parameters.
+// Cancelling ctx stops the request, including any wait before a retry.
+func (c *Client) CompleteWithOptions(ctx context.Context, userPrompt string, user *models.AuthUser, opts Options) (string, error) {
+	reqBody := c.Request(userPrompt, user, opts)
+
+	useCache := opts.Cache && c.cache != nil
+	cacheKey := ""
+	if useCache {
+		cacheKey = CacheKey(reqBody)
+		if completion, ok := c.cache.Get(cacheKey); ok {
+			c.logger.Debug("LLM completion served from cache", zap.String("cache_key", cacheKey))
+			opts.Usage.addCached()
+			return completion, nil
+		}
+	}
+
+	completion, err := c.send(ctx, reqBody, opts.Usage)
+	if err != nil {
+		return "", err
+	}
+
+	if useCache {
+		c.cache.Set(cacheKey, completion)
+	}
+
+	return completion, nil
+}
+
+// Request builds the exact request body a completion would send, without sending it
+func (c *Client) Request(userPrompt string, user *models.AuthUser, opts Options) ChatCompletionRequest {
 	messages := c.buildMessages(userPrompt, user)
+	if opts.SystemPrompt != "" {
+		messages = withSystemPrompt(messages, opts.SystemPrompt)
+	}
 
-	reqBody := ChatCompletionRequest{
+	return ChatCompletionRequest{
 		Model:       modelName,
 		Messages:    messages,
-		MaxTokens:   maxTokens,
-		Temperature: temperature,
-		TopP:        topP,
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
 		Stream:      false,
 	}
+}
+
+// send posts a chat completion request upstream, retrying on rate limits until ctx
+// is done
+func (c *Client) send(ctx context.Context, reqBody ChatCompletionRequest, usage *Usage) (string, error) {
+	messages := reqBody.Messages
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -120,7 +211,7 @@ func (c *Client) Complete(userPrompt string, user *models.AuthUser) (string, err
 
 	// Retry loop for transient upstream throttling (HTTP 429)
 	for attempt := 0; attempt < llmMaxRetries; attempt++ {
-		req, err := http.NewRequest("POST", cerebrasAPIURL, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return "", fmt.Errorf("failed to create request: %w", err)
 		}
@@ -158,6 +249,7 @@ func (c *Client) Complete(userPrompt string, user *models.AuthUser) (string, err
 				zap.Int("completion_tokens", completionResp.Usage.CompletionTokens),
 				zap.Int("total_tokens", completionResp.Usage.TotalTokens),
 			)
+			usage.add(completionResp.Usage.PromptTokens, completionResp.Usage.CompletionTokens, completionResp.Usage.TotalTokens)
 
 			return completion, nil
 		}
@@ -183,7 +275,11 @@ func (c *Client) Complete(userPrompt string, user *models.AuthUser) (string, err
 				backoff := time.Duration(llmBackoffBaseMs*(1<<attempt)) * time.Millisecond
 				// jitter up to 100ms
 				jitter := time.Duration(rand.Intn(100)) * time.Millisecond
-				time.Sleep(backoff + jitter)
+				select {
+				case <-time.After(backoff + jitter):
+				case <-ctx.Done():
+					return "", ctx.Err()
+				}
 				continue
 			}
 
@@ -209,6 +305,20 @@ func (c *Client) Complete(userPrompt string, user *models.AuthUser) (string, err
 
 // buildMessages constructs the message array for the LLM request
 func (c *Client) buildMessages(userPrompt string, user *models.AuthUser) []Message {
+	messages := c.contextMessages(user)
+
+	// Add user prompt
+	messages = append(messages, Message{
+		Role:    "user",
+		Content: userPrompt,
+	})
+
+	return messages
+}
+
+// contextMessages returns the base system messages followed by the user's context,
+// if any
+func (c *Client) contextMessages(user *models.AuthUser) []Message {
 	messages := make([]Message, 0, len(constants.SystemMessages)+2)
 
 	// Add system messages
@@ -254,11 +364,14 @@ func (c *Client) buildMessages(userPrompt string, user *models.AuthUser) []Messa
 		})
 	}
 
-	// Add user prompt
-	messages = append(messages, Message{
-		Role:    "user",
-		Content: userPrompt,
-	})
-
 	return messages
 }
+
+// withSystemPrompt inserts an extra system message right after the base system messages
+func withSystemPrompt(messages []Message, systemPrompt string) []Message {
+	idx := len(constants.SystemMessages)
+	result := make([]Message, 0, len(messages)+1)
+	result = append(result, messages[:idx]...)
+	result = append(result, Message{Role: "system", Content: systemPrompt})
+	return append(result, messages[idx:]...)
+}
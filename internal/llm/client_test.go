@@ -1,8 +1,16 @@
 package llm
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dokoola/llm-go/internal/constants"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -223,3 +231,232 @@ func TestClientAPIKeyStorage(t *testing.T) {
 		t.Error("expected client to be created with API key")
 	}
 }
+
+func TestClientRequest(t *testing.T) {
+	logger, _ := initTestLogger()
+	defer logger.Sync()
+
+	client := NewClient("test-key", logger)
+	opts := DefaultOptions()
+	opts.SystemPrompt = "Write in French."
+	user := &models.AuthUser{Name: "Ada", PublicID: "u1", IsTalent: true}
+
+	req := client.Request("Write a bio", user, opts)
+
+	base := len(constants.SystemMessages)
+	if len(req.Messages) != base+3 {
+		t.Fatalf("expected %d messages, got %d", base+3, len(req.Messages))
+	}
+	if req.Messages[base].Content != "Write in French." {
+		t.Errorf("expected template system prompt after base messages, got %q", req.Messages[base].Content)
+	}
+	if got := req.Messages[base+1].Content; got == "" || req.Messages[base+1].Role != "system" {
+		t.Errorf("expected user context message, got %+v", req.Messages[base+1])
+	}
+	if last := req.Messages[len(req.Messages)-1]; last.Role != "user" || last.Content != "Write a bio" {
+		t.Errorf("expected user prompt last, got %+v", last)
+	}
+	if req.Model != modelName || req.MaxTokens != maxTokens {
+		t.Errorf("unexpected request params: %+v", req)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "abcd"},
+		{Role: "user", Content: "abcde"},
+	}
+
+	// 1 + 2 content tokens plus per-message overhead
+	if got, want := EstimateTokens(messages), 3+2*messageOverheadTokens; got != want {
+		t.Errorf("EstimateTokens() = %d, want %d", got, want)
+	}
+	if got := EstimateTokens(nil); got != 0 {
+		t.Errorf("EstimateTokens(nil) = %d, want 0", got)
+	}
+}
+
+func newFakeServerClient(t *testing.T) (*Client, *llmtest.Server) {
+	t.Helper()
+	logger, _ := initTestLogger()
+
+	server := llmtest.NewServer(t)
+	client := NewClient("test-key", logger)
+	client.SetAPIURL(server.URL())
+	return client, server
+}
+
+func TestClientCompleteWithFakeServer(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	server.Enqueue(llmtest.Reply("I build reliable systems."))
+
+	completion, err := client.Complete(context.Background(), "Write a bio", &models.AuthUser{Name: "Ada", IsTalent: true})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if completion != "I build reliable systems." {
+		t.Errorf("Complete() = %q", completion)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	if requests[0].Model != modelName || requests[0].UserPrompt() != "Write a bio" {
+		t.Errorf("unexpected request: %+v", requests[0])
+	}
+	if len(requests[0].Messages) != len(constants.SystemMessages)+2 {
+		t.Errorf("expected system, user context and user messages, got %d", len(requests[0].Messages))
+	}
+}
+
+func TestClientCompleteConversation(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	server.Enqueue(llmtest.Reply("A shorter bio."))
+
+	opts := DefaultOptions()
+	opts.SystemPrompt = "Earlier: the user asked for a bio."
+	turns := []Message{
+		{Role: RoleUser, Content: "Write a bio"},
+		{Role: RoleAssistant, Content: "A long bio."},
+		{Role: RoleUser, Content: "Make it shorter"},
+	}
+
+	completion, err := client.CompleteConversation(context.Background(), turns, nil, opts)
+	if err != nil {
+		t.Fatalf("CompleteConversation() error = %v", err)
+	}
+	if completion != "A shorter bio." {
+		t.Errorf("CompleteConversation() = %q", completion)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	messages := requests[0].Messages
+	base := len(constants.SystemMessages)
+	if len(messages) != base+1+len(turns) || messages[base].Content != opts.SystemPrompt {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+	for i, turn := range turns {
+		if got := messages[base+1+i]; got.Role != turn.Role || got.Content != turn.Content {
+			t.Errorf("turn %d = %+v, want %+v", i, got, turn)
+		}
+	}
+}
+
+func TestClientCompleteRetriesRateLimit(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	server.Enqueue(llmtest.RateLimited(), llmtest.Reply("ok"))
+
+	completion, err := client.Complete(context.Background(), "hello", nil)
+	if err != nil || completion != "ok" {
+		t.Fatalf("Complete() = %q, %v", completion, err)
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("expected a retry after 429, got %d requests", got)
+	}
+}
+
+func TestClientCompleteStopsRetryOnCancel(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	server.Enqueue(llmtest.RateLimited(), llmtest.Reply("ok"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.Complete(ctx, "hello", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Complete() error = %v, want context.Canceled", err)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("expected no retry after cancellation, got %d requests", got)
+	}
+}
+
+func TestClientCompleteRecordsUsage(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	client.SetCache(NewCache(10, time.Minute))
+	server.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply("a category") })
+
+	usage := &Usage{}
+	opts := DefaultOptions()
+	opts.Cache = true
+	opts.Usage = usage
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CompleteWithOptions(context.Background(), "categorize this", nil, opts); err != nil {
+			t.Fatalf("CompleteWithOptions() error = %v", err)
+		}
+	}
+
+	totals := usage.Totals()
+	if totals.LLMCalls != 1 || totals.CachedCalls != 1 {
+		t.Errorf("expected one upstream and one cached call, got %+v", totals)
+	}
+	if totals.PromptTokens == 0 || totals.TotalTokens != totals.PromptTokens+totals.CompletionTokens || totals.Model != modelName {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+
+	// A nil Usage records nothing
+	var none *Usage
+	if got := none.Totals(); got.LLMCalls != 0 || got.Model != modelName {
+		t.Errorf("nil Usage totals = %+v", got)
+	}
+}
+
+func TestClientCompleteErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response llmtest.Response
+		want     string
+	}{
+		{"malformed", llmtest.Malformed(), "failed to unmarshal response"},
+		{"api error", llmtest.Error(http.StatusBadRequest, "context length exceeded"), "context length exceeded"},
+		{"no choices", llmtest.Response{Body: `{"choices": []}`}, "no completion choices"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newFakeServerClient(t)
+			server.Enqueue(tt.response)
+
+			_, err := client.Complete(context.Background(), "hello", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Complete() error = %v, want %q", err, tt.want)
+			}
+			if errors.Is(err, ErrRateLimited) {
+				t.Error("non-429 errors must not be reported as rate limiting")
+			}
+		})
+	}
+}
+
+func TestClientCompleteRecordReplay(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	dir := t.TempDir()
+
+	client.SetHTTPClient(llmtest.NewTransport(dir, llmtest.Record).Client())
+	recorded, err := client.Complete(context.Background(), "hello", nil)
+	if err != nil {
+		t.Fatalf("recording Complete() error = %v", err)
+	}
+
+	// Replaying never reaches the server
+	client.SetHTTPClient(llmtest.NewTransport(dir, llmtest.Replay).Client())
+	replayed, err := client.Complete(context.Background(), "hello", nil)
+	if err != nil || replayed != recorded {
+		t.Errorf("replayed Complete() = %q, %v; want %q", replayed, err, recorded)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("expected 1 upstream request, got %d", got)
+	}
+
+	if _, err := client.Complete(context.Background(), "something else", nil); !errors.Is(err, llmtest.ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for an unrecorded request, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Message roles of conversation turns
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ConversationRequest builds the request body for a multi-turn completion: the base
+// system messages, the user context, then the turns in order, ending with the
+// user's latest message
+func (c *Client) ConversationRequest(turns []Message, user *models.AuthUser, opts Options) ChatCompletionRequest {
+	messages := append(c.contextMessages(user), turns...)
+	if opts.SystemPrompt != "" {
+		messages = withSystemPrompt(messages, opts.SystemPrompt)
+	}
+
+	return ChatCompletionRequest{
+		Model:       modelName,
+		Messages:    messages,
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		Stream:      false,
+	}
+}
+
+// CompleteConversation gets the next assistant turn of a conversation. Conversation
+// completions are never cached, since their history makes each request unique.
+func (c *Client) CompleteConversation(ctx context.Context, turns []Message, user *models.AuthUser, opts Options) (string, error) {
+	return c.send(ctx, c.ConversationRequest(turns, user, opts), opts.Usage)
+}
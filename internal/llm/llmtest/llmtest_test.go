@@ -0,0 +1,117 @@
+package llmtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, url string, req Request) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerStreamsChunks(t *testing.T) {
+	server := NewServer(t)
+	server.Enqueue(Response{Chunks: []string{"Hello", ", ", "world"}})
+
+	resp := post(t, server.URL(), Request{Model: "test", Stream: true, Messages: []Message{{Role: "user", Content: "hi"}}})
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	var content strings.Builder
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+
+	if !done || content.String() != "Hello, world" {
+		t.Errorf("streamed %q (done %v)", content.String(), done)
+	}
+}
+
+func TestServerRespondAndDelay(t *testing.T) {
+	server := NewServer(t)
+	server.Respond(func(req Request) Response {
+		return Response{Content: strings.ToUpper(req.UserPrompt()), Delay: 50 * time.Millisecond}
+	})
+
+	start := time.Now()
+	resp := post(t, server.URL(), Request{Messages: []Message{{Role: "system", Content: "s"}, {Role: "user", Content: "shout"}}})
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the reply to be delayed, took %v", elapsed)
+	}
+
+	var body struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	if got := body.Choices[0].Message.Content; got != "SHOUT" {
+		t.Errorf("content = %q, want SHOUT", got)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("expected 1 recorded request, got %d", got)
+	}
+}
+
+func TestServerScriptedErrors(t *testing.T) {
+	server := NewServer(t)
+	server.Enqueue(RateLimited(), Error(http.StatusUnauthorized, "bad key"))
+
+	if resp := post(t, server.URL(), Request{}); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("first status = %d, want 429", resp.StatusCode)
+	}
+	if resp := post(t, server.URL(), Request{}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("second status = %d, want 401", resp.StatusCode)
+	}
+	// Once the script runs out, the default responder echoes
+	if resp := post(t, server.URL(), Request{}); resp.StatusCode != http.StatusOK {
+		t.Errorf("third status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(RecordEnv, "")
+	if ModeFromEnv() != Replay {
+		t.Error("expected replay by default")
+	}
+	t.Setenv(RecordEnv, "1")
+	if ModeFromEnv() != Record {
+		t.Error("expected record when LLMTEST_RECORD=1")
+	}
+}
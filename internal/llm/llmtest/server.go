@@ -0,0 +1,262 @@
+// Package llmtest provides test doubles for the OpenAI-compatible chat completions
+// API: an in-process fake server with scripted replies, and an HTTP transport that
+// records real exchanges to fixture files and replays them.
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// completionsPath is where the fake server serves chat completions
+const completionsPath = "/v1/chat/completions"
+
+// Message is a chat message in a request
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request received by the fake server
+type Request struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature"`
+	TopP        float64   `json:"top_p"`
+	Stream      bool      `json:"stream"`
+}
+
+// UserPrompt returns the content of the last user message
+func (r Request) UserPrompt() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Response is a scripted reply
+type Response struct {
+	// Content is the completion text
+	Content string
+	// Status is the HTTP status; zero means 200
+	Status int
+	// Body is sent as-is instead of a generated body, e.g. for malformed JSON
+	Body string
+	// Delay is waited before replying
+	Delay time.Duration
+	// Chunks are the content deltas sent to streaming requests; when empty the
+	// content is streamed word by word
+	Chunks []string
+}
+
+// Reply is a successful completion
+func Reply(content string) Response {
+	return Response{Content: content}
+}
+
+// RateLimited is a 429 reply
+func RateLimited() Response {
+	return Response{Status: http.StatusTooManyRequests, Body: `{"message":"Too many requests, please slow down"}`}
+}
+
+// Error is an API error reply in the OpenAI error format
+func Error(status int, message string) Response {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]string{"message": message, "type": "invalid_request_error", "code": fmt.Sprint(status)},
+	})
+	return Response{Status: status, Body: string(body)}
+}
+
+// Malformed is a 200 reply whose body is not valid JSON
+func Malformed() Response {
+	return Response{Body: `{"id": "chatcmpl-broken", "choices": [`}
+}
+
+// Server is an in-process fake of the chat completions API. Replies are taken from
+// a queue of scripted responses, then from the responder, which echoes the user
+// prompt by default.
+type Server struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	queue     []Response
+	responder func(Request) Response
+	requests  []Request
+}
+
+// NewServer starts a fake server. It is closed when the test finishes.
+func NewServer(t interface{ Cleanup(func()) }) *Server {
+	s := &Server{
+		responder: func(req Request) Response { return Reply("echo: " + req.UserPrompt()) },
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// URL is the chat completions endpoint to configure clients with
+func (s *Server) URL() string {
+	return s.server.URL + completionsPath
+}
+
+// Enqueue scripts the next replies, in order
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, responses...)
+}
+
+// Respond sets how requests are answered once the queue is empty
+func (s *Server) Respond(responder func(Request) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responder = responder
+}
+
+// Requests returns every request received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// next records a request and picks its reply
+func (s *Server) next(req Request) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	if len(s.queue) > 0 {
+		resp := s.queue[0]
+		s.queue = s.queue[1:]
+		return resp
+	}
+	return s.responder(req)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != completionsPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req Request
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeBody(w, Error(http.StatusBadRequest, "invalid JSON body: "+err.Error()))
+		return
+	}
+
+	resp := s.next(req)
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if resp.Body != "" || (resp.Status != 0 && resp.Status != http.StatusOK) {
+		writeBody(w, resp)
+		return
+	}
+
+	if req.Stream {
+		stream(w, req, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(completion(req, resp.Content))
+}
+
+// writeBody sends a response's raw body and status
+func writeBody(w http.ResponseWriter, resp Response) {
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, resp.Body)
+}
+
+// completion builds a chat completion body with rough token usage
+func completion(req Request, content string) map[string]interface{} {
+	promptTokens := 0
+	for _, m := range req.Messages {
+		promptTokens += len(m.Content) / 4
+	}
+	completionTokens := len(content) / 4
+
+	return map[string]interface{}{
+		"id":      "chatcmpl-llmtest",
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      promptTokens + completionTokens,
+		},
+	}
+}
+
+// stream sends the reply as server-sent events, one content delta per event
+func stream(w http.ResponseWriter, req Request, resp Response) {
+	chunks := resp.Chunks
+	if len(chunks) == 0 {
+		for i, word := range strings.SplitAfter(resp.Content, " ") {
+			if word != "" || i == 0 {
+				chunks = append(chunks, word)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	send := func(delta map[string]string, finish interface{}) {
+		chunk := map[string]interface{}{
+			"id":      "chatcmpl-llmtest",
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   req.Model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+		// The final chunk reports usage for the whole completion, as Cerebras does
+		if finish != nil {
+			chunk["usage"] = completion(req, resp.Content)["usage"]
+		}
+		event, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	send(map[string]string{"role": "assistant"}, nil)
+	for _, chunk := range chunks {
+		send(map[string]string{"content": chunk}, nil)
+	}
+	send(map[string]string{}, "stop")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
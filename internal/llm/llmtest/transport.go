@@ -0,0 +1,197 @@
+package llmtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Mode selects whether a Transport records or replays exchanges
+type Mode int
+
+const (
+	// Replay serves responses from fixture files and never touches the network
+	Replay Mode = iota
+	// Record sends requests upstream and saves each exchange to a fixture file
+	Record
+)
+
+// RecordEnv is the environment variable that switches ModeFromEnv to Record
+const RecordEnv = "LLMTEST_RECORD"
+
+// ModeFromEnv returns Record when LLMTEST_RECORD is set to a non-empty value other
+// than "0", and Replay otherwise
+func ModeFromEnv() Mode {
+	if v := os.Getenv(RecordEnv); v != "" && v != "0" {
+		return Record
+	}
+	return Replay
+}
+
+// ErrNoRecording is returned in replay mode when no fixture matches a request
+var ErrNoRecording = errors.New("llmtest: no recorded exchange")
+
+// Exchange is a recorded request and response. Headers are not stored, so API keys
+// never end up in fixtures.
+type Exchange struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request that identifies it
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a saved upstream response
+type RecordedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Transport is an http.RoundTripper that records exchanges to, or replays them from,
+// <dir>/<hash>.json. Requests are matched on method, URL and JSON body.
+type Transport struct {
+	Dir  string
+	Mode Mode
+	// Base sends requests in record mode; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// NewTransport creates a transport over dir
+func NewTransport(dir string, mode Mode) *Transport {
+	return &Transport{Dir: dir, Mode: mode}
+}
+
+// Client returns an HTTP client using the transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded := RecordedRequest{Method: req.Method, URL: req.URL.String(), Body: canonicalJSON(body)}
+	path := filepath.Join(t.Dir, fixtureName(recorded))
+
+	if t.Mode == Replay {
+		return t.replay(req, recorded, path)
+	}
+	return t.record(req, recorded, path)
+}
+
+// replay serves a saved response
+func (t *Transport) replay(req *http.Request, recorded RecordedRequest, path string) (*http.Response, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s (%s); record it with %s=1", ErrNoRecording, recorded.Method, recorded.URL, filepath.Base(path), RecordEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var exchange Exchange
+	if err := json.Unmarshal(raw, &exchange); err != nil {
+		return nil, fmt.Errorf("llmtest: invalid fixture %s: %w", path, err)
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Response.Status, http.StatusText(exchange.Response.Status)),
+		StatusCode:    exchange.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader([]byte(exchange.Response.Body))),
+		ContentLength: int64(len(exchange.Response.Body)),
+		Request:       req,
+	}
+	if exchange.Response.ContentType != "" {
+		resp.Header.Set("Content-Type", exchange.Response.ContentType)
+	}
+	return resp, nil
+}
+
+// record sends a request upstream and saves the exchange
+func (t *Transport) record(req *http.Request, recorded RecordedRequest, path string) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        string(body),
+		},
+	}
+	raw, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// canonicalJSON re-encodes a JSON body with sorted keys so equivalent requests
+// match. Non-JSON bodies are stored as a JSON string.
+func canonicalJSON(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			return canonical
+		}
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// fixtureName derives a stable file name from a request
+func fixtureName(req RecordedRequest) string {
+	sum := sha256.New()
+	sum.Write([]byte(req.Method + " " + req.URL + "\n"))
+	sum.Write(req.Body)
+	return hex.EncodeToString(sum.Sum(nil))[:16] + ".json"
+}
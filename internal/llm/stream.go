@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// chatCompletionChunk is one server-sent event of a streamed completion. The last
+// chunk may carry the token usage of the whole completion.
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Stream sends a completion request and calls onDelta with each piece of content as
+// the model produces it. It returns the full completion. Streamed completions are
+// never cached. Rate limits are retried until the first byte of the stream; an
+// error from onDelta, or ctx being cancelled, stops the stream.
+func (c *Client) Stream(ctx context.Context, userPrompt string, user *models.AuthUser, opts Options, onDelta func(string) error) (string, error) {
+	reqBody := c.Request(userPrompt, user, opts)
+	reqBody.Stream = true
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	c.logger.Debug("Sending streaming completion request",
+		zap.String("model", modelName),
+		zap.Int("message_count", len(reqBody.Messages)),
+	)
+
+	for attempt := 0; attempt < llmMaxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewReader(jsonData))
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to send request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			defer resp.Body.Close()
+			return c.readStream(resp.Body, opts.Usage, onDelta)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			var errResp ErrorResponse
+			if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+				return "", fmt.Errorf("LLM API error: %s", errResp.Error.Message)
+			}
+			return "", fmt.Errorf("LLM API error: status %d, body: %s", resp.StatusCode, string(body))
+		}
+
+		c.logger.Warn("LLM API rate limited",
+			zap.Int("status_code", resp.StatusCode),
+			zap.Int("attempt", attempt+1),
+		)
+		if attempt == llmMaxRetries-1 {
+			return "", fmt.Errorf("%w: LLM API error: status %d, body: %s", ErrRateLimited, resp.StatusCode, string(body))
+		}
+
+		// Exponential backoff with jitter, as for unstreamed requests
+		backoff := time.Duration(llmBackoffBaseMs*(1<<attempt))*time.Millisecond + time.Duration(rand.Intn(100))*time.Millisecond
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	return "", fmt.Errorf("LLM API error: exhausted retries or unknown error")
+}
+
+// readStream reads server-sent events until the stream ends, passing each content
+// delta to onDelta
+func (c *Client) readStream(body io.Reader, usage *Usage, onDelta func(string) error) (string, error) {
+	var completion strings.Builder
+	recorded := false
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+
+		if chunk.Usage != nil && !recorded {
+			usage.add(chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens, chunk.Usage.TotalTokens)
+			recorded = true
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			completion.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// Providers that don't report usage on streams still count as a call
+	if !recorded {
+		usage.add(0, 0, 0)
+	}
+
+	c.logger.Info("LLM streaming completion successful", zap.Int("completion_length", completion.Len()))
+
+	return completion.String(), nil
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+)
+
+func TestClientStream(t *testing.T) {
+	client, server := newFakeServerClient(t)
+	server.Enqueue(llmtest.Response{Content: "I build reliable systems.", Chunks: []string{"I build ", "reliable ", "systems."}})
+
+	usage := &Usage{}
+	opts := DefaultOptions()
+	opts.Usage = usage
+
+	var deltas []string
+	completion, err := client.Stream(context.Background(), "Write a bio", nil, opts, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if completion != "I build reliable systems." {
+		t.Errorf("Stream() = %q", completion)
+	}
+	if len(deltas) != 3 || deltas[1] != "reliable " {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if !server.Requests()[0].Stream {
+		t.Error("expected a streaming request")
+	}
+	if totals := usage.Totals(); totals.LLMCalls != 1 || totals.TotalTokens == 0 {
+		t.Errorf("usage = %+v", totals)
+	}
+}
+
+func TestClientStreamErrors(t *testing.T) {
+	t.Run("rate limited", func(t *testing.T) {
+		client, server := newFakeServerClient(t)
+		server.Enqueue(llmtest.RateLimited(), llmtest.Reply("ok"))
+
+		completion, err := client.Stream(context.Background(), "hello", nil, DefaultOptions(), func(string) error { return nil })
+		if err != nil || completion != "ok" {
+			t.Fatalf("Stream() = %q, %v", completion, err)
+		}
+		if got := len(server.Requests()); got != 2 {
+			t.Errorf("expected a retry after 429, got %d requests", got)
+		}
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		client, server := newFakeServerClient(t)
+		server.Enqueue(llmtest.Error(http.StatusBadRequest, "bad model"))
+
+		if _, err := client.Stream(context.Background(), "hello", nil, DefaultOptions(), func(string) error { return nil }); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("callback stops the stream", func(t *testing.T) {
+		client, server := newFakeServerClient(t)
+		server.Enqueue(llmtest.Reply("one two three"))
+
+		stop := errors.New("client went away")
+		calls := 0
+		_, err := client.Stream(context.Background(), "hello", nil, DefaultOptions(), func(string) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("Stream() error = %v after %d deltas", err, calls)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package llm
+
+import "unicode/utf8"
+
+const (
+	// charsPerToken approximates how many characters of English text make up a token
+	charsPerToken = 4
+	// messageOverheadTokens covers the role and separators each chat message adds
+	messageOverheadTokens = 4
+)
+
+// EstimateTokens roughly counts the prompt tokens a message array uses. It is a
+// character-based estimate for previews and budgeting, not the model's tokenizer.
+func EstimateTokens(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		chars := utf8.RuneCountInString(m.Content)
+		total += (chars+charsPerToken-1)/charsPerToken + messageOverheadTokens
+	}
+	return total
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"sync"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Usage adds up the LLM calls and tokens of the completions it is passed to in
+// Options. It is safe for concurrent use; a nil Usage records nothing.
+type Usage struct {
+	mu     sync.Mutex
+	totals models.Usage
+}
+
+// Totals returns the usage recorded so far
+func (u *Usage) Totals() models.Usage {
+	if u == nil {
+		return models.Usage{Model: modelName}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	totals := u.totals
+	totals.Model = modelName
+	return totals
+}
+
+// add records an upstream call and the tokens it reported
+func (u *Usage) add(promptTokens, completionTokens, totalTokens int) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.totals.LLMCalls++
+	u.totals.PromptTokens += promptTokens
+	u.totals.CompletionTokens += completionTokens
+	u.totals.TotalTokens += totalTokens
+}
+
+// addCached records a completion served from the response cache
+func (u *Usage) addCached() {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.totals.CachedCalls++
+}
@@ -0,0 +1,64 @@
+package locale
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent function words used to tell the supported languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "for", "with", "that", "you", "your", "our", "are", "on", "this", "we", "be", "as", "will", "have"},
+	"fr": {"le", "la", "les", "et", "des", "du", "un", "une", "est", "pour", "dans", "avec", "que", "qui", "vous", "votre", "nous", "notre", "sur", "pas", "au", "aux", "ce", "sont"},
+	"pt": {"o", "os", "as", "e", "do", "da", "dos", "das", "um", "uma", "para", "com", "que", "não", "em", "no", "na", "você", "seu", "sua", "nosso", "nossa", "são", "é"},
+}
+
+const (
+	// minDetectHits is the number of stopwords needed before trusting a detection
+	minDetectHits = 5
+	// detectMargin is how much the best language must beat the runner-up by
+	detectMargin = 1.5
+)
+
+// Detect guesses the language of text from stopword frequencies. ok is false when the
+// text is too short or too mixed to tell.
+func Detect(text string) (lang string, ok bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	scores := make(map[string]int, len(stopwords))
+	for _, word := range words {
+		for code, list := range stopwords {
+			for _, stop := range list {
+				if word == stop {
+					scores[code]++
+					break
+				}
+			}
+		}
+	}
+
+	best, runnerUp := "", 0
+	for code, score := range scores {
+		if best == "" || score > scores[best] {
+			if best != "" {
+				runnerUp = scores[best]
+			}
+			best = code
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+
+	if best == "" || scores[best] < minDetectHits || float64(scores[best]) < detectMargin*float64(runnerUp) {
+		return "", false
+	}
+	return best, true
+}
+
+// Matches reports whether text appears to be written in the locale's language. Text
+// that cannot be classified is assumed to match.
+func (l Locale) Matches(text string) bool {
+	lang, ok := Detect(text)
+	return !ok || lang == l.code()
+}
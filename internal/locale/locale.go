@@ -0,0 +1,169 @@
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locale is a supported language with an optional region, e.g. "fr" or "fr-SN"
+type Locale struct {
+	Language string
+	Region   string
+}
+
+// Default is the locale used when a request does not set one
+var Default = Locale{Language: "en"}
+
+// language describes a supported output language
+type language struct {
+	name   string
+	months [12]string
+	// dateFormat is a fmt pattern taking the day, month name and year
+	dateFormat string
+	// symbolAfter places currency symbols after the amount
+	symbolAfter bool
+}
+
+var languages = map[string]language{
+	"en": {
+		name:       "English",
+		months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		dateFormat: "%[2]s %02[1]d, %[3]d",
+	},
+	"fr": {
+		name:        "French",
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		dateFormat:  "%[1]d %[2]s %[3]d",
+		symbolAfter: true,
+	},
+	"pt": {
+		name:        "Portuguese",
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		dateFormat:  "%[1]d de %[2]s de %[3]d",
+		symbolAfter: true,
+	},
+}
+
+// regionCurrencies maps regions to the currency symbol used when a job has none
+var regionCurrencies = map[string]string{
+	"NG": "₦",
+	"GH": "GH₵",
+	"SL": "Le",
+	"LR": "L$",
+	"GM": "D",
+	"GN": "FG",
+	"CV": "CVE",
+	// West African CFA franc
+	"SN": "FCFA", "CI": "FCFA", "BJ": "FCFA", "TG": "FCFA",
+	"ML": "FCFA", "BF": "FCFA", "NE": "FCFA", "GW": "FCFA",
+	// Central African CFA franc
+	"CM": "FCFA", "GA": "FCFA", "CG": "FCFA", "TD": "FCFA", "CF": "FCFA", "GQ": "FCFA",
+}
+
+// Parse reads a language tag such as "fr", "fr-SN" or "pt_GW". An empty tag returns
+// the default locale.
+func Parse(tag string) (Locale, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return Default, nil
+	}
+
+	lang, region, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	lang = strings.ToLower(lang)
+	region = strings.ToUpper(region)
+
+	if _, ok := languages[lang]; !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q: language must be one of %s", tag, strings.Join(Languages(), ", "))
+	}
+	if region != "" && !isCountryCode(region) {
+		return Locale{}, fmt.Errorf("invalid locale %q: region must be a two-letter country code", tag)
+	}
+
+	return Locale{Language: lang, Region: region}, nil
+}
+
+// isCountryCode reports whether s looks like an ISO 3166 alpha-2 code
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Languages returns the supported language codes
+func Languages() []string {
+	return []string{"en", "fr", "pt"}
+}
+
+// String returns the locale's tag
+func (l Locale) String() string {
+	if l.Region == "" {
+		return l.Language
+	}
+	return l.Language + "-" + l.Region
+}
+
+// IsDefault reports whether the locale writes in the default language
+func (l Locale) IsDefault() bool {
+	return l.Language == "" || l.Language == Default.Language
+}
+
+// LanguageName returns the English name of the locale's language
+func (l Locale) LanguageName() string {
+	return l.lang().name
+}
+
+// Instruction is the system instruction asking the model to write in the locale's
+// language. It is empty for the default language.
+func (l Locale) Instruction() string {
+	if l.IsDefault() {
+		return ""
+	}
+	return fmt.Sprintf("Write your entire response in %s (%s), using natural, idiomatic phrasing for a local professional audience. Keep proper nouns, skill names and product names as given.", l.LanguageName(), l)
+}
+
+// RetryInstruction is sent after the model answered in the wrong language
+func (l Locale) RetryInstruction() string {
+	return fmt.Sprintf("Your previous answer was not written in %[1]s. Respond again, entirely in %[1]s.", l.LanguageName())
+}
+
+// FormatDate formats a date the way the locale writes it
+func (l Locale) FormatDate(t time.Time) string {
+	lang := l.lang()
+	return fmt.Sprintf(lang.dateFormat, t.Day(), lang.months[t.Month()-1], t.Year())
+}
+
+// FormatMoney formats a whole amount with a currency symbol
+func (l Locale) FormatMoney(symbol string, amount float64) string {
+	if l.lang().symbolAfter || len([]rune(symbol)) > 2 {
+		return fmt.Sprintf("%.0f %s", amount, symbol)
+	}
+	return fmt.Sprintf("%s%.0f", symbol, amount)
+}
+
+// CurrencySymbol returns the symbol to assume when none is given
+func (l Locale) CurrencySymbol() string {
+	if symbol, ok := regionCurrencies[l.Region]; ok {
+		return symbol
+	}
+	return "$"
+}
+
+// code returns the locale's language code, falling back to the default
+func (l Locale) code() string {
+	if _, ok := languages[l.Language]; ok {
+		return l.Language
+	}
+	return Default.Language
+}
+
+// lang returns the locale's language, falling back to the default
+func (l Locale) lang() language {
+	return languages[l.code()]
+}
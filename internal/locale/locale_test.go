@@ -0,0 +1,125 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    Locale
+		wantErr bool
+	}{
+		{tag: "", want: Default},
+		{tag: "fr", want: Locale{Language: "fr"}},
+		{tag: "fr-SN", want: Locale{Language: "fr", Region: "SN"}},
+		{tag: "pt_gw", want: Locale{Language: "pt", Region: "GW"}},
+		{tag: "EN-gh", want: Locale{Language: "en", Region: "GH"}},
+		{tag: "de", wantErr: true},
+		{tag: "fr-12", wantErr: true},
+		{tag: "fr-SEN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := Parse(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	date := time.Date(2026, time.August, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale Locale
+		date   string
+		money  string
+	}{
+		{Locale{Language: "en"}, "August 04, 2026", "$500"},
+		{Locale{Language: "en", Region: "NG"}, "August 04, 2026", "₦500"},
+		{Locale{Language: "en", Region: "GH"}, "August 04, 2026", "500 GH₵"},
+		{Locale{Language: "fr", Region: "SN"}, "4 août 2026", "500 FCFA"},
+		{Locale{Language: "pt", Region: "CV"}, "4 de agosto de 2026", "500 CVE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale.String(), func(t *testing.T) {
+			if got := tt.locale.FormatDate(date); got != tt.date {
+				t.Errorf("FormatDate() = %q, want %q", got, tt.date)
+			}
+			if got := tt.locale.FormatMoney(tt.locale.CurrencySymbol(), 500); got != tt.money {
+				t.Errorf("FormatMoney() = %q, want %q", got, tt.money)
+			}
+		})
+	}
+}
+
+func TestInstruction(t *testing.T) {
+	if got := Default.Instruction(); got != "" {
+		t.Errorf("expected no instruction for the default locale, got %q", got)
+	}
+
+	fr := Locale{Language: "fr", Region: "CI"}
+	if got := fr.Instruction(); got == "" {
+		t.Error("expected an instruction for French")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "english",
+			text:   "We are looking for a developer to join our team and help us build the next version of the platform with you.",
+			want:   "en",
+			wantOK: true,
+		},
+		{
+			name:   "french",
+			text:   "Nous recherchons un développeur pour rejoindre notre équipe et nous aider à construire la prochaine version de la plateforme avec vous.",
+			want:   "fr",
+			wantOK: true,
+		},
+		{
+			name:   "portuguese",
+			text:   "Estamos à procura de um programador para a nossa equipa, com experiência em Go e que queira construir a próxima versão da plataforma connosco. É uma vaga para você.",
+			want:   "pt",
+			wantOK: true,
+		},
+		{
+			name: "too short",
+			text: "Go, React, PostgreSQL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Detect(tt.text)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Detect() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	fr := Locale{Language: "fr"}
+
+	if fr.Matches("We are looking for a developer to join our team and help us build the platform with you.") {
+		t.Error("expected English text not to match French")
+	}
+	if !fr.Matches("Go, React, PostgreSQL") {
+		t.Error("expected unclassifiable text to match")
+	}
+}
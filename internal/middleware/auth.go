@@ -5,10 +5,19 @@ import (
 	"time"
 
 	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/models"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// ServiceKeyContextKey is the gin context key holding the authenticated service key
+const ServiceKeyContextKey = "service_key"
+
+// ServiceKey returns the service key of the authenticated caller, if any
+func ServiceKey(c *gin.Context) string {
+	return c.GetString(ServiceKeyContextKey)
+}
+
 // AuthMiddleware validates service authentication using custom headers
 func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -31,11 +40,7 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 				zap.String("client_name", clientName),
 				zap.String("secretHash", secretHash),
 			)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Forbidden",
-				"message": "Missing required authentication headers",
-			})
-			c.Abort()
+			AbortWithError(c, http.StatusForbidden, models.ErrUnauthorized, "Missing required authentication headers")
 			return
 		}
 
@@ -46,11 +51,7 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 				zap.String("service_key", serviceKey),
 				zap.String("client_name", clientName),
 			)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Forbidden",
-				"message": "Invalid service credentials",
-			})
-			c.Abort()
+			AbortWithError(c, http.StatusForbidden, models.ErrUnauthorized, "Invalid service credentials")
 			return
 		}
 
@@ -62,11 +63,7 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 				zap.Bool("client_match", service.ClientName == clientName),
 				zap.Bool("secret_match", service.SecretHash == secretHash),
 			)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Forbidden",
-				"message": "Invalid service credentials",
-			})
-			c.Abort()
+			AbortWithError(c, http.StatusForbidden, models.ErrUnauthorized, "Invalid service credentials")
 			return
 		}
 
@@ -75,6 +72,26 @@ func AuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
 			zap.String("service_key", serviceKey),
 			zap.String("client_name", clientName),
 		)
+		c.Set(ServiceKeyContextKey, serviceKey)
+		c.Next()
+	}
+}
+
+// AdminMiddleware restricts a route group to services flagged as admin in config.ini.
+// It must run after AuthMiddleware.
+func AdminMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceKey := ServiceKey(c)
+
+		if service, ok := cfg.AllowedServices[serviceKey]; !ok || !service.Admin {
+			logger.Warn("Non-admin service attempted admin access",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("service_key", serviceKey),
+			)
+			AbortWithError(c, http.StatusForbidden, models.ErrForbidden, "Admin access required")
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -125,7 +142,8 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+cfg.ServiceKeyName+", "+cfg.ClientNameHeader+", "+cfg.SecretHashHeader)
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+IdempotencyKeyHeader+", "+RequestIDHeader+", "+UserIDHeader+", "+cfg.ServiceKeyName+", "+cfg.ClientNameHeader+", "+cfg.SecretHashHeader)
+		c.Header("Access-Control-Expose-Headers", RequestIDHeader+", "+DeprecationHeader+", "+SunsetHeader+", Link")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight requests
@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/models"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -56,6 +58,11 @@ func TestAuthMiddleware_RejectsMissingHeaders(t *testing.T) {
 	if w.Code != http.StatusForbidden {
 		t.Errorf("expected status 403, got %d", w.Code)
 	}
+
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != models.ErrUnauthorized {
+		t.Errorf("expected an unauthorized error, got %s", w.Body.String())
+	}
 }
 
 func TestAuthMiddleware_RejectsInvalidServiceKey(t *testing.T) {
@@ -179,3 +186,47 @@ func TestProcessTimerMiddleware(t *testing.T) {
 		t.Error("expected X-Process-Time header to be set")
 	}
 }
+
+func TestAdminMiddleware(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	cfg := &config.Config{
+		AllowedServices: map[string]config.ServiceConfig{
+			"admin-key": {Admin: true},
+			"user-key":  {Admin: false},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		serviceKey string
+		expected   int
+	}{
+		{name: "admin service", serviceKey: "admin-key", expected: http.StatusOK},
+		{name: "non-admin service", serviceKey: "user-key", expected: http.StatusForbidden},
+		{name: "unauthenticated", serviceKey: "", expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.serviceKey != "" {
+					c.Set(ServiceKeyContextKey, tt.serviceKey)
+				}
+				c.Next()
+			})
+			router.Use(AdminMiddleware(cfg, logger))
+			router.GET("/admin/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req := httptest.NewRequest("GET", "/admin/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserIDHeader names the user a v2 request is for, as an alternative to user_id
+	// in the body
+	UserIDHeader = "X-User-ID"
+
+	// DeprecationHeader marks responses of a deprecated API version
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader is the date after which a deprecated API version may be removed
+	SunsetHeader = "Sunset"
+)
+
+// DeprecationMiddleware marks every response under prefix as deprecated and links
+// to the same route under successorPrefix. sunset, an HTTP date, is sent when set.
+func DeprecationMiddleware(prefix, successorPrefix, sunset string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		successor := successorPrefix + strings.TrimPrefix(c.Request.URL.Path, prefix)
+
+		c.Header(DeprecationHeader, "true")
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		if sunset != "" {
+			c.Header(SunsetHeader, sunset)
+		}
+		c.Next()
+	}
+}
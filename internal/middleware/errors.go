@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// RequestIDHeader carries the request ID in both directions
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDContextKey is the gin context key holding the request ID
+	RequestIDContextKey = "request_id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID returns the ID of the current request, if any
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDContextKey)
+}
+
+// RequestIDMiddleware tags each request with an ID, reusing a caller's X-Request-ID
+// when it is reasonable, and echoes it in the response
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDContextKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AbortWithError stops the request with the error envelope
+func AbortWithError(c *gin.Context, status int, code models.ErrorCodeEnum, message string, fieldErrors ...models.FieldError) {
+	c.AbortWithStatusJSON(status, models.NewErrorResponse(code, message, RequestID(c), fieldErrors))
+}
+
+// RecoveryMiddleware turns panics into internal_error responses
+func RecoveryMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err interface{}) {
+		logger.Error("Panic while handling request",
+			zap.String("path", c.Request.URL.Path),
+			zap.String("request_id", RequestID(c)),
+			zap.Any("error", err),
+		)
+		AbortWithError(c, http.StatusInternalServerError, models.ErrInternal, "Internal server error")
+	})
+}
+
+// NotFound answers requests for unknown routes
+func NotFound(c *gin.Context) {
+	AbortWithError(c, http.StatusNotFound, models.ErrNotFound, "Route not found: "+c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// validRequestID accepts short IDs of printable ASCII
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "req_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newErrorsRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.Use(RecoveryMiddleware(zap.NewNop()))
+	router.GET("/invalid", func(c *gin.Context) {
+		AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, "Invalid data",
+			models.FieldError{Field: "name", Message: "is required"})
+	})
+	router.GET("/limited", func(c *gin.Context) {
+		AbortWithError(c, http.StatusServiceUnavailable, models.ErrUpstreamRateLimited, "Try again later")
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	router.NoRoute(NotFound)
+	return router
+}
+
+func serveError(t *testing.T, router *gin.Engine, path, requestID string) (*httptest.ResponseRecorder, models.ErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid error body: %v\n%s", err, w.Body.String())
+	}
+	return w, resp
+}
+
+func TestAbortWithError_Envelope(t *testing.T) {
+	w, resp := serveError(t, newErrorsRouter(), "/invalid", "")
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+	if resp.Success || resp.Error.Code != models.ErrInvalidRequest || resp.Error.Retryable {
+		t.Errorf("unexpected error: %+v", resp)
+	}
+	if resp.ErrorMessage == nil || *resp.ErrorMessage != resp.Error.Message {
+		t.Errorf("error_message should repeat error.message: %+v", resp)
+	}
+	if len(resp.Error.FieldErrors) != 1 || resp.Error.FieldErrors[0].Field != "name" {
+		t.Errorf("field_errors = %+v", resp.Error.FieldErrors)
+	}
+	if resp.Error.RequestID == "" || resp.Error.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Errorf("request_id %q should match the %s header %q", resp.Error.RequestID, RequestIDHeader, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestAbortWithError_Retryable(t *testing.T) {
+	_, resp := serveError(t, newErrorsRouter(), "/limited", "")
+
+	if resp.Error.Code != models.ErrUpstreamRateLimited || !resp.Error.Retryable {
+		t.Errorf("expected a retryable upstream_rate_limited error, got %+v", resp.Error)
+	}
+}
+
+func TestRequestIDMiddleware_ReusesCallerID(t *testing.T) {
+	router := newErrorsRouter()
+
+	w, resp := serveError(t, router, "/invalid", "client-trace-42")
+	if resp.Error.RequestID != "client-trace-42" || w.Header().Get(RequestIDHeader) != "client-trace-42" {
+		t.Errorf("expected the caller's request ID, got %q", resp.Error.RequestID)
+	}
+
+	// IDs with spaces or that are too long are replaced
+	for _, id := range []string{"has spaces", strings.Repeat("a", maxRequestIDLength+1)} {
+		_, resp := serveError(t, router, "/invalid", id)
+		if resp.Error.RequestID == id || !strings.HasPrefix(resp.Error.RequestID, "req_") {
+			t.Errorf("request ID %q should be replaced, got %q", id, resp.Error.RequestID)
+		}
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	w, resp := serveError(t, newErrorsRouter(), "/panic", "")
+
+	if w.Code != http.StatusInternalServerError || resp.Error.Code != models.ErrInternal {
+		t.Errorf("expected 500 internal_error, got %d %+v", w.Code, resp.Error)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	w, resp := serveError(t, newErrorsRouter(), "/missing", "")
+
+	if w.Code != http.StatusNotFound || resp.Error.Code != models.ErrNotFound {
+		t.Errorf("expected 404 not_found, got %d %+v", w.Code, resp.Error)
+	}
+}
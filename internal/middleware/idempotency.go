@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dokoola/llm-go/internal/idempotency"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes caps the request body read to hash it
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// responseRecorder captures the response body while still writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the first response for requests that repeat an
+// Idempotency-Key. Keys are scoped per service and route; concurrent duplicates wait
+// for the in-flight request and reusing a key with a different body, query string
+// or X-User-ID returns 422.
+// Responses that aren't stored, like server errors, are never replayed: waiting
+// duplicates run the request again. It must run after AuthMiddleware.
+func IdempotencyMiddleware(store *idempotency.Store, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, "Invalid request: Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				AbortWithError(c, http.StatusRequestEntityTooLarge, models.ErrInvalidRequest, fmt.Sprintf("Invalid request: body must be at most %d bytes", tooLarge.Limit))
+				return
+			}
+			AbortWithError(c, http.StatusBadRequest, models.ErrInvalidRequest, "Invalid request: failed to read body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := ServiceKey(c) + ":" + c.Request.Method + " " + c.FullPath() + ":" + key
+
+		stored, owner, err := store.Begin(c.Request.Context(), storeKey, requestFingerprint(c, body))
+		if err != nil {
+			if errors.Is(err, idempotency.ErrBodyMismatch) {
+				logger.Warn("Idempotency key reused with different request",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("idempotency_key", key),
+				)
+				AbortWithError(c, http.StatusUnprocessableEntity, models.ErrInvalidRequest, "Idempotency-Key was already used with a different request body, query or "+UserIDHeader)
+				return
+			}
+
+			// The request ended while waiting for the in-flight one
+			AbortWithError(c, http.StatusConflict, models.ErrInProgress, "A request with this Idempotency-Key is still in progress; retry later")
+			return
+		}
+
+		if !owner {
+			logger.Debug("Replaying idempotent response",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("idempotency_key", key),
+			)
+			for name, values := range stored.Header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, stored.Header.Get("Content-Type"), stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer func() {
+			if r := recover(); r != nil {
+				// Release the key and let the recovery middleware handle the panic
+				store.Complete(storeKey, nil, false)
+				panic(r)
+			}
+
+			status := recorder.Status()
+			header := http.Header{}
+			for _, name := range []string{"Content-Type", "Location"} {
+				if value := recorder.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+
+			// Server errors are not persisted so a retry gets a fresh attempt
+			store.Complete(storeKey, &idempotency.Response{
+				StatusCode: status,
+				Header:     header,
+				Body:       recorder.body.Bytes(),
+			}, status < http.StatusInternalServerError)
+		}()
+
+		c.Next()
+	}
+}
+
+// requestFingerprint hashes everything besides the route that selects what a request
+// does: the query string, the X-User-ID header and the body
+func requestFingerprint(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", c.Request.URL.RawQuery, c.GetHeader(UserIDHeader))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
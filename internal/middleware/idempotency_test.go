@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/idempotency"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newIdempotencyTestRouter(calls *int32) *gin.Engine {
+	logger, _ := zap.NewDevelopment()
+	store := idempotency.NewStore(100, time.Minute)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(ServiceKeyContextKey, c.GetHeader("X-Service-Key"))
+		c.Next()
+	})
+	router.POST("/chat/completion", IdempotencyMiddleware(store, logger), func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		c.JSON(http.StatusOK, gin.H{"success": true, "call": n})
+	})
+	return router
+}
+
+func doIdempotentRequest(router *gin.Engine, serviceKey, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/chat/completion", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Key", serviceKey)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddlewareReplaysResponse(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	first := doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`)
+	second := doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`)
+
+	if calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls)
+	}
+
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("expected identical bodies, got %q and %q", first.Body.String(), second.Body.String())
+	}
+
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("expected replayed header on duplicate response")
+	}
+}
+
+func TestIdempotencyMiddlewareScopesKeysPerService(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`)
+	doIdempotentRequest(router, "DKL002", "abc", `{"text":"hi"}`)
+
+	if calls != 2 {
+		t.Errorf("expected handler to run for each service, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsMismatchedBody(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`)
+	w := doIdempotentRequest(router, "DKL001", "abc", `{"text":"bye"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsMismatchedQueryAndUser(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	send := func(query, userID string) int {
+		req := httptest.NewRequest("POST", "/chat/completion"+query, bytes.NewBufferString(`{"text":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Service-Key", "DKL001")
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		if userID != "" {
+			req.Header.Set(UserIDHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("?user_id=u1&format=markdown", ""); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	for _, tc := range []struct{ query, userID string }{
+		{"?user_id=u2&format=markdown", ""},
+		{"?user_id=u1&format=html", ""},
+		{"?user_id=u1&format=markdown", "u3"},
+	} {
+		if code := send(tc.query, tc.userID); code != http.StatusUnprocessableEntity {
+			t.Errorf("query %q, user %q: expected status 422, got %d", tc.query, tc.userID, code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareWithoutKey(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	doIdempotentRequest(router, "DKL001", "", `{"text":"hi"}`)
+	doIdempotentRequest(router, "DKL001", "", `{"text":"hi"}`)
+
+	if calls != 2 {
+		t.Errorf("expected handler to run for each request without a key, ran %d times", calls)
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsLargeBody(t *testing.T) {
+	var calls int32
+	router := newIdempotencyTestRouter(&calls)
+
+	body := `{"text":"` + strings.Repeat("a", maxIdempotentBodyBytes) + `"}`
+	w := doIdempotentRequest(router, "DKL001", "abc", body)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != models.ErrInvalidRequest {
+		t.Errorf("expected an invalid_request error, got %s", w.Body.String())
+	}
+	if calls != 0 {
+		t.Errorf("expected handler not to run, ran %d times", calls)
+	}
+}
+
+// newBlockingIdempotencyRouter routes to a handler that waits for release, then fails
+// its first call with fail and answers 200 after that
+func newBlockingIdempotencyRouter(calls *int32, started, release chan struct{}, fail func(c *gin.Context)) *gin.Engine {
+	logger := zap.NewNop()
+	store := idempotency.NewStore(100, time.Minute)
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		AbortWithError(c, http.StatusInternalServerError, models.ErrInternal, "Internal server error")
+	}))
+	router.POST("/chat/completion", IdempotencyMiddleware(store, logger), func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		if n == 1 {
+			close(started)
+			<-release
+			fail(c)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "call": n})
+	})
+	return router
+}
+
+func TestIdempotencyMiddlewareWaiterRunsAgainAfterUnstoredResponse(t *testing.T) {
+	failures := map[string]func(c *gin.Context){
+		"server error": func(c *gin.Context) {
+			AbortWithError(c, http.StatusBadGateway, models.ErrUpstreamError, "upstream failed")
+		},
+		"panic": func(c *gin.Context) { panic("boom") },
+	}
+
+	for name, fail := range failures {
+		t.Run(name, func(t *testing.T) {
+			var calls int32
+			started, release := make(chan struct{}), make(chan struct{})
+			router := newBlockingIdempotencyRouter(&calls, started, release, fail)
+
+			first := make(chan *httptest.ResponseRecorder, 1)
+			go func() { first <- doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`) }()
+			<-started
+
+			second := make(chan *httptest.ResponseRecorder, 1)
+			go func() { second <- doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`) }()
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+
+			if w := <-first; w.Code < http.StatusInternalServerError {
+				t.Errorf("expected the first request to fail, got %d", w.Code)
+			}
+			w := <-second
+			if w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" {
+				t.Errorf("expected the waiter to run the request again, got %d (%s)", w.Code, w.Body.String())
+			}
+			if calls != 2 {
+				t.Errorf("expected handler to run twice, ran %d times", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareWaiterContextDone(t *testing.T) {
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	router := newBlockingIdempotencyRouter(&calls, started, release, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	defer close(release)
+
+	go doIdempotentRequest(router, "DKL001", "abc", `{"text":"hi"}`)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("POST", "/chat/completion", bytes.NewBufferString(`{"text":"hi"}`)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", w.Code)
+	}
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != models.ErrInProgress || !resp.Error.Retryable {
+		t.Errorf("expected a retryable request_in_progress error, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// ConversationRoleEnum is who sent a conversation message
+type ConversationRoleEnum string
+
+const (
+	ConversationRoleUser      ConversationRoleEnum = "user"
+	ConversationRoleAssistant ConversationRoleEnum = "assistant"
+)
+
+// ConversationMessage is one turn of a conversation
+type ConversationMessage struct {
+	Role      ConversationRoleEnum `json:"role"`
+	Content   string               `json:"content"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// Conversation is a chat session kept server-side between turns. Once the history
+// outgrows the token window, the oldest messages are folded into Summary and no
+// longer listed.
+type Conversation struct {
+	ID                 string                `json:"id"`
+	UserID             string                `json:"user_id,omitempty"`
+	Locale             string                `json:"locale"`
+	Messages           []ConversationMessage `json:"messages"`
+	Summary            string                `json:"summary,omitempty"`
+	SummarizedMessages int                   `json:"summarized_messages,omitempty"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	ExpiresAt          time.Time             `json:"expires_at"`
+}
+
+// ConversationCreateRequest is the request payload for starting a conversation
+type ConversationCreateRequest struct {
+	UserID string `json:"user_id,omitempty"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// ConversationResponse is the response for creating, reading or deleting a conversation
+type ConversationResponse struct {
+	Data    *Conversation `json:"data,omitempty"`
+	Success bool          `json:"success"`
+}
+
+// ConversationMessageRequest is the request payload for sending a message
+type ConversationMessageRequest struct {
+	Text   string           `json:"text" binding:"required"`
+	Format OutputFormatEnum `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
+}
+
+// ConversationReply is the assistant's answer to a message. Message holds the reply
+// as the model wrote it, which is what later turns see; Completion is converted to
+// Format when one was requested.
+type ConversationReply struct {
+	ConversationID string              `json:"conversation_id"`
+	Message        ConversationMessage `json:"message"`
+	Completion     string              `json:"completion"`
+	GenerationID   string              `json:"generation_id,omitempty"`
+	Locale         string              `json:"locale"`
+	Format         OutputFormatEnum    `json:"format,omitempty"`
+	// Summarized is set when older messages were folded into the summary to fit the
+	// token window
+	Summarized bool `json:"summarized,omitempty"`
+}
+
+// ConversationMessageResponse is the response for sending a message
+type ConversationMessageResponse struct {
+	Data    ConversationReply `json:"data"`
+	Usage   Usage             `json:"usage"`
+	Success bool              `json:"success"`
+}
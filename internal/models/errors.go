@@ -0,0 +1,72 @@
+package models
+
+// ErrorCodeEnum defines the machine-readable codes of error responses
+type ErrorCodeEnum string
+
+const (
+	// ErrInvalidRequest: the body, query or headers failed validation
+	ErrInvalidRequest ErrorCodeEnum = "invalid_request"
+	// ErrUnauthorized: service credentials are missing or invalid
+	ErrUnauthorized ErrorCodeEnum = "unauthorized"
+	// ErrForbidden: the service may not use the route
+	ErrForbidden ErrorCodeEnum = "forbidden"
+	// ErrNotFound: the route or resource doesn't exist
+	ErrNotFound ErrorCodeEnum = "not_found"
+	// ErrConflict: the resource is in a state that doesn't allow the request
+	ErrConflict ErrorCodeEnum = "conflict"
+	// ErrInProgress: a request with the same Idempotency-Key is still running
+	ErrInProgress ErrorCodeEnum = "request_in_progress"
+	// ErrUserNotFound: the backend doesn't know the user
+	ErrUserNotFound ErrorCodeEnum = "user_not_found"
+	// ErrTemplateInvalid: the template data is invalid or the template failed to render
+	ErrTemplateInvalid ErrorCodeEnum = "template_invalid"
+	// ErrUpstreamRateLimited: the LLM provider is rate limiting the service
+	ErrUpstreamRateLimited ErrorCodeEnum = "upstream_rate_limited"
+	// ErrUpstreamError: the LLM provider or the backend failed
+	ErrUpstreamError ErrorCodeEnum = "upstream_error"
+	// ErrQuotaExceeded: the service is at capacity, e.g. the task queue is full
+	ErrQuotaExceeded ErrorCodeEnum = "quota_exceeded"
+	// ErrInternal: an unexpected failure in the service itself
+	ErrInternal ErrorCodeEnum = "internal_error"
+)
+
+// Retryable reports whether the same request may succeed if retried later
+func (c ErrorCodeEnum) Retryable() bool {
+	switch c {
+	case ErrInProgress, ErrUpstreamRateLimited, ErrUpstreamError, ErrQuotaExceeded, ErrInternal:
+		return true
+	}
+	return false
+}
+
+// APIError describes why a request failed
+type APIError struct {
+	Code        ErrorCodeEnum `json:"code"`
+	Message     string        `json:"message"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Retryable   bool          `json:"retryable"`
+	FieldErrors []FieldError  `json:"field_errors,omitempty"`
+}
+
+// ErrorResponse is the body of every error response. ErrorMessage repeats
+// Error.Message for clients that read the older envelope.
+type ErrorResponse struct {
+	Error        APIError `json:"error"`
+	ErrorMessage *string  `json:"error_message"`
+	Success      bool     `json:"success"`
+}
+
+// NewErrorResponse builds an error response
+func NewErrorResponse(code ErrorCodeEnum, message, requestID string, fieldErrors []FieldError) ErrorResponse {
+	return ErrorResponse{
+		Error: APIError{
+			Code:        code,
+			Message:     message,
+			RequestID:   requestID,
+			Retryable:   code.Retryable(),
+			FieldErrors: fieldErrors,
+		},
+		ErrorMessage: &message,
+		Success:      false,
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// FeedbackRatingEnum defines the thumbs up/down rating for a generation
+type FeedbackRatingEnum string
+
+const (
+	FeedbackUp   FeedbackRatingEnum = "up"
+	FeedbackDown FeedbackRatingEnum = "down"
+)
+
+// FeedbackReasonEnum defines the reason codes users can attach to feedback
+type FeedbackReasonEnum string
+
+const (
+	ReasonTooLong    FeedbackReasonEnum = "too_long"
+	ReasonTooShort   FeedbackReasonEnum = "too_short"
+	ReasonWrongTone  FeedbackReasonEnum = "wrong_tone"
+	ReasonInaccurate FeedbackReasonEnum = "inaccurate"
+	ReasonGeneric    FeedbackReasonEnum = "generic"
+	ReasonFormatting FeedbackReasonEnum = "formatting"
+	ReasonOffTopic   FeedbackReasonEnum = "off_topic"
+	ReasonOther      FeedbackReasonEnum = "other"
+)
+
+// GenerationFeedbackRequest is the request payload for generation feedback
+type GenerationFeedbackRequest struct {
+	Rating     FeedbackRatingEnum   `json:"rating" binding:"required,oneof=up down"`
+	EditedText *string              `json:"edited_text,omitempty"`
+	Reasons    []FeedbackReasonEnum `json:"reasons,omitempty" binding:"omitempty,dive,oneof=too_long too_short wrong_tone inaccurate generic formatting off_topic other"`
+	UserID     string               `json:"user_id,omitempty"`
+}
+
+// GenerationFeedback is a single piece of feedback stored with a generation
+type GenerationFeedback struct {
+	Rating     FeedbackRatingEnum   `json:"rating"`
+	EditedText *string              `json:"edited_text,omitempty"`
+	Reasons    []FeedbackReasonEnum `json:"reasons,omitempty"`
+	UserID     string               `json:"user_id,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// GenerationFeedbackResponse is the response for generation feedback
+type GenerationFeedbackResponse struct {
+	GenerationID string  `json:"generation_id,omitempty"`
+	ErrorMessage *string `json:"error_message,omitempty"`
+	Success      bool    `json:"success"`
+}
+
+// TemplateFeedbackStats holds aggregated outcome stats for a prompt template version
+type TemplateFeedbackStats struct {
+	TemplateName  PromptTemplateEnum         `json:"template_name"`
+	Version       string                     `json:"version,omitempty"`
+	Generations   int                        `json:"generations"`
+	FeedbackCount int                        `json:"feedback_count"`
+	ThumbsUp      int                        `json:"thumbs_up"`
+	ThumbsDown    int                        `json:"thumbs_down"`
+	EditedCount   int                        `json:"edited_count"`
+	ApprovalRate  float64                    `json:"approval_rate"`
+	Reasons       map[FeedbackReasonEnum]int `json:"reasons"`
+}
+
+// GenerationStatsResponse is the response for aggregated feedback stats
+type GenerationStatsResponse struct {
+	Data         []TemplateFeedbackStats `json:"data"`
+	ErrorMessage *string                 `json:"error_message,omitempty"`
+	Success      bool                    `json:"success"`
+}
@@ -112,6 +112,18 @@ func TestPromptGenerationRequest(t *testing.T) {
 			t.Error("expected profile data")
 		}
 	})
+
+	t.Run("v2 requests default to markdown", func(t *testing.T) {
+		req := PromptGenerationV2Request{TemplateName: PromptTalentBio}.PromptGenerationRequest()
+		if req.Format != FormatMarkdown {
+			t.Errorf("expected format %q, got %q", FormatMarkdown, req.Format)
+		}
+
+		req = PromptGenerationV2Request{TemplateName: PromptTalentBio, Format: FormatHTML}.PromptGenerationRequest()
+		if req.Format != FormatHTML {
+			t.Errorf("expected format %q, got %q", FormatHTML, req.Format)
+		}
+	})
 }
 
 func TestPromptGenerationResponse(t *testing.T) {
@@ -33,15 +33,103 @@ const (
 	LengthDetailed ModelResponseLengthEnum = "detailed" // 320-450 words
 )
 
+// OutputFormatEnum defines the formats generated content can be returned in
+type OutputFormatEnum string
+
+const (
+	FormatMarkdown OutputFormatEnum = "markdown"
+	FormatHTML     OutputFormatEnum = "html"
+	FormatText     OutputFormatEnum = "text"
+)
+
 // PromptGenerationRequest is the request payload for prompt-based generation
 type PromptGenerationRequest struct {
-	Data         map[string]interface{} `json:"data" binding:"required"`
-	TemplateName PromptTemplateEnum     `json:"template_name" binding:"required"`
+	Data            map[string]interface{}  `json:"data" binding:"required"`
+	TemplateName    PromptTemplateEnum      `json:"template_name" binding:"required"`
+	TemplateVersion string                  `json:"template_version,omitempty"`
+	Tone            ModelTuneEnum           `json:"tone,omitempty" binding:"omitempty,oneof=professional confident friendly enthusiastic formal warm persuasive"`
+	Length          ModelResponseLengthEnum `json:"length,omitempty" binding:"omitempty,oneof=short medium detailed"`
+	Locale          string                  `json:"locale,omitempty"`
+	Format          OutputFormatEnum        `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
 }
 
 // PromptGenerationResponse is the response for prompt generation
 type PromptGenerationResponse struct {
-	Completion   *string `json:"completion,omitempty"`
-	ErrorMessage *string `json:"error_message,omitempty"`
-	Success      bool    `json:"success"`
+	Completion      *string             `json:"completion,omitempty"`
+	GenerationID    string              `json:"generation_id,omitempty"`
+	TemplateVersion string              `json:"template_version,omitempty"`
+	Locale          string              `json:"locale,omitempty"`
+	Format          OutputFormatEnum    `json:"format,omitempty"`
+	Metadata        *GenerationMetadata `json:"metadata,omitempty"`
+	ErrorMessage    *string             `json:"error_message,omitempty"`
+	Success         bool                `json:"success"`
+}
+
+// FieldError describes an invalid field in template data
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// GenerationMetadata describes how a completion was produced and checked
+type GenerationMetadata struct {
+	Attempts             int                   `json:"attempts"`
+	ConstraintViolations []ConstraintViolation `json:"constraint_violations"`
+}
+
+// ConstraintViolation is an output rule the model broke. Fixed is true when the
+// service corrected the output itself.
+type ConstraintViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixed   bool   `json:"fixed"`
+}
+
+// ChatMessage is a message in the array sent to the LLM
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// GenerationParams are the sampling parameters sent with a completion
+type GenerationParams struct {
+	MaxTokens   int     `json:"max_tokens"`
+	Temperature float64 `json:"temperature"`
+	TopP        float64 `json:"top_p"`
+}
+
+// RenderedPromptData is the LLM request a prompt generation would send
+type RenderedPromptData struct {
+	TemplateName    PromptTemplateEnum      `json:"template_name"`
+	TemplateVersion string                  `json:"template_version,omitempty"`
+	Tone            ModelTuneEnum           `json:"tone,omitempty"`
+	Length          ModelResponseLengthEnum `json:"length,omitempty"`
+	Locale          string                  `json:"locale"`
+	Model           string                  `json:"model"`
+	Params          GenerationParams        `json:"params"`
+	Messages        []ChatMessage           `json:"messages"`
+	EstimatedTokens int                     `json:"estimated_tokens"`
+}
+
+// RenderPromptResponse is the response for a prompt dry run
+type RenderPromptResponse struct {
+	Data         *RenderedPromptData `json:"data,omitempty"`
+	ErrorMessage *string             `json:"error_message,omitempty"`
+	Success      bool                `json:"success"`
+}
+
+// RefineRequest is the request payload for revising generated content. The content
+// comes from generation_id, or from template_name and text; text given with a
+// generation_id replaces the stored output, e.g. after the user edited it.
+type RefineRequest struct {
+	GenerationID    string                  `json:"generation_id,omitempty"`
+	TemplateName    PromptTemplateEnum      `json:"template_name,omitempty" binding:"required_without=GenerationID"`
+	Text            string                  `json:"text,omitempty" binding:"required_without=GenerationID"`
+	Instruction     string                  `json:"instruction" binding:"required,max=1000"`
+	UserID          string                  `json:"user_id,omitempty"`
+	TemplateVersion string                  `json:"template_version,omitempty"`
+	Tone            ModelTuneEnum           `json:"tone,omitempty" binding:"omitempty,oneof=professional confident friendly enthusiastic formal warm persuasive"`
+	Length          ModelResponseLengthEnum `json:"length,omitempty" binding:"omitempty,oneof=short medium detailed"`
+	Locale          string                  `json:"locale,omitempty"`
+	Format          OutputFormatEnum        `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
 }
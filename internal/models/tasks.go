@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// TaskKindEnum defines the kinds of work that can run asynchronously
+type TaskKindEnum string
+
+const (
+	TaskJobCategorize TaskKindEnum = "jobs_categorize"
+	TaskJobDescribe   TaskKindEnum = "jobs_describe"
+)
+
+// TaskStatusEnum defines the lifecycle states of an async task
+type TaskStatusEnum string
+
+const (
+	TaskQueued    TaskStatusEnum = "queued"
+	TaskRunning   TaskStatusEnum = "running"
+	TaskCompleted TaskStatusEnum = "completed"
+	TaskFailed    TaskStatusEnum = "failed"
+	TaskCancelled TaskStatusEnum = "cancelled"
+)
+
+// Finished reports whether the status is terminal
+func (s TaskStatusEnum) Finished() bool {
+	return s == TaskCompleted || s == TaskFailed || s == TaskCancelled
+}
+
+// TaskProgress reports how many items of a task have been processed
+type TaskProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// TaskInfo is the externally visible state of an async task
+type TaskInfo struct {
+	ID        string         `json:"id"`
+	Kind      TaskKindEnum   `json:"kind"`
+	Status    TaskStatusEnum `json:"status"`
+	Progress  TaskProgress   `json:"progress"`
+	Result    interface{}    `json:"result,omitempty"`
+	Error     *string        `json:"error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+}
+
+// TaskResponse is the response for async task submission and polling
+type TaskResponse struct {
+	Data         *TaskInfo `json:"data,omitempty"`
+	StatusURL    string    `json:"status_url,omitempty"`
+	ErrorMessage *string   `json:"error_message,omitempty"`
+	Success      bool      `json:"success"`
+}
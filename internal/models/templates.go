@@ -0,0 +1,58 @@
+package models
+
+// TemplatesReloadResponse represents the response after reloading prompt templates
+type TemplatesReloadResponse struct {
+	Templates    []PromptTemplateEnum `json:"templates,omitempty"`
+	ErrorMessage *string              `json:"error_message,omitempty"`
+	Success      bool                 `json:"success"`
+}
+
+// TemplateSchemaResponse represents the JSON Schema for a template's input data
+type TemplateSchemaResponse struct {
+	Data         map[string]interface{} `json:"data,omitempty"`
+	ErrorMessage *string                `json:"error_message,omitempty"`
+	Success      bool                   `json:"success"`
+}
+
+// TemplateField describes one input field of a template, addressed by its dotted
+// path in the request data
+type TemplateField struct {
+	Path     string   `json:"path"`
+	Type     string   `json:"type"`
+	Items    string   `json:"items,omitempty"`
+	Required bool     `json:"required"`
+	Enum     []string `json:"enum,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Minimum  *float64 `json:"minimum,omitempty"`
+	Maximum  *float64 `json:"maximum,omitempty"`
+}
+
+// TemplateVersionInfo is a template version and its share of traffic
+type TemplateVersionInfo struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// TemplateInfo describes a template for clients building generation forms
+type TemplateInfo struct {
+	Name           PromptTemplateEnum              `json:"name"`
+	Description    string                          `json:"description"`
+	RequiredFields []TemplateField                 `json:"required_fields"`
+	OptionalFields []TemplateField                 `json:"optional_fields"`
+	Tones          []string                        `json:"tones"`
+	Lengths        []string                        `json:"lengths"`
+	Formats        []OutputFormatEnum              `json:"formats"`
+	DefaultTone    ModelTuneEnum                   `json:"default_tone"`
+	DefaultLength  ModelResponseLengthEnum         `json:"default_length"`
+	DefaultFormat  OutputFormatEnum                `json:"default_format"`
+	MaxChars       map[ModelResponseLengthEnum]int `json:"max_chars,omitempty"`
+	CurrentVersion string                          `json:"current_version"`
+	Versions       []TemplateVersionInfo           `json:"versions"`
+}
+
+// TemplateCatalogResponse lists the available templates
+type TemplateCatalogResponse struct {
+	Data         []TemplateInfo `json:"data"`
+	ErrorMessage *string        `json:"error_message,omitempty"`
+	Success      bool           `json:"success"`
+}
@@ -2,12 +2,17 @@ package models
 
 // TextCompletionRequest is the request payload for text completion
 type TextCompletionRequest struct {
-	Text string `json:"text" binding:"required"`
+	Text   string           `json:"text" binding:"required"`
+	Locale string           `json:"locale,omitempty"`
+	Format OutputFormatEnum `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
 }
 
 // TextCompletionResponse is the response for text completion
 type TextCompletionResponse struct {
-	Completion   *string `json:"completion,omitempty"`
-	ErrorMessage *string `json:"error_message,omitempty"`
-	Success      bool    `json:"success"`
+	Completion   *string          `json:"completion,omitempty"`
+	GenerationID string           `json:"generation_id,omitempty"`
+	Locale       string           `json:"locale,omitempty"`
+	Format       OutputFormatEnum `json:"format,omitempty"`
+	ErrorMessage *string          `json:"error_message,omitempty"`
+	Success      bool             `json:"success"`
 }
@@ -0,0 +1,113 @@
+package models
+
+// Usage reports the LLM work behind a v2 response. Calls served from the response
+// cache are counted in CachedCalls and use no tokens.
+type Usage struct {
+	Model            string `json:"model"`
+	LLMCalls         int    `json:"llm_calls"`
+	CachedCalls      int    `json:"cached_calls"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+}
+
+// JobDescribeV2Request is the v2 request payload for job description
+type JobDescribeV2Request struct {
+	Jobs   []JobDescribeRequest `json:"jobs" binding:"required,min=1,dive"`
+	Format OutputFormatEnum     `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
+}
+
+// JobDescribeV2Response is the v2 response for job description
+type JobDescribeV2Response struct {
+	Data    []JobDescription `json:"data"`
+	Usage   Usage            `json:"usage"`
+	Success bool             `json:"success"`
+}
+
+// JobCategorizationV2Request is the v2 request payload for job categorization
+type JobCategorizationV2Request struct {
+	Jobs []JobData `json:"jobs" binding:"required,min=1,dive"`
+	// NoCache skips the LLM response cache, like Cache-Control: no-cache
+	NoCache bool `json:"no_cache,omitempty"`
+}
+
+// JobCategorizationV2Response is the v2 response for job categorization
+type JobCategorizationV2Response struct {
+	Data    []JobResponseData `json:"data"`
+	Usage   Usage             `json:"usage"`
+	Success bool              `json:"success"`
+}
+
+// TextCompletionV2Request is the v2 request payload for text completion
+type TextCompletionV2Request struct {
+	Text   string           `json:"text" binding:"required"`
+	UserID string           `json:"user_id,omitempty"`
+	Locale string           `json:"locale,omitempty"`
+	Format OutputFormatEnum `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
+}
+
+// CompletionData is a generated text and how it was produced
+type CompletionData struct {
+	Completion      string              `json:"completion"`
+	GenerationID    string              `json:"generation_id,omitempty"`
+	TemplateVersion string              `json:"template_version,omitempty"`
+	Locale          string              `json:"locale"`
+	Format          OutputFormatEnum    `json:"format,omitempty"`
+	Metadata        *GenerationMetadata `json:"metadata,omitempty"`
+}
+
+// CompletionV2Response is the v2 response for text completion and prompt generation
+type CompletionV2Response struct {
+	Data    CompletionData `json:"data"`
+	Usage   Usage          `json:"usage"`
+	Success bool           `json:"success"`
+}
+
+// PromptGenerationV2Request is the v2 request payload for prompt generation and
+// prompt previews
+type PromptGenerationV2Request struct {
+	TemplateName    PromptTemplateEnum      `json:"template_name" binding:"required"`
+	Data            map[string]interface{}  `json:"data" binding:"required"`
+	UserID          string                  `json:"user_id,omitempty"`
+	TemplateVersion string                  `json:"template_version,omitempty"`
+	Tone            ModelTuneEnum           `json:"tone,omitempty" binding:"omitempty,oneof=professional confident friendly enthusiastic formal warm persuasive"`
+	Length          ModelResponseLengthEnum `json:"length,omitempty" binding:"omitempty,oneof=short medium detailed"`
+	Locale          string                  `json:"locale,omitempty"`
+	Format          OutputFormatEnum        `json:"format,omitempty" binding:"omitempty,oneof=markdown html text"`
+}
+
+// PromptGenerationRequest converts the v2 request to the shape shared with v1. Unlike
+// v1, which returns the completion as written without a format, v2 always converts
+// it, to markdown by default.
+func (r PromptGenerationV2Request) PromptGenerationRequest() PromptGenerationRequest {
+	format := r.Format
+	if format == "" {
+		format = FormatMarkdown
+	}
+
+	return PromptGenerationRequest{
+		Data:            r.Data,
+		TemplateName:    r.TemplateName,
+		TemplateVersion: r.TemplateVersion,
+		Tone:            r.Tone,
+		Length:          r.Length,
+		Locale:          r.Locale,
+		Format:          format,
+	}
+}
+
+// RenderPromptV2Response is the v2 response for a prompt dry run
+type RenderPromptV2Response struct {
+	Data    RenderedPromptData `json:"data"`
+	Usage   Usage              `json:"usage"`
+	Success bool               `json:"success"`
+}
+
+// CompletionChunk is one message of a streamed completion. Content arrives as a
+// series of deltas; the last message has Done set with the final data and usage.
+type CompletionChunk struct {
+	Delta string          `json:"delta,omitempty"`
+	Done  bool            `json:"done"`
+	Data  *CompletionData `json:"data,omitempty"`
+	Usage *Usage          `json:"usage,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookPayload is the body delivered to a task's callback URL
+type WebhookPayload struct {
+	Event string   `json:"event"`
+	Task  TaskInfo `json:"task"`
+}
+
+// WebhookDeadLetter is a webhook delivery that exhausted its retries
+type WebhookDeadLetter struct {
+	TaskID     string          `json:"task_id"`
+	ServiceKey string          `json:"service_key"`
+	URL        string          `json:"url"`
+	Attempts   int             `json:"attempts"`
+	LastStatus int             `json:"last_status,omitempty"`
+	LastError  string          `json:"last_error"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// WebhookDeadLettersResponse is the response for the dead-letter admin endpoint
+type WebhookDeadLettersResponse struct {
+	Data         []WebhookDeadLetter `json:"data"`
+	ErrorMessage *string             `json:"error_message,omitempty"`
+	Success      bool                `json:"success"`
+}
@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// enums lists the values of the models enum types, published as string schemas
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(models.PromptTemplateEnum("")): {
+		string(models.PromptNone), string(models.PromptTalentBio), string(models.PromptClientAboutUs),
+		string(models.PromptJobDescription), string(models.PromptProposalCoverLetter),
+	},
+	reflect.TypeOf(models.ModelTuneEnum("")): {
+		string(models.TuneProfessional), string(models.TuneConfident), string(models.TuneFriendly),
+		string(models.TuneEnthusiastic), string(models.TuneFormal), string(models.TuneWarm), string(models.TunePersuasive),
+	},
+	reflect.TypeOf(models.ModelResponseLengthEnum("")): {
+		string(models.LengthShort), string(models.LengthMedium), string(models.LengthDetailed),
+	},
+	reflect.TypeOf(models.OutputFormatEnum("")): {
+		string(models.FormatMarkdown), string(models.FormatHTML), string(models.FormatText),
+	},
+	reflect.TypeOf(models.TaskKindEnum("")): {
+		string(models.TaskJobCategorize), string(models.TaskJobDescribe),
+	},
+	reflect.TypeOf(models.TaskStatusEnum("")): {
+		string(models.TaskQueued), string(models.TaskRunning), string(models.TaskCompleted),
+		string(models.TaskFailed), string(models.TaskCancelled),
+	},
+	reflect.TypeOf(models.FeedbackRatingEnum("")): {
+		string(models.FeedbackUp), string(models.FeedbackDown),
+	},
+	reflect.TypeOf(models.FeedbackReasonEnum("")): {
+		string(models.ReasonTooLong), string(models.ReasonTooShort), string(models.ReasonWrongTone),
+		string(models.ReasonInaccurate), string(models.ReasonGeneric), string(models.ReasonFormatting),
+		string(models.ReasonOffTopic), string(models.ReasonOther),
+	},
+	reflect.TypeOf(models.ConversationRoleEnum("")): {
+		string(models.ConversationRoleUser), string(models.ConversationRoleAssistant),
+	},
+	reflect.TypeOf(models.ErrorCodeEnum("")): {
+		string(models.ErrInvalidRequest), string(models.ErrUnauthorized), string(models.ErrForbidden),
+		string(models.ErrNotFound), string(models.ErrConflict), string(models.ErrInProgress), string(models.ErrUserNotFound),
+		string(models.ErrTemplateInvalid), string(models.ErrUpstreamRateLimited), string(models.ErrUpstreamError),
+		string(models.ErrQuotaExceeded), string(models.ErrInternal),
+	},
+}
+
+// schemas collects the named schemas referenced while describing types
+type schemas map[string]interface{}
+
+// ref returns a reference to a component schema
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// of describes a Go type as an OpenAPI schema. Structs and enums are added as
+// components and referenced by name.
+func (s schemas) of(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	if values, ok := enums[t]; ok {
+		if _, done := s[t.Name()]; !done {
+			s[t.Name()] = map[string]interface{}{"type": "string", "enum": values}
+		}
+		return ref(t.Name())
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, done := s[t.Name()]; !done {
+			// Reserve the name first so recursive types terminate
+			s[t.Name()] = nil
+			s[t.Name()] = s.object(t)
+		}
+		return ref(t.Name())
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object describes a struct's JSON fields
+func (s schemas) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{}, t.NumField())
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		name, prop, isRequired, ok := s.field(t.Field(i))
+		if !ok {
+			continue
+		}
+		if isRequired {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// field describes a struct field by its JSON name, applying its binding rules. A
+// field is required when binding requires it, or when it has no binding rules and
+// is always serialized. ok is false for fields that are not part of the JSON.
+func (s schemas) field(field reflect.StructField) (name string, prop map[string]interface{}, required, ok bool) {
+	tag := strings.Split(field.Tag.Get("json"), ",")
+	name = tag[0]
+	if name == "" || name == "-" || !field.IsExported() {
+		return "", nil, false, false
+	}
+
+	omitempty := false
+	for _, opt := range tag[1:] {
+		omitempty = omitempty || opt == "omitempty"
+	}
+
+	binding, bound := field.Tag.Lookup("binding")
+	required = !bound && !omitempty
+
+	rules, itemRules, _ := strings.Cut(binding, ",dive")
+	itemRules = strings.TrimPrefix(itemRules, ",")
+
+	prop = s.of(field.Type)
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "required" {
+			required = true
+		}
+	}
+	prop = constrain(prop, rules)
+	if items, ok := prop["items"].(map[string]interface{}); ok && itemRules != "" {
+		prop["items"] = constrain(items, itemRules)
+	}
+
+	return name, prop, required, true
+}
+
+// boundKeys maps min and max binding rules to schema keywords by schema type
+var boundKeys = map[string]map[string]string{
+	"string":  {"min": "minLength", "max": "maxLength"},
+	"array":   {"min": "minItems", "max": "maxItems"},
+	"integer": {"min": "minimum", "max": "maximum"},
+	"number":  {"min": "minimum", "max": "maximum"},
+}
+
+// constrain applies binding rules to a schema. An enum from a oneof rule replaces a
+// reference, since it is what the request is validated against.
+func constrain(prop map[string]interface{}, rules string) map[string]interface{} {
+	for _, rule := range strings.Split(rules, ",") {
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "oneof":
+			prop = map[string]interface{}{"type": "string", "enum": strings.Fields(param)}
+		case "min", "max":
+			v, err := strconv.ParseFloat(param, 64)
+			typ, _ := prop["type"].(string)
+			if err != nil || boundKeys[typ] == nil {
+				continue
+			}
+			prop[boundKeys[typ][tag]] = v
+		}
+	}
+	return prop
+}
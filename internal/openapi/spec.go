@@ -0,0 +1,429 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. Schemas are
+// generated from the models package types, so the document follows their JSON tags
+// and binding rules.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.0.3"
+
+// Info is the service-specific part of the document
+type Info struct {
+	Title   string
+	Version string
+	// APIPrefix is prepended to every v1 operation path, e.g. /api/v1
+	APIPrefix string
+	// V2Prefix is prepended to every v2 operation path, e.g. /api/v2
+	V2Prefix string
+
+	// Authentication header names
+	ServiceKeyHeader string
+	ClientNameHeader string
+	SecretHashHeader string
+}
+
+// Param is a query or header parameter
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Enum        []string
+}
+
+// Operation documents one route
+type Operation struct {
+	Method string
+	// Path is relative to the API prefix, with gin-style parameters such as /tasks/:id
+	Path        string
+	Tag         string
+	Summary     string
+	Description string
+
+	// Public routes need no authentication; Admin routes need an admin service
+	Public bool
+	Admin  bool
+
+	// Request is a zero value of the JSON body type, nil when there is no body
+	Request interface{}
+	// Response is a zero value of the JSON response type of a successful call
+	Response interface{}
+	// Statuses lists the error statuses besides the authentication errors. Every
+	// error is answered with a models.ErrorResponse
+	Statuses []int
+	Params   []Param
+
+	// Async routes can run as a task and return 202 with a models.TaskResponse
+	Async bool
+
+	// V2 routes are served under the v2 prefix; v1 routes are deprecated
+	V2 bool
+}
+
+var (
+	userIDParam = Param{Name: "user_id", In: "query", Description: "Public ID of the Dokoola user the content is for"}
+	formatParam = Param{Name: "format", In: "query", Description: "Output format of the generated text",
+		Enum: []string{string(models.FormatMarkdown), string(models.FormatHTML), string(models.FormatText)}}
+	idempotencyParam = Param{Name: "Idempotency-Key", In: "header", Description: "Replays the stored response for a retried request with the same key"}
+	noCacheParam     = Param{Name: "Cache-Control", In: "header", Description: "no-cache skips the LLM response cache"}
+	asyncParams      = []Param{
+		{Name: "async", In: "query", Description: "true runs the request as an async task", Enum: []string{"true"}},
+		{Name: "callback_url", In: "query", Description: "URL the task result is POSTed to; implies async"},
+		{Name: "Prefer", In: "header", Description: "respond-async runs the request as an async task"},
+	}
+	userIDHeaderParam = Param{Name: "X-User-ID", In: "header", Description: "Public ID of the Dokoola user the content is for, if user_id is not in the body"}
+)
+
+// Operations lists every route the service registers, relative to the API prefix of
+// its version
+var Operations = withV2(v1Operations, v2Operations)
+
+// v1Operations are the routes of the v1 API
+var v1Operations = []Operation{
+	{
+		Method: http.MethodGet, Path: "/health", Tag: "Health", Public: true,
+		Summary:  "Service health status",
+		Response: models.HealthCheckResponse{},
+	},
+	{
+		Method: http.MethodPost, Path: "/jobs/describe", Tag: "Jobs", Async: true,
+		Summary:  "Generate job descriptions",
+		Request:  []models.JobDescribeRequest{},
+		Response: models.JobDescribeResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   append([]Param{formatParam, idempotencyParam}, asyncParams...),
+	},
+	{
+		Method: http.MethodPost, Path: "/jobs/categorize", Tag: "Jobs", Async: true,
+		Summary:     "Categorize job postings",
+		Description: "Assigns each job the slug of its most relevant category. Jobs the LLM fails on get an empty category.",
+		Request:     models.JobCategorizationRequest{},
+		Response:    models.JobCategorizationResponse{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:      append([]Param{idempotencyParam, noCacheParam}, asyncParams...),
+	},
+	{
+		Method: http.MethodPost, Path: "/chat/completion", Tag: "Text Completion",
+		Summary:  "Generate a text completion",
+		Request:  models.TextCompletionRequest{},
+		Response: models.TextCompletionResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   []Param{userIDParam, idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/actions/generate-prompt", Tag: "Prompt Generation",
+		Summary:  "Generate content from a template",
+		Request:  models.PromptGenerationRequest{},
+		Response: models.PromptGenerationResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   []Param{userIDParam, idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/actions/render-prompt", Tag: "Prompt Generation",
+		Summary:     "Preview the LLM request for a generation",
+		Description: "Takes the same body as generate-prompt and returns the exact messages, without calling the LLM.",
+		Request:     models.PromptGenerationRequest{},
+		Response:    models.RenderPromptResponse{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Params:      []Param{userIDParam},
+	},
+	{
+		Method: http.MethodGet, Path: "/templates", Tag: "Templates",
+		Summary:  "Catalog of templates, their input fields and options",
+		Response: models.TemplateCatalogResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/templates/:name/schema", Tag: "Templates",
+		Summary:  "JSON Schema for a template's data",
+		Response: models.TemplateSchemaResponse{},
+		Statuses: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/actions/refine", Tag: "Prompt Generation",
+		Summary:     "Revise generated content",
+		Description: "Applies an instruction such as \"more formal\" to content from generation_id, or to text generated from template_name. A generation must belong to the calling service, and its tone, length, locale and format apply unless the request sets them. The revision keeps the template's output constraints and is recorded as a new generation.",
+		Request:     models.RefineRequest{},
+		Response:    models.CompletionV2Response{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:      []Param{userIDHeaderParam, idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/chat/conversations", Tag: "Conversations",
+		Summary:     "Start a conversation",
+		Description: "Conversations keep their history server-side, so each message is answered in the context of the earlier turns. Idle conversations expire.",
+		Request:     models.ConversationCreateRequest{},
+		Response:    models.ConversationResponse{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Params:      []Param{userIDHeaderParam},
+	},
+	{
+		Method: http.MethodGet, Path: "/chat/conversations/:id", Tag: "Conversations",
+		Summary:  "Conversation history",
+		Response: models.ConversationResponse{},
+		Statuses: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodDelete, Path: "/chat/conversations/:id", Tag: "Conversations",
+		Summary:  "End a conversation",
+		Response: models.ConversationResponse{},
+		Statuses: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/chat/conversations/:id/messages", Tag: "Conversations",
+		Summary:     "Send a message and get the reply",
+		Description: "When the history outgrows the token window, the oldest messages are summarized and sent as the summary instead.",
+		Request:     models.ConversationMessageRequest{},
+		Response:    models.ConversationMessageResponse{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:      []Param{idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/generations/:id/feedback", Tag: "Generations",
+		Summary:     "Rate a generation",
+		Description: "A generation keeps one rating: submitting again replaces the earlier feedback in the stats.",
+		Request:     models.GenerationFeedbackRequest{},
+		Response:    models.GenerationFeedbackResponse{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/generations/stats", Tag: "Generations",
+		Summary:  "Feedback stats per template version",
+		Response: models.GenerationStatsResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/tasks/:id", Tag: "Async Tasks",
+		Summary:  "Task status, progress and results",
+		Response: models.TaskResponse{},
+		Statuses: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodDelete, Path: "/tasks/:id", Tag: "Async Tasks",
+		Summary:  "Cancel a queued or running task",
+		Response: models.TaskResponse{},
+		Statuses: []int{http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/webhooks/dead-letters", Tag: "Admin", Admin: true,
+		Summary:  "Webhook deliveries that exhausted their retries",
+		Response: models.WebhookDeadLettersResponse{},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/templates/reload", Tag: "Admin", Admin: true,
+		Summary:  "Reload prompt templates from disk",
+		Response: models.TemplatesReloadResponse{},
+		Statuses: []int{http.StatusUnprocessableEntity},
+	},
+}
+
+// v2Operations are the v2 routes whose shapes differ from v1. Every other v1 route
+// is served by v2 unchanged.
+var v2Operations = []Operation{
+	{
+		Method: http.MethodPost, Path: "/jobs/describe", Tag: "Jobs", Async: true,
+		Summary:  "Generate job descriptions",
+		Request:  models.JobDescribeV2Request{},
+		Response: models.JobDescribeV2Response{},
+		Statuses: []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   append([]Param{idempotencyParam}, asyncParams...),
+	},
+	{
+		Method: http.MethodPost, Path: "/jobs/categorize", Tag: "Jobs", Async: true,
+		Summary:     "Categorize job postings",
+		Description: "Assigns each job the slug of its most relevant category. Jobs the LLM fails on get an empty category.",
+		Request:     models.JobCategorizationV2Request{},
+		Response:    models.JobCategorizationV2Response{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:      append([]Param{idempotencyParam, noCacheParam}, asyncParams...),
+	},
+	{
+		Method: http.MethodPost, Path: "/chat/completion", Tag: "Text Completion",
+		Summary:  "Generate a text completion",
+		Request:  models.TextCompletionV2Request{},
+		Response: models.CompletionV2Response{},
+		Statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   []Param{userIDHeaderParam, idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/actions/generate-prompt", Tag: "Prompt Generation",
+		Summary:  "Generate content from a template",
+		Request:  models.PromptGenerationV2Request{},
+		Response: models.CompletionV2Response{},
+		Statuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		Params:   []Param{userIDHeaderParam, idempotencyParam},
+	},
+	{
+		Method: http.MethodPost, Path: "/actions/render-prompt", Tag: "Prompt Generation",
+		Summary:     "Preview the LLM request for a generation",
+		Description: "Takes the same body as generate-prompt and returns the exact messages, without calling the LLM.",
+		Request:     models.PromptGenerationV2Request{},
+		Response:    models.RenderPromptV2Response{},
+		Statuses:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Params:      []Param{userIDHeaderParam},
+	},
+}
+
+// withV2 lists the v1 operations followed by the v2 ones: the changed routes, then
+// the v1 routes that v2 serves unchanged
+func withV2(v1, changed []Operation) []Operation {
+	ops := append([]Operation(nil), v1...)
+
+	replaced := map[string]bool{}
+	for _, op := range changed {
+		op.V2 = true
+		ops = append(ops, op)
+		replaced[op.Method+" "+op.Path] = true
+	}
+	for _, op := range v1 {
+		if !replaced[op.Method+" "+op.Path] {
+			op.V2 = true
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Spec builds the OpenAPI document for the operations
+func Spec(info Info) map[string]interface{} {
+	components := schemas{}
+	paths := map[string]interface{}{}
+
+	for _, op := range Operations {
+		prefix := info.APIPrefix
+		if op.V2 {
+			prefix = info.V2Prefix
+		}
+		path := prefix + PathTemplate(op.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation(op, components)
+	}
+
+	return map[string]interface{}{
+		"openapi": Version,
+		"info": map[string]interface{}{
+			"title":   info.Title,
+			"version": info.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}(components),
+			"securitySchemes": map[string]interface{}{
+				"serviceKey": apiKeyScheme(info.ServiceKeyHeader, "Service key from config.ini"),
+				"clientName": apiKeyScheme(info.ClientNameHeader, "Client name of the service"),
+				"secretHash": apiKeyScheme(info.SecretHashHeader, "Secret hash of the service"),
+			},
+		},
+	}
+}
+
+// PathTemplate converts a gin path such as /tasks/:id to /tasks/{id}
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operation builds the OpenAPI operation object for a route
+func operation(op Operation, components schemas) map[string]interface{} {
+	doc := map[string]interface{}{
+		"operationId": operationID(op),
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+	}
+	if op.Description != "" {
+		doc["description"] = op.Description
+	}
+	if !op.V2 {
+		doc["deprecated"] = true
+	}
+
+	params := []interface{}{}
+	for _, segment := range strings.Split(op.Path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, map[string]interface{}{
+				"name": segment[1:], "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	for _, p := range op.Params {
+		schema := map[string]interface{}{"type": "string"}
+		if len(p.Enum) > 0 {
+			schema["enum"] = p.Enum
+		}
+		params = append(params, map[string]interface{}{
+			"name": p.Name, "in": p.In, "description": p.Description, "schema": schema,
+		})
+	}
+	if len(params) > 0 {
+		doc["parameters"] = params
+	}
+
+	if op.Request != nil {
+		doc["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(components.of(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	errorResponse := components.of(reflect.TypeOf(models.ErrorResponse{}))
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{"description": http.StatusText(http.StatusOK), "content": jsonContent(components.of(reflect.TypeOf(op.Response)))},
+	}
+	for _, status := range op.Statuses {
+		responses[strconv.Itoa(status)] = map[string]interface{}{"description": http.StatusText(status), "content": jsonContent(errorResponse)}
+	}
+	if op.Async {
+		responses["202"] = map[string]interface{}{
+			"description": "Accepted as an async task; poll the Location header",
+			"content":     jsonContent(components.of(reflect.TypeOf(models.TaskResponse{}))),
+		}
+	}
+
+	if !op.Public {
+		description := "Missing or invalid service credentials"
+		if op.Admin {
+			description += ", or the service is not an admin"
+		}
+		responses["403"] = map[string]interface{}{"description": description, "content": jsonContent(errorResponse)}
+		doc["security"] = []interface{}{map[string]interface{}{"serviceKey": []string{}, "clientName": []string{}, "secretHash": []string{}}}
+	}
+	doc["responses"] = responses
+
+	return doc
+}
+
+// operationID derives a stable ID such as postJobsCategorize, or postJobsCategorizeV2
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == ':' }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if op.V2 {
+		b.WriteString("V2")
+	}
+	return b.String()
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func apiKeyScheme(header, description string) map[string]interface{} {
+	return map[string]interface{}{"type": "apiKey", "in": "header", "name": header, "description": description}
+}
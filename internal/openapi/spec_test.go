@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite api/openapi.json from the models")
+
+// goldenPath is the published API contract
+const goldenPath = "../../api/openapi.json"
+
+// goldenInfo describes the service for the published contract
+var goldenInfo = Info{
+	Title:            "Dokoola LLM Service",
+	Version:          "0.1.0",
+	APIPrefix:        "/api/v1",
+	V2Prefix:         "/api/v2",
+	ServiceKeyHeader: "X-Service-Key",
+	ClientNameHeader: "X-Service-Client-Name",
+	SecretHashHeader: "X-Service-Secret",
+}
+
+func TestSpecMatchesGolden(t *testing.T) {
+	got, err := json.MarshalIndent(Spec(goldenInfo), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("%v; run go test ./internal/openapi -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the API no longer matches %s. If the change is intended, run go test ./internal/openapi -update and review the diff", goldenPath)
+	}
+}
+
+func TestSpecSchemas(t *testing.T) {
+	spec := Spec(goldenInfo)
+	components := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	request := components["PromptGenerationRequest"].(map[string]interface{})
+	if got := request["required"]; !reflect.DeepEqual(got, []string{"data", "template_name"}) {
+		t.Errorf("PromptGenerationRequest required = %v", got)
+	}
+	props := request["properties"].(map[string]interface{})
+	if tone := props["tone"].(map[string]interface{}); len(tone["enum"].([]string)) != 7 {
+		t.Errorf("tone = %v, want the binding's oneof values", tone)
+	}
+	if name := props["template_name"].(map[string]interface{}); name["$ref"] != "#/components/schemas/PromptTemplateEnum" {
+		t.Errorf("template_name = %v", name)
+	}
+
+	// Responses require the fields that are always serialized
+	task := components["TaskInfo"].(map[string]interface{})
+	if got := task["required"]; !reflect.DeepEqual(got, []string{"id", "kind", "status", "progress", "created_at", "updated_at"}) {
+		t.Errorf("TaskInfo required = %v", got)
+	}
+	created := task["properties"].(map[string]interface{})["created_at"].(map[string]interface{})
+	if created["format"] != "date-time" {
+		t.Errorf("created_at = %v", created)
+	}
+
+	feedback := components["GenerationFeedbackRequest"].(map[string]interface{})["properties"].(map[string]interface{})
+	reasons := feedback["reasons"].(map[string]interface{})["items"].(map[string]interface{})
+	if len(reasons["enum"].([]string)) != 8 {
+		t.Errorf("reasons items = %v, want the dive oneof values", reasons)
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	if _, ok := paths["/api/v1/tasks/{id}"].(map[string]interface{})["delete"]; !ok {
+		t.Error("expected DELETE /api/v1/tasks/{id}")
+	}
+	health := paths["/api/v1/health"].(map[string]interface{})["get"].(map[string]interface{})
+	if _, ok := health["security"]; ok {
+		t.Error("health check should be public")
+	}
+}
+
+// Request fields validated with oneof must accept exactly the values of their enum
+// type, so the published enums and the validation rules can't drift apart
+func TestBindingEnumsMatchModels(t *testing.T) {
+	for _, op := range Operations {
+		if op.Request == nil {
+			continue
+		}
+		typ := reflect.TypeOf(op.Request)
+		if typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			ft := field.Type
+			if ft.Kind() == reflect.Slice {
+				ft = ft.Elem()
+			}
+			values, isEnum := enums[ft]
+			if !isEnum {
+				continue
+			}
+
+			_, oneof, ok := strings.Cut(field.Tag.Get("binding"), "oneof=")
+			if !ok {
+				continue
+			}
+			bound := strings.Fields(oneof)
+			want := append([]string(nil), values...)
+			sort.Strings(bound)
+			sort.Strings(want)
+			if !reflect.DeepEqual(bound, want) {
+				t.Errorf("%s.%s accepts %v, but %s has %v", typ.Name(), field.Name, bound, ft.Name(), want)
+			}
+		}
+	}
+}
+
+func TestSpecVersions(t *testing.T) {
+	paths := Spec(goldenInfo)["paths"].(map[string]interface{})
+
+	v1 := paths["/api/v1/actions/generate-prompt"].(map[string]interface{})["post"].(map[string]interface{})
+	if v1["deprecated"] != true {
+		t.Error("v1 operations should be deprecated")
+	}
+
+	v2 := paths["/api/v2/actions/generate-prompt"].(map[string]interface{})["post"].(map[string]interface{})
+	if _, ok := v2["deprecated"]; ok || v2["operationId"] != "postActionsGeneratePromptV2" {
+		t.Errorf("unexpected v2 operation: %v", v2)
+	}
+
+	// Routes whose shapes didn't change are served by both versions
+	for _, path := range []string{"/api/v1/tasks/{id}", "/api/v2/tasks/{id}", "/api/v2/health", "/api/v2/admin/templates/reload"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("expected %s", path)
+		}
+	}
+
+	var v1Count, v2Count int
+	for _, op := range Operations {
+		if op.V2 {
+			v2Count++
+		} else {
+			v1Count++
+		}
+	}
+	if v1Count != v2Count {
+		t.Errorf("v1 has %d operations and v2 %d; v2 should serve every v1 route", v1Count, v2Count)
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/tasks/:id":                "/tasks/{id}",
+		"/generations/:id/feedback": "/generations/{id}/feedback",
+		"/templates":                "/templates",
+	}
+	for in, want := range tests {
+		if got := PathTemplate(in); got != want {
+			t.Errorf("PathTemplate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOperationIDsAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, op := range Operations {
+		id := operationID(op)
+		if seen[id] {
+			t.Errorf("duplicate operation ID %s", id)
+		}
+		seen[id] = true
+	}
+	if !seen["postJobsCategorize"] || !seen["deleteTasksId"] {
+		t.Errorf("unexpected operation IDs: %v", seen)
+	}
+}
@@ -0,0 +1,222 @@
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// Rule names reported in constraint violations
+const (
+	RulePreamble        = "preamble"
+	RuleBannedCharacter = "banned_character"
+	RuleBannedPhrase    = "banned_phrase"
+	RuleMaxLength       = "max_length"
+)
+
+// Result is the output of running a chain over a completion
+type Result struct {
+	Text       string
+	Violations []models.ConstraintViolation
+	// Retry is set when a processor wants the model asked again with this instruction
+	Retry string
+}
+
+// retry adds an instruction for the next attempt to those of earlier processors
+func (r *Result) retry(instruction string) {
+	r.Retry = strings.TrimSpace(r.Retry + " " + instruction)
+}
+
+// Processor checks or rewrites a completion. final is true when no retry is possible,
+// so processors must fix what they can instead of asking for a retry.
+type Processor interface {
+	Process(text string, final bool, result *Result) string
+}
+
+// Chain runs processors in order
+type Chain []Processor
+
+// Run applies every processor to text
+func (c Chain) Run(text string, final bool) Result {
+	result := Result{}
+	for _, p := range c {
+		text = p.Process(text, final, &result)
+	}
+	result.Text = text
+	return result
+}
+
+// preamblePattern matches chatty lead-ins such as "Here is your bio:" or
+// "Sure! Here's a cover letter for you:"
+var preamblePattern = regexp.MustCompile(`(?i)^\s*(?:(?:sure|certainly|of course|absolutely|okay|ok)[!,.]?\s*)?(?:here(?:'s| is| are)|below is|i've written|i have written)\b[^\n]*:\s*\n+`)
+
+// StripPreamble removes a leading "Here is your ...:" line
+type StripPreamble struct{}
+
+func (StripPreamble) Process(text string, final bool, result *Result) string {
+	loc := preamblePattern.FindStringIndex(text)
+	if loc == nil {
+		return text
+	}
+
+	result.Violations = append(result.Violations, models.ConstraintViolation{
+		Rule:    RulePreamble,
+		Message: fmt.Sprintf("removed preamble %q", strings.TrimSpace(text[:loc[1]])),
+		Fixed:   true,
+	})
+	return text[loc[1]:]
+}
+
+// BannedCharacters replaces characters the prompt forbids, along with the spaces
+// around them
+type BannedCharacters struct {
+	Chars       []string
+	Replacement string
+}
+
+func (b BannedCharacters) Process(text string, final bool, result *Result) string {
+	for _, char := range b.Chars {
+		pattern := regexp.MustCompile(`[ \t]*` + regexp.QuoteMeta(char) + `[ \t]*`)
+		matches := pattern.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		text = pattern.ReplaceAllLiteralString(text, b.Replacement)
+		result.Violations = append(result.Violations, models.ConstraintViolation{
+			Rule:    RuleBannedCharacter,
+			Message: fmt.Sprintf("replaced %d occurrence(s) of %q", len(matches), char),
+			Fixed:   true,
+		})
+	}
+	return text
+}
+
+// BannedPhrases removes sentences containing phrases such as AI self-references.
+// Phrases match whole words only. With Reprompt set, the first pass asks for the
+// phrases to be left out instead; on the final pass the sentences are removed.
+type BannedPhrases struct {
+	Phrases  []string
+	Reprompt bool
+}
+
+func (b BannedPhrases) Process(text string, final bool, result *Result) string {
+	var found []string
+	for _, phrase := range b.Phrases {
+		pattern := regexp.MustCompile(`(?i)[^.!?\n]*` + wordPattern(phrase) + `[^.!?\n]*[.!?]?[ \t]*`)
+		matches := pattern.FindAllString(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		if b.Reprompt && !final {
+			found = append(found, phrase)
+			result.Violations = append(result.Violations, models.ConstraintViolation{
+				Rule:    RuleBannedPhrase,
+				Message: fmt.Sprintf("%d sentence(s) contain %q", len(matches), phrase),
+			})
+			continue
+		}
+		// Drop the sentence but keep the whitespace that separated it from the previous one
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return match[:len(match)-len(strings.TrimLeft(match, " \t"))]
+		})
+		result.Violations = append(result.Violations, models.ConstraintViolation{
+			Rule:    RuleBannedPhrase,
+			Message: fmt.Sprintf("removed %d sentence(s) containing %q", len(matches), phrase),
+			Fixed:   true,
+		})
+	}
+
+	if len(found) > 0 {
+		result.retry(fmt.Sprintf("Rewrite your answer without referring to yourself as an AI or language model (remove %q), keeping everything else.", strings.Join(found, `", "`)))
+	}
+	return text
+}
+
+// wordPattern matches a phrase as whole words: it can't start or end inside a word
+func wordPattern(phrase string) string {
+	pattern := regexp.QuoteMeta(phrase)
+	if r, _ := utf8.DecodeRuneInString(phrase); isWord(r) {
+		pattern = `\b` + pattern
+	}
+	if r, _ := utf8.DecodeLastRuneInString(phrase); isWord(r) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// MaxLength enforces a character limit. With Reprompt set, the first pass asks for a
+// shorter answer; otherwise, or on the final pass, the text is trimmed.
+type MaxLength struct {
+	Max      int
+	Reprompt bool
+}
+
+func (m MaxLength) Process(text string, final bool, result *Result) string {
+	length := utf8.RuneCountInString(text)
+	if m.Max <= 0 || length <= m.Max {
+		return text
+	}
+
+	if m.Reprompt && !final {
+		result.Violations = append(result.Violations, models.ConstraintViolation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("%d characters exceeds the %d character limit", length, m.Max),
+		})
+		result.retry(fmt.Sprintf("Your previous answer was %d characters long. Rewrite it in at most %d characters (including spaces), keeping the same content and format.", length, m.Max))
+		return text
+	}
+
+	result.Violations = append(result.Violations, models.ConstraintViolation{
+		Rule:    RuleMaxLength,
+		Message: fmt.Sprintf("trimmed from %d to at most %d characters", length, m.Max),
+		Fixed:   true,
+	})
+	return Truncate(text, m.Max)
+}
+
+// Truncate shortens text to at most max characters, cutting at the last sentence end
+// when that keeps at least a third of the text, or otherwise at the last word boundary
+func Truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	cut := string(runes[:max])
+	if i := strings.LastIndexAny(cut, ".!?"); i >= len(cut)/3 {
+		return strings.TrimSpace(cut[:i+1])
+	}
+	if i := strings.LastIndexAny(cut, " \n"); i > 0 {
+		return strings.TrimSpace(cut[:i])
+	}
+	return cut
+}
+
+var (
+	codeFencePattern  = regexp.MustCompile("(?s)^\\s*```(?:markdown|md)?\\s*\\n(.*?)\\n```\\s*$")
+	bulletPattern     = regexp.MustCompile(`(?m)^(\s*)[•●▪]\s*`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+	trailingPattern   = regexp.MustCompile(`(?m)[ \t]+$`)
+)
+
+// NormalizeMarkdown unwraps code-fenced answers, standardizes bullets and whitespace
+type NormalizeMarkdown struct{}
+
+func (NormalizeMarkdown) Process(text string, final bool, result *Result) string {
+	if m := codeFencePattern.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	}
+	text = bulletPattern.ReplaceAllString(text, "$1- ")
+	text = trailingPattern.ReplaceAllString(text, "")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
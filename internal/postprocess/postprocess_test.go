@@ -0,0 +1,138 @@
+package postprocess
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestStripPreamble(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"here is", "Here is your bio:\n\nI build things.", "I build things."},
+		{"sure", "Sure! Here's a cover letter for you:\nDear client,", "Dear client,"},
+		{"none", "I build things: fast.", "I build things: fast."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Chain{StripPreamble{}}.Run(tt.in, false)
+			if result.Text != tt.want {
+				t.Errorf("Text = %q, want %q", result.Text, tt.want)
+			}
+			if wantViolation := tt.in != tt.want; (len(result.Violations) > 0) != wantViolation {
+				t.Errorf("Violations = %+v", result.Violations)
+			}
+		})
+	}
+}
+
+func TestBannedCharactersAndPhrases(t *testing.T) {
+	chain := Chain{
+		BannedPhrases{Phrases: []string{"as an AI"}},
+		BannedCharacters{Chars: []string{"—"}, Replacement: ", "},
+	}
+
+	result := chain.Run("I ship fast — and I test. As an AI, I cannot lie. Let's talk.", false)
+
+	if want := "I ship fast, and I test. Let's talk."; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	if len(result.Violations) != 2 {
+		t.Fatalf("expected 2 violations, got %+v", result.Violations)
+	}
+	for _, v := range result.Violations {
+		if !v.Fixed {
+			t.Errorf("expected violation to be fixed: %+v", v)
+		}
+	}
+}
+
+func TestBannedPhrasesMatchWholeWords(t *testing.T) {
+	chain := Chain{BannedPhrases{Phrases: []string{"as an AI language model", "I'm an AI assistant"}}}
+
+	text := "As an AI engineer, I build computer vision systems for retail. I'm an AI researcher at heart. Let's talk."
+	if result := chain.Run(text, true); result.Text != text || len(result.Violations) != 0 {
+		t.Errorf("expected content about AI work to be kept, got %+v", result)
+	}
+
+	result := chain.Run("I build vision systems. I'm an AI assistantship coordinator. Let's talk.", true)
+	if len(result.Violations) != 0 {
+		t.Errorf("expected no match inside a word, got %+v", result.Violations)
+	}
+}
+
+func TestBannedPhrasesReprompt(t *testing.T) {
+	chain := Chain{
+		BannedPhrases{Phrases: []string{"as an AI language model"}, Reprompt: true},
+		MaxLength{Max: 40, Reprompt: true},
+	}
+	text := "As an AI language model, I can't meet you. I build Go services for retail teams."
+
+	result := chain.Run(text, false)
+	if result.Text != text {
+		t.Errorf("expected the text kept for the re-prompt, got %q", result.Text)
+	}
+	if !strings.Contains(result.Retry, "as an AI language model") || !strings.Contains(result.Retry, "at most 40 characters") {
+		t.Errorf("expected both retry instructions, got %q", result.Retry)
+	}
+	if len(result.Violations) != 2 || result.Violations[0].Fixed || result.Violations[0].Rule != RuleBannedPhrase {
+		t.Errorf("unexpected violations: %+v", result.Violations)
+	}
+
+	// The final pass removes what the re-prompt didn't fix
+	result = chain.Run(text, true)
+	if result.Text != "I build Go services for retail teams." || result.Retry != "" {
+		t.Errorf("unexpected final result: %+v", result)
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	text := "First sentence here. Second sentence is a good bit longer than the first one."
+
+	t.Run("reprompt", func(t *testing.T) {
+		result := Chain{MaxLength{Max: 40, Reprompt: true}}.Run(text, false)
+		if result.Retry == "" || result.Text != text {
+			t.Errorf("expected a retry without trimming, got %+v", result)
+		}
+		if len(result.Violations) != 1 || result.Violations[0].Fixed || result.Violations[0].Rule != RuleMaxLength {
+			t.Errorf("unexpected violations: %+v", result.Violations)
+		}
+	})
+
+	t.Run("final pass trims", func(t *testing.T) {
+		result := Chain{MaxLength{Max: 40, Reprompt: true}}.Run(text, true)
+		if result.Retry != "" || result.Text != "First sentence here." {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		result := Chain{MaxLength{Max: 200, Reprompt: true}}.Run(text, false)
+		if result.Retry != "" || len(result.Violations) != 0 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	got := Truncate("Réalisé avec soin pour des clients exigeants", 20)
+	if utf8.RuneCountInString(got) > 20 || strings.HasSuffix(got, " ") {
+		t.Errorf("Truncate() = %q", got)
+	}
+	if got != "Réalisé avec soin" {
+		t.Errorf("Truncate() = %q, want cut at a word boundary", got)
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	in := "```markdown\n# Title   \n\n\n\n• one\n• two\n```"
+	want := "# Title\n\n- one\n- two"
+
+	if got := (Chain{NormalizeMarkdown{}}).Run(in, false).Text; got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,263 @@
+package prompts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// TalentBioInput is the data accepted by the talent_bio template
+type TalentBioInput struct {
+	Profile *TalentProfile `json:"profile" validate:"required"`
+}
+
+// TalentProfile is a talent's public profile
+type TalentProfile struct {
+	Name          string       `json:"name" validate:"required_without=User"`
+	Title         string       `json:"title" validate:"required"`
+	Skills        string       `json:"skills"`
+	Rating        *float64     `json:"rating" validate:"omitempty,gte=0,lte=5"`
+	JobsCompleted int          `json:"jobs_completed" validate:"gte=0"`
+	User          *ProfileUser `json:"user"`
+}
+
+// ProfileUser holds the account names attached to a profile
+type ProfileUser struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name"`
+}
+
+// ClientAboutUsInput is the data accepted by the client_about_us template
+type ClientAboutUsInput struct {
+	Profile *ClientProfile `json:"profile" validate:"required"`
+}
+
+// ClientProfile is a client's profile. Company details take precedence over the
+// profile's own fields when present.
+type ClientProfile struct {
+	Name     string   `json:"name" validate:"required_without=Company"`
+	Industry string   `json:"industry"`
+	Country  *Country `json:"country"`
+	Company  *Company `json:"company"`
+}
+
+// Company is the company a client hires for
+type Company struct {
+	Name     string   `json:"name" validate:"required"`
+	Industry string   `json:"industry"`
+	Country  *Country `json:"country"`
+}
+
+// Country is a country reference
+type Country struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// JobDescriptionInput is the data accepted by the job_description template
+type JobDescriptionInput struct {
+	Job    *Job       `json:"job" validate:"required"`
+	Client *JobClient `json:"client" validate:"required"`
+}
+
+// Job is a job posting
+type Job struct {
+	Title               string              `json:"title" validate:"required"`
+	Description         string              `json:"description"`
+	CategoryName        string              `json:"category_name"`
+	Category            *JobCategory        `json:"category"`
+	JobType             string              `json:"job_type"`
+	ExperienceLevel     string              `json:"experience_level"`
+	EstimatedDuration   string              `json:"estimated_duration"`
+	RequiredSkills      []string            `json:"required_skills"`
+	Address             string              `json:"address"`
+	Country             *Country            `json:"country"`
+	ApplicationDeadline string              `json:"application_deadline" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Pricing             *JobPricing         `json:"pricing"`
+	IsThirdParty        bool                `json:"is_third_party"`
+	ThirdPartyMetadata  *ThirdPartyMetadata `json:"third_party_metadata"`
+	Client              *JobClient          `json:"client"`
+}
+
+// JobCategory is a job's category
+type JobCategory struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// JobPricing is a job's budget
+type JobPricing struct {
+	Budget     float64   `json:"budget" validate:"gte=0"`
+	FixedPrice bool      `json:"fixed_price"`
+	Currency   *Currency `json:"currency"`
+}
+
+// Currency is the currency a job is priced in
+type Currency struct {
+	Symbol string `json:"symbol" validate:"required"`
+}
+
+// ThirdPartyMetadata describes the company behind a job posted on its behalf
+type ThirdPartyMetadata struct {
+	CompanyName string `json:"company_name"`
+}
+
+// JobClient is the client who posted a job
+type JobClient struct {
+	Name  string `json:"name" validate:"required"`
+	About string `json:"about"`
+}
+
+// ProposalCoverLetterInput is the data accepted by the proposal_cover_letter template
+type ProposalCoverLetterInput struct {
+	Talent   *ProposalTalent   `json:"talent" validate:"required"`
+	Job      *Job              `json:"job" validate:"required"`
+	Resume   *Resume           `json:"resume"`
+	Metadata *ProposalMetadata `json:"metadata"`
+}
+
+// ProposalTalent is the talent applying to a job
+type ProposalTalent struct {
+	Name    string   `json:"name" validate:"required"`
+	Title   string   `json:"title" validate:"required"`
+	Bio     string   `json:"bio"`
+	Skills  string   `json:"skills"`
+	Pricing float64  `json:"pricing" validate:"gte=0"`
+	Rating  *float64 `json:"rating" validate:"omitempty,gte=0,lte=5"`
+	Badge   string   `json:"badge"`
+}
+
+// Resume is the resume attached to a proposal
+type Resume struct {
+	Description string `json:"description"`
+}
+
+// ProposalMetadata holds the writing options for a proposal
+type ProposalMetadata struct {
+	Tone            models.ModelTuneEnum           `json:"tone" validate:"omitempty,oneof=professional confident friendly enthusiastic formal warm persuasive"`
+	Length          models.ModelResponseLengthEnum `json:"length" validate:"omitempty,oneof=short medium detailed"`
+	AdditionalNotes string                         `json:"additional_notes"`
+}
+
+// style returns the tone and length chosen in the proposal metadata
+func (in *ProposalCoverLetterInput) style() (models.ModelTuneEnum, models.ModelResponseLengthEnum) {
+	if in.Metadata == nil {
+		return "", ""
+	}
+	return in.Metadata.Tone, in.Metadata.Length
+}
+
+// ValidationError reports every invalid field in template data
+type ValidationError struct {
+	Fields []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+var validate = newValidator()
+
+// newValidator creates a validator that reports fields by their JSON names
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// decodeInput decodes template data into input and validates it. Type mismatches and
+// failed validation rules are returned as a *ValidationError.
+func decodeInput(data map[string]interface{}, input interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(input); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &ValidationError{Fields: []models.FieldError{{
+				Field:   typeErr.Field,
+				Message: "must be " + jsonTypeName(typeErr.Type),
+			}}}
+		}
+		return fmt.Errorf("invalid data: %w", err)
+	}
+
+	if err := validate.Struct(input); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
+
+		verr := &ValidationError{}
+		for _, fe := range fieldErrs {
+			verr.Fields = append(verr.Fields, models.FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Message: fieldMessage(fe),
+			})
+		}
+		return verr
+	}
+
+	return nil
+}
+
+// fieldPath strips the root struct name from a validator namespace
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldMessage turns a failed validation rule into a readable message
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "datetime":
+		return "must be an RFC 3339 timestamp"
+	default:
+		return "is invalid"
+	}
+}
+
+// jsonTypeName describes a Go type by its JSON type
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,116 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// refineTemplate asks for targeted edits to previously generated content. It only
+// knows the template's description and constraints, not the data the content was
+// generated from, so it keeps the facts already in the text.
+var refineTemplate = template.Must(template.New("refine").Parse(`{{define "system" -}}
+You revise content previously generated for the Dokoola freelance marketplace. Apply the user's instruction as a targeted edit: change what it asks for and keep everything else, including the facts, structure and voice of the original. Do not invent facts that are not in the original or the instruction.
+{{- end}}
+{{define "user" -}}
+Revise the following text ({{.Description}}).
+
+Instruction: {{.Instruction}}
+
+Original:
+"""
+{{.Original}}
+"""
+
+Constraints:
+{{- if .Tone}}
+- Tone: {{.ToneDescription}}, unless the instruction asks for another{{end}}
+{{- if .LengthGuideline}}
+- Length: {{.LengthGuideline}}, unless the instruction asks for another{{end}}
+{{- if .MaxChars}}
+- Under {{.MaxChars}} characters total{{end}}
+- Keep what the instruction does not ask to change
+
+Output ONLY the revised text in simple markdown, without HTML tags. No explanations or preamble.
+{{- end}}`))
+
+// RenderRefinement builds a prompt revising content generated from a template. The
+// version's generation params and the template's output constraints (max_chars for
+// the length, banned phrases and characters) apply as they do to a generation, so
+// the revision is post-processed the same way. Tone and length guidance is only
+// added when given, and gives way to the instruction.
+func (r *Registry) RenderRefinement(name models.PromptTemplateEnum, sel RenderOptions, original, instruction string) (*RenderedPrompt, error) {
+	if name == models.PromptNone {
+		return nil, &ValidationError{Fields: []models.FieldError{{Field: "template_name", Message: "only content generated from a template can be refined"}}}
+	}
+
+	t, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown template: %s", name)
+	}
+
+	return t.RenderRefinement(sel, original, instruction)
+}
+
+// RenderRefinement picks a version like Render and builds a refinement prompt for it
+func (t *Template) RenderRefinement(sel RenderOptions, original, instruction string) (*RenderedPrompt, error) {
+	if err := validateStyle(sel.Tone, sel.Length); err != nil {
+		return nil, err
+	}
+
+	var v *Version
+	if sel.Version != "" {
+		if v = t.Version(sel.Version); v == nil {
+			return nil, fmt.Errorf("unknown version %s for template %s", sel.Version, t.Name)
+		}
+	} else {
+		v = t.Pick(sel.UserID)
+	}
+
+	length := sel.Length
+	if length == "" {
+		length = DefaultLength
+	}
+
+	view := map[string]interface{}{
+		"Description":     t.Description,
+		"Instruction":     strings.TrimSpace(instruction),
+		"Original":        strings.TrimSpace(original),
+		"Tone":            string(sel.Tone),
+		"ToneDescription": ToneDescriptions[sel.Tone],
+		"LengthGuideline": LengthGuidelines[sel.Length],
+		"MaxChars":        t.MaxChars[length],
+	}
+
+	rendered := &RenderedPrompt{
+		Version:     v.Name,
+		Tone:        sel.Tone,
+		Length:      length,
+		Locale:      sel.Locale,
+		Params:      v.Params,
+		MaxChars:    t.MaxChars[length],
+		PostProcess: t.pipeline(length),
+	}
+
+	var buf bytes.Buffer
+	if err := refineTemplate.ExecuteTemplate(&buf, "user", view); err != nil {
+		return nil, fmt.Errorf("failed to render refinement of %s@%s: %w", t.Name, v.Name, err)
+	}
+	rendered.User = buf.String()
+
+	buf.Reset()
+	if err := refineTemplate.ExecuteTemplate(&buf, "system", view); err != nil {
+		return nil, fmt.Errorf("failed to render refinement of %s@%s: %w", t.Name, v.Name, err)
+	}
+	rendered.System = strings.TrimSpace(buf.String())
+
+	// Ask for the requested language, as a generation would
+	if instruction := sel.Locale.Instruction(); instruction != "" {
+		rendered.System = strings.TrimSpace(rendered.System + "\n\n" + instruction)
+	}
+
+	return rendered, nil
+}
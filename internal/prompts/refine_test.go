@@ -0,0 +1,63 @@
+package prompts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestRegistryRenderRefinement(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	fr, _ := locale.Parse("fr")
+	rendered, err := registry.RenderRefinement(models.PromptTalentBio, RenderOptions{Length: models.LengthShort, Locale: fr},
+		"I build reliable Go services.", "Emphasize Python")
+	if err != nil {
+		t.Fatalf("RenderRefinement() error = %v", err)
+	}
+
+	for _, want := range []string{"Emphasize Python", "I build reliable Go services.", "Under 300 characters", "professional bio"} {
+		if !strings.Contains(rendered.User, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, rendered.User)
+		}
+	}
+	if strings.Contains(rendered.User, "Tone:") {
+		t.Error("expected no tone guidance unless a tone is requested")
+	}
+	if !strings.Contains(rendered.System, fr.Instruction()) {
+		t.Errorf("expected the locale instruction in the system prompt, got %q", rendered.System)
+	}
+	if rendered.Version != DefaultVersion || rendered.Length != models.LengthShort {
+		t.Errorf("unexpected rendered prompt: %+v", rendered)
+	}
+
+	// The template's output constraints apply to the revision
+	if result := rendered.PostProcess.Run(strings.Repeat("I build reliable Python services. ", 20), true); len([]rune(result.Text)) > 300 {
+		t.Errorf("expected the short bio limit to apply, got %d characters", len([]rune(result.Text)))
+	}
+}
+
+func TestRegistryRenderRefinementErrors(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	var verr *ValidationError
+	if _, err := registry.RenderRefinement(models.PromptNone, RenderOptions{}, "text", "shorter"); !errors.As(err, &verr) {
+		t.Errorf("expected a validation error for the none template, got %v", err)
+	}
+	if _, err := registry.RenderRefinement(models.PromptTalentBio, RenderOptions{Tone: "sarcastic"}, "text", "shorter"); !errors.As(err, &verr) {
+		t.Errorf("expected a validation error for an unknown tone, got %v", err)
+	}
+	if _, err := registry.RenderRefinement(models.PromptTalentBio, RenderOptions{Version: "v9"}, "text", "shorter"); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,609 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/postprocess"
+	"go.uber.org/zap"
+)
+
+// defaultTemplates holds the templates shipped with the binary. A directory
+// configured with PROMPT_TEMPLATES_DIR replaces them at runtime.
+//
+//go:embed templates/*.json templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Params are the generation parameters a template asks for. Unset fields keep the
+// LLM client defaults.
+type Params struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+}
+
+// Apply overrides the given options with the parameters set on the template
+func (p Params) Apply(opts llm.Options) llm.Options {
+	if p.Temperature != nil {
+		opts.Temperature = *p.Temperature
+	}
+	if p.TopP != nil {
+		opts.TopP = *p.TopP
+	}
+	if p.MaxTokens != nil {
+		opts.MaxTokens = *p.MaxTokens
+	}
+	return opts
+}
+
+// manifest is the JSON sidecar describing a template
+type manifest struct {
+	Description string                                 `json:"description"`
+	Params      Params                                 `json:"params"`
+	MaxChars    map[models.ModelResponseLengthEnum]int `json:"max_chars"`
+	PostProcess PostProcessConfig                      `json:"postprocess"`
+	Versions    []versionManifest                      `json:"versions"`
+}
+
+// PostProcessConfig adds to the default output checks for a template
+type PostProcessConfig struct {
+	// BannedPhrases and BannedChars extend the defaults
+	BannedPhrases []string `json:"banned_phrases,omitempty"`
+	BannedChars   []string `json:"banned_chars,omitempty"`
+	// LengthAction is "reprompt" (ask once, then trim) or "trim"
+	LengthAction string `json:"length_action,omitempty"`
+}
+
+// Length actions for outputs over a template's max_chars
+const (
+	LengthActionReprompt = "reprompt"
+	LengthActionTrim     = "trim"
+)
+
+var (
+	// defaultBannedPhrases are model self-references no template should output. They
+	// are specific enough not to match content about AI work, such as "an AI engineer".
+	defaultBannedPhrases = []string{
+		"as an AI language model", "as an AI assistant", "as a language model",
+		"I am an AI language model", "I'm an AI language model", "I am an AI assistant", "I'm an AI assistant",
+	}
+	// defaultBannedChars are characters the templates tell the model to avoid
+	defaultBannedChars = []string{"—"}
+)
+
+// versionManifest describes one version of a template. Params are merged over the
+// template-level params.
+type versionManifest struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Weight int    `json:"weight"`
+	Params Params `json:"params"`
+}
+
+const (
+	// DefaultVersion is the version name used when a manifest does not list versions
+	DefaultVersion = "v1"
+
+	// DefaultTone and DefaultLength apply when a request does not choose a style
+	DefaultTone   = models.TuneProfessional
+	DefaultLength = models.LengthMedium
+)
+
+// Template is a prompt template with one or more versions. Traffic is split between
+// versions by weight.
+type Template struct {
+	Name        models.PromptTemplateEnum
+	Description string
+	// MaxChars caps the output length per requested length, for short-form templates
+	MaxChars    map[models.ModelResponseLengthEnum]int
+	PostProcess PostProcessConfig
+	Versions    []*Version
+}
+
+// Version is a parsed template file. It defines a "user" block and optionally a
+// "system" block rendered as an extra system message.
+type Version struct {
+	Name   string
+	Weight int
+	Params Params
+
+	tmpl *template.Template
+}
+
+// RenderOptions controls which version of a template is rendered and how
+type RenderOptions struct {
+	// UserID keeps a user on the same version across requests
+	UserID string
+	// Version pins a specific version, bypassing the traffic split
+	Version string
+	// Tone and Length override the template defaults and any style in the data
+	Tone   models.ModelTuneEnum
+	Length models.ModelResponseLengthEnum
+	// Locale sets the output language and how dates and money are written
+	Locale locale.Locale
+}
+
+// RenderedPrompt is the output of rendering a template
+type RenderedPrompt struct {
+	Version string
+	Tone    models.ModelTuneEnum
+	Length  models.ModelResponseLengthEnum
+	Locale  locale.Locale
+	System  string
+	User    string
+	Params  Params
+	// MaxChars is the template's character limit for the length, 0 when it has none
+	MaxChars int
+	// PostProcess checks and cleans up the completion for this prompt
+	PostProcess postprocess.Chain
+}
+
+// styled is implemented by inputs that carry their own tone and length
+type styled interface {
+	style() (models.ModelTuneEnum, models.ModelResponseLengthEnum)
+}
+
+// Registry holds the prompt templates and supports reloading them without a restart
+type Registry struct {
+	mu        sync.RWMutex
+	dir       string
+	templates map[models.PromptTemplateEnum]*Template
+	logger    *zap.Logger
+}
+
+// NewRegistry loads and validates templates from dir, or from the embedded
+// defaults when dir is empty
+func NewRegistry(dir string, logger *zap.Logger) (*Registry, error) {
+	r := &Registry{
+		dir:    dir,
+		logger: logger,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload re-reads the template directory. On error the current templates are kept.
+func (r *Registry) Reload() error {
+	templates, err := loadTemplates(r.source())
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.mu.Unlock()
+
+	source := r.dir
+	if source == "" {
+		source = "embedded"
+	}
+	r.logger.Info("Prompt templates loaded",
+		zap.String("source", source),
+		zap.Int("count", len(templates)),
+	)
+
+	return nil
+}
+
+// Get returns the template with the given name
+func (r *Registry) Get(name models.PromptTemplateEnum) (*Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.templates[name]
+	return t, ok
+}
+
+// Names returns the loaded template names, sorted
+func (r *Registry) Names() []models.PromptTemplateEnum {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]models.PromptTemplateEnum, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// Render builds the prompt for a template from request data
+func (r *Registry) Render(name models.PromptTemplateEnum, sel RenderOptions, data map[string]interface{}, user *models.AuthUser) (*RenderedPrompt, error) {
+	if name == models.PromptNone {
+		return &RenderedPrompt{}, nil
+	}
+
+	t, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown template: %s", name)
+	}
+
+	return t.Render(sel, data, user)
+}
+
+// Render picks a version and renders it
+func (t *Template) Render(sel RenderOptions, data map[string]interface{}, user *models.AuthUser) (*RenderedPrompt, error) {
+	if err := validateStyle(sel.Tone, sel.Length); err != nil {
+		return nil, err
+	}
+
+	var v *Version
+	if sel.Version != "" {
+		if v = t.Version(sel.Version); v == nil {
+			return nil, fmt.Errorf("unknown version %s for template %s", sel.Version, t.Name)
+		}
+	} else {
+		v = t.Pick(sel.UserID)
+	}
+
+	return t.renderVersion(v, sel, data, user)
+}
+
+// Version returns the version with the given name, or nil
+func (t *Template) Version(name string) *Version {
+	for _, v := range t.Versions {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Pick chooses a version by weight. The same user ID always maps to the same
+// version while the weights are unchanged; without a user ID the pick is random.
+func (t *Template) Pick(userID string) *Version {
+	if len(t.Versions) == 1 {
+		return t.Versions[0]
+	}
+
+	total := 0
+	for _, v := range t.Versions {
+		total += v.Weight
+	}
+
+	var n int
+	if userID != "" {
+		h := fnv.New32a()
+		h.Write([]byte(string(t.Name) + ":" + userID))
+		n = int(h.Sum32() % uint32(total))
+	} else {
+		n = rand.Intn(total)
+	}
+
+	for _, v := range t.Versions {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return t.Versions[len(t.Versions)-1]
+}
+
+// Current returns the version serving the most traffic, the first listed on a tie
+func (t *Template) Current() *Version {
+	current := t.Versions[0]
+	for _, v := range t.Versions[1:] {
+		if v.Weight > current.Weight {
+			current = v
+		}
+	}
+	return current
+}
+
+// renderVersion decodes and validates the data into the template's input type, then
+// renders a version with it. Invalid data returns a *ValidationError.
+func (t *Template) renderVersion(v *Version, sel RenderOptions, data map[string]interface{}, user *models.AuthUser) (*RenderedPrompt, error) {
+	spec := templateSpecs[t.Name]
+
+	input, err := spec.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request options win over style carried in the data, then the defaults
+	tone, length := sel.Tone, sel.Length
+	if s, ok := input.(styled); ok {
+		dataTone, dataLength := s.style()
+		if tone == "" {
+			tone = dataTone
+		}
+		if length == "" {
+			length = dataLength
+		}
+	}
+
+	rendered, err := t.execute(v, spec.view(input, user, sel.Locale), tone, length)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ask for the requested language on top of the template's own system prompt
+	rendered.Locale = sel.Locale
+	if instruction := sel.Locale.Instruction(); instruction != "" {
+		rendered.System = strings.TrimSpace(rendered.System + "\n\n" + instruction)
+	}
+
+	return rendered, nil
+}
+
+// execute renders a version's blocks against a prepared view, adding the tone and
+// length guidance every template can use
+func (t *Template) execute(v *Version, view map[string]interface{}, tone models.ModelTuneEnum, length models.ModelResponseLengthEnum) (*RenderedPrompt, error) {
+	if tone == "" {
+		tone = DefaultTone
+	}
+	if length == "" {
+		length = DefaultLength
+	}
+
+	view["Tone"] = string(tone)
+	view["ToneDescription"] = ToneDescriptions[tone]
+	view["Length"] = string(length)
+	view["LengthGuideline"] = LengthGuidelines[length]
+	view["MaxChars"] = t.MaxChars[length]
+
+	rendered := &RenderedPrompt{
+		Version:     v.Name,
+		Tone:        tone,
+		Length:      length,
+		Params:      v.Params,
+		MaxChars:    t.MaxChars[length],
+		PostProcess: t.pipeline(length),
+	}
+
+	var buf bytes.Buffer
+	if err := v.tmpl.ExecuteTemplate(&buf, "user", view); err != nil {
+		return nil, fmt.Errorf("failed to render template %s@%s: %w", t.Name, v.Name, err)
+	}
+	rendered.User = buf.String()
+
+	if v.tmpl.Lookup("system") != nil {
+		buf.Reset()
+		if err := v.tmpl.ExecuteTemplate(&buf, "system", view); err != nil {
+			return nil, fmt.Errorf("failed to render template %s@%s: %w", t.Name, v.Name, err)
+		}
+		rendered.System = strings.TrimSpace(buf.String())
+	}
+
+	return rendered, nil
+}
+
+// source returns the filesystem templates are loaded from
+func (r *Registry) source() fs.FS {
+	if r.dir != "" {
+		return os.DirFS(r.dir)
+	}
+	sub, _ := fs.Sub(defaultTemplates, "templates")
+	return sub
+}
+
+// loadTemplates parses and validates every template in fsys. Each known template
+// must have a <name>.json manifest and a <name>.tmpl file defining a "user" block,
+// or one file per version listed in the manifest.
+func loadTemplates(fsys fs.FS) (map[models.PromptTemplateEnum]*Template, error) {
+	manifests, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	templates := make(map[models.PromptTemplateEnum]*Template, len(manifests))
+
+	for _, file := range manifests {
+		name := models.PromptTemplateEnum(strings.TrimSuffix(path.Base(file), ".json"))
+
+		if _, ok := templateSpecs[name]; !ok {
+			return nil, fmt.Errorf("template %s: unknown template name", name)
+		}
+
+		t, err := parseTemplate(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+
+		if err := validateTemplate(t); err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+
+		templates[name] = t
+	}
+
+	for name := range templateSpecs {
+		if _, ok := templates[name]; !ok {
+			return nil, fmt.Errorf("template %s: missing", name)
+		}
+	}
+
+	return templates, nil
+}
+
+// parseTemplate reads a template's manifest and the files for each of its versions
+func parseTemplate(fsys fs.FS, name models.PromptTemplateEnum) (*Template, error) {
+	raw, err := fs.ReadFile(fsys, string(name)+".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	versions := m.Versions
+	if len(versions) == 0 {
+		versions = []versionManifest{{Name: DefaultVersion, Weight: 100}}
+	}
+
+	t := &Template{
+		Name:        name,
+		Description: m.Description,
+		MaxChars:    m.MaxChars,
+		PostProcess: m.PostProcess,
+	}
+
+	for _, vm := range versions {
+		if vm.Name == "" {
+			return nil, fmt.Errorf("version name is required")
+		}
+		if t.Version(vm.Name) != nil {
+			return nil, fmt.Errorf("duplicate version %s", vm.Name)
+		}
+
+		file := vm.File
+		if file == "" {
+			file = string(name) + ".tmpl"
+		}
+
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: failed to read template: %w", vm.Name, err)
+		}
+
+		tmpl, err := template.New(string(name)).
+			Option("missingkey=error").
+			Funcs(templateFuncs).
+			Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("version %s: invalid template: %w", vm.Name, err)
+		}
+
+		t.Versions = append(t.Versions, &Version{
+			Name:   vm.Name,
+			Weight: vm.Weight,
+			Params: m.Params.merge(vm.Params),
+			tmpl:   tmpl,
+		})
+	}
+
+	return t, nil
+}
+
+// merge returns p with any fields set on override replaced
+func (p Params) merge(override Params) Params {
+	if override.Temperature != nil {
+		p.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		p.TopP = override.TopP
+	}
+	if override.MaxTokens != nil {
+		p.MaxTokens = override.MaxTokens
+	}
+	return p
+}
+
+// validateTemplate checks each version's structure, parameters and weight, and that
+// it renders against sample input so missing fields surface at startup instead of at
+// request time
+func validateTemplate(t *Template) error {
+	spec := templateSpecs[t.Name]
+
+	switch t.PostProcess.LengthAction {
+	case "", LengthActionReprompt, LengthActionTrim:
+	default:
+		return fmt.Errorf("postprocess: unknown length_action %s", t.PostProcess.LengthAction)
+	}
+
+	for length, max := range t.MaxChars {
+		if _, ok := LengthGuidelines[length]; !ok {
+			return fmt.Errorf("max_chars: unknown length %s", length)
+		}
+		if max <= 0 {
+			return fmt.Errorf("max_chars: %s must be positive, got %d", length, max)
+		}
+	}
+
+	total := 0
+	for _, v := range t.Versions {
+		if v.tmpl.Lookup("user") == nil {
+			return fmt.Errorf(`version %s: missing {{define "user"}} block`, v.Name)
+		}
+
+		if v.Weight < 0 {
+			return fmt.Errorf("version %s: weight must not be negative, got %d", v.Name, v.Weight)
+		}
+		total += v.Weight
+
+		if p := v.Params.Temperature; p != nil && (*p < 0 || *p > 2) {
+			return fmt.Errorf("version %s: temperature must be between 0 and 2, got %v", v.Name, *p)
+		}
+		if p := v.Params.TopP; p != nil && (*p <= 0 || *p > 1) {
+			return fmt.Errorf("version %s: top_p must be between 0 and 1, got %v", v.Name, *p)
+		}
+		if p := v.Params.MaxTokens; p != nil && *p <= 0 {
+			return fmt.Errorf("version %s: max_tokens must be positive, got %d", v.Name, *p)
+		}
+
+		for length := range LengthGuidelines {
+			if _, err := t.execute(v, spec.view(spec.sample, nil, locale.Default), DefaultTone, length); err != nil {
+				return fmt.Errorf("version %s: sample render failed: %w", v.Name, err)
+			}
+		}
+	}
+
+	if total <= 0 {
+		return fmt.Errorf("version weights must add up to more than zero")
+	}
+
+	return nil
+}
+
+// templateFuncs are the helpers available inside prompt templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"default": func(fallback, value interface{}) interface{} {
+		if s, ok := value.(string); ok && s == "" {
+			return fallback
+		}
+		if value == nil {
+			return fallback
+		}
+		return value
+	},
+}
+
+// pipeline builds the post-processing chain for a response length
+func (t *Template) pipeline(length models.ModelResponseLengthEnum) postprocess.Chain {
+	return postprocess.Chain{
+		postprocess.StripPreamble{},
+		postprocess.BannedPhrases{Phrases: append(append([]string{}, defaultBannedPhrases...), t.PostProcess.BannedPhrases...), Reprompt: true},
+		postprocess.BannedCharacters{Chars: append(append([]string{}, defaultBannedChars...), t.PostProcess.BannedChars...), Replacement: ", "},
+		postprocess.NormalizeMarkdown{},
+		postprocess.MaxLength{Max: t.MaxChars[length], Reprompt: t.PostProcess.LengthAction != LengthActionTrim},
+	}
+}
+
+// validateStyle checks a requested tone and length against the supported values
+func validateStyle(tone models.ModelTuneEnum, length models.ModelResponseLengthEnum) error {
+	verr := &ValidationError{}
+	if _, ok := ToneDescriptions[tone]; tone != "" && !ok {
+		verr.Fields = append(verr.Fields, models.FieldError{Field: "tone", Message: "must be one of: " + strings.Join(Tones(), ", ")})
+	}
+	if _, ok := LengthGuidelines[length]; length != "" && !ok {
+		verr.Fields = append(verr.Fields, models.FieldError{Field: "length", Message: "must be one of: " + strings.Join(Lengths(), ", ")})
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -0,0 +1,505 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// copyEmbeddedTemplates writes the embedded templates to a temp directory
+func copyEmbeddedTemplates(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	entries, err := defaultTemplates.ReadDir("templates")
+	if err != nil {
+		t.Fatalf("failed to read embedded templates: %v", err)
+	}
+	for _, entry := range entries {
+		data, err := defaultTemplates.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", entry.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), data, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", entry.Name(), err)
+		}
+	}
+	return dir
+}
+
+func writeTemplate(t *testing.T, dir string, name models.PromptTemplateEnum, text string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, string(name)+".tmpl"), []byte(text), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+}
+
+func TestNewRegistryLoadsEmbeddedTemplates(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	names := registry.Names()
+	if len(names) != len(templateSpecs) {
+		t.Fatalf("loaded %d templates, want %d", len(names), len(templateSpecs))
+	}
+
+	tmpl, ok := registry.Get(models.PromptTalentBio)
+	if !ok {
+		t.Fatal("talent_bio template not loaded")
+	}
+	if len(tmpl.Versions) != 1 || tmpl.Versions[0].Name != DefaultVersion {
+		t.Fatalf("talent_bio versions = %+v, want single %s", tmpl.Versions, DefaultVersion)
+	}
+	if p := tmpl.Versions[0].Params.Temperature; p == nil || *p != 0.6 {
+		t.Errorf("talent_bio temperature = %v, want 0.6", p)
+	}
+}
+
+func TestRegistryRender(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	data := map[string]interface{}{
+		"profile": map[string]interface{}{
+			"name":   "Jane Doe",
+			"title":  "Go Developer",
+			"skills": "Go, gRPC, Postgres",
+		},
+	}
+
+	rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{}, data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(rendered.User, "Jane Doe") || !strings.Contains(rendered.User, "Go, gRPC, Postgres") {
+		t.Errorf("rendered prompt missing profile data: %q", rendered.User)
+	}
+
+	opts := rendered.Params.Apply(llm.DefaultOptions())
+	if opts.Temperature != 0.6 {
+		t.Errorf("applied temperature = %v, want 0.6", opts.Temperature)
+	}
+}
+
+func TestRegistryRenderValidationErrors(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template models.PromptTemplateEnum
+		data     map[string]interface{}
+		want     []models.FieldError
+	}{
+		{
+			name:     "missing fields",
+			template: models.PromptJobDescription,
+			data:     map[string]interface{}{"job": map[string]interface{}{}},
+			want: []models.FieldError{
+				{Field: "job.title", Message: "is required"},
+				{Field: "client", Message: "is required"},
+			},
+		},
+		{
+			name:     "wrong type",
+			template: models.PromptTalentBio,
+			data: map[string]interface{}{
+				"profile": map[string]interface{}{"name": "Jane", "title": "Engineer", "rating": "five"},
+			},
+			want: []models.FieldError{{Field: "profile.rating", Message: "must be a number"}},
+		},
+		{
+			name:     "out of range",
+			template: models.PromptProposalCoverLetter,
+			data: map[string]interface{}{
+				"talent": map[string]interface{}{"name": "Jane", "title": "Engineer", "rating": 7},
+				"job":    map[string]interface{}{"title": "Go Developer", "application_deadline": "next week"},
+			},
+			want: []models.FieldError{
+				{Field: "talent.rating", Message: "must be at most 5"},
+				{Field: "job.application_deadline", Message: "must be an RFC 3339 timestamp"},
+			},
+		},
+		{
+			name:     "name from user account",
+			template: models.PromptTalentBio,
+			data: map[string]interface{}{
+				"profile": map[string]interface{}{"title": "Engineer", "user": map[string]interface{}{"first_name": "Jane"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Render(tt.template, RenderOptions{}, tt.data, nil)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Render() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Fields, tt.want) {
+				t.Errorf("Fields = %+v, want %+v", verr.Fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryReloadFromDirectory(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+
+	registry, err := NewRegistry(dir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	writeTemplate(t, dir, models.PromptTalentBio, `{{define "system"}}Be brief.{{end}}{{define "user"}}Bio for {{.FullName}}{{end}}`)
+	if err := registry.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{}, map[string]interface{}{
+		"profile": map[string]interface{}{"name": "Jane", "title": "Engineer"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if rendered.User != "Bio for Jane" {
+		t.Errorf("User = %q, want %q", rendered.User, "Bio for Jane")
+	}
+	if rendered.System != "Be brief." {
+		t.Errorf("System = %q, want %q", rendered.System, "Be brief.")
+	}
+}
+
+func TestRegistryReloadKeepsTemplatesOnError(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+
+	registry, err := NewRegistry(dir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"missing user block", `{{define "system"}}hi{{end}}`},
+		{"unknown field", `{{define "user"}}{{.NoSuchField}}{{end}}`},
+		{"syntax error", `{{define "user"}}{{.FullName{{end}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTemplate(t, dir, models.PromptTalentBio, tt.text)
+			if err := registry.Reload(); err == nil {
+				t.Fatal("Reload() expected error")
+			}
+
+			rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{}, map[string]interface{}{
+				"profile": map[string]interface{}{"name": "Jane", "title": "Engineer"},
+			}, nil)
+			if err != nil {
+				t.Fatalf("Render() after failed reload error = %v", err)
+			}
+			if !strings.Contains(rendered.User, "elite Dokoola profile copywriter") {
+				t.Errorf("expected original template to be kept, got %q", rendered.User)
+			}
+		})
+	}
+}
+
+func TestNewRegistryRejectsInvalidParams(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+
+	manifest := `{"params": {"temperature": 5}}`
+	if err := os.WriteFile(filepath.Join(dir, "talent_bio.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	if _, err := NewRegistry(dir, zap.NewNop()); err == nil {
+		t.Fatal("NewRegistry() expected error for out-of-range temperature")
+	}
+}
+
+func TestNewRegistryRequiresAllTemplates(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+
+	if err := os.Remove(filepath.Join(dir, "client_about_us.json")); err != nil {
+		t.Fatalf("failed to remove manifest: %v", err)
+	}
+
+	if _, err := NewRegistry(dir, zap.NewNop()); err == nil {
+		t.Fatal("NewRegistry() expected error for missing template")
+	}
+}
+
+// writeVersionedBio sets up talent_bio with a v1/v2 split in dir
+func writeVersionedBio(t *testing.T, dir string, weights [2]int) {
+	t.Helper()
+
+	manifest := fmt.Sprintf(`{
+		"params": {"temperature": 0.6},
+		"versions": [
+			{"name": "v1", "file": "talent_bio.tmpl", "weight": %d},
+			{"name": "v2", "file": "talent_bio.v2.tmpl", "weight": %d, "params": {"temperature": 0.9}}
+		]
+	}`, weights[0], weights[1])
+	if err := os.WriteFile(filepath.Join(dir, "talent_bio.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "talent_bio.v2.tmpl"), []byte(`{{define "user"}}v2 bio for {{.FullName}}{{end}}`), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+}
+
+func TestRegistryVersionSplit(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+	writeVersionedBio(t, dir, [2]int{50, 50})
+
+	registry, err := NewRegistry(dir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	tmpl, _ := registry.Get(models.PromptTalentBio)
+
+	// The same user always gets the same version
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		first := tmpl.Pick(userID).Name
+		for j := 0; j < 3; j++ {
+			if got := tmpl.Pick(userID).Name; got != first {
+				t.Fatalf("Pick(%q) = %s, previously %s", userID, got, first)
+			}
+		}
+		counts[first]++
+	}
+
+	if counts["v1"] < 50 || counts["v2"] < 50 {
+		t.Errorf("expected a roughly even split, got %v", counts)
+	}
+
+	// Version params are merged over the template params
+	v2 := tmpl.Version("v2")
+	if v2 == nil || v2.Params.Temperature == nil || *v2.Params.Temperature != 0.9 {
+		t.Errorf("v2 temperature not overridden: %+v", v2)
+	}
+}
+
+func TestRegistryPinnedVersion(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+	writeVersionedBio(t, dir, [2]int{100, 0})
+
+	registry, err := NewRegistry(dir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	data := map[string]interface{}{"profile": map[string]interface{}{"name": "Jane", "title": "Engineer"}}
+
+	rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{UserID: "user-1"}, data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if rendered.Version != "v1" {
+		t.Errorf("Version = %s, want v1 for a zero-weight v2", rendered.Version)
+	}
+
+	rendered, err = registry.Render(models.PromptTalentBio, RenderOptions{Version: "v2"}, data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if rendered.Version != "v2" || rendered.User != "v2 bio for Jane" {
+		t.Errorf("pinned render = %+v", rendered)
+	}
+
+	if _, err := registry.Render(models.PromptTalentBio, RenderOptions{Version: "v9"}, data, nil); err == nil {
+		t.Error("Render() expected error for unknown version")
+	}
+	tmpl, _ := registry.Get(models.PromptTalentBio)
+	if got := tmpl.Current().Name; got != "v1" {
+		t.Errorf("Current() = %s, want v1", got)
+	}
+}
+
+func TestNewRegistryRejectsZeroTotalWeight(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+	writeVersionedBio(t, dir, [2]int{0, 0})
+
+	if _, err := NewRegistry(dir, zap.NewNop()); err == nil {
+		t.Fatal("NewRegistry() expected error when all weights are zero")
+	}
+}
+
+func TestRegistryRenderStyle(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	bio := map[string]interface{}{"profile": map[string]interface{}{"name": "Jane", "title": "Engineer"}}
+	letter := map[string]interface{}{
+		"talent":   map[string]interface{}{"name": "Jane", "title": "Engineer"},
+		"job":      map[string]interface{}{"title": "Go Developer"},
+		"metadata": map[string]interface{}{"tone": "warm", "length": "short"},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{}, bio, nil)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if rendered.Tone != DefaultTone || rendered.Length != DefaultLength {
+			t.Errorf("style = %s/%s, want %s/%s", rendered.Tone, rendered.Length, DefaultTone, DefaultLength)
+		}
+		if !strings.Contains(rendered.User, ToneDescriptions[DefaultTone]) || !strings.Contains(rendered.User, "MAX 500 characters") {
+			t.Errorf("default style not applied: %q", rendered.User)
+		}
+	})
+
+	t.Run("request options", func(t *testing.T) {
+		rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{Tone: models.TuneFriendly, Length: models.LengthShort}, bio, nil)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if !strings.Contains(rendered.User, ToneDescriptions[models.TuneFriendly]) || !strings.Contains(rendered.User, "MAX 300 characters") {
+			t.Errorf("requested style not applied: %q", rendered.User)
+		}
+	})
+
+	t.Run("metadata style", func(t *testing.T) {
+		rendered, err := registry.Render(models.PromptProposalCoverLetter, RenderOptions{}, letter, nil)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if rendered.Tone != models.TuneWarm || !strings.Contains(rendered.User, LengthGuidelines[models.LengthShort]) {
+			t.Errorf("metadata style not applied: %s %q", rendered.Tone, rendered.User)
+		}
+	})
+
+	t.Run("request overrides metadata", func(t *testing.T) {
+		rendered, err := registry.Render(models.PromptProposalCoverLetter, RenderOptions{Tone: models.TuneFormal}, letter, nil)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if rendered.Tone != models.TuneFormal || rendered.Length != models.LengthShort {
+			t.Errorf("style = %s/%s, want formal/short", rendered.Tone, rendered.Length)
+		}
+	})
+
+	t.Run("unknown values", func(t *testing.T) {
+		_, err := registry.Render(models.PromptTalentBio, RenderOptions{Tone: "sarcastic", Length: "epic"}, bio, nil)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+			t.Fatalf("Render() error = %v, want tone and length field errors", err)
+		}
+
+		letter["metadata"] = map[string]interface{}{"tone": "sarcastic"}
+		_, err = registry.Render(models.PromptProposalCoverLetter, RenderOptions{}, letter, nil)
+		if !errors.As(err, &verr) || verr.Fields[0].Field != "metadata.tone" {
+			t.Fatalf("Render() error = %v, want metadata.tone field error", err)
+		}
+	})
+}
+
+func TestRegistryRenderLocale(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	data := map[string]interface{}{
+		"job": map[string]interface{}{
+			"title":                "Développeur Go",
+			"application_deadline": "2026-08-04T00:00:00Z",
+			"pricing":              map[string]interface{}{"budget": 500, "fixed_price": true},
+		},
+		"client": map[string]interface{}{"name": "Acme"},
+	}
+
+	rendered, err := registry.Render(models.PromptJobDescription, RenderOptions{Locale: locale.Locale{Language: "fr", Region: "SN"}}, data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if !strings.Contains(rendered.User, "Apply by 4 août 2026") {
+		t.Errorf("expected localized deadline, got %q", rendered.User)
+	}
+	if !strings.Contains(rendered.User, "500 FCFA fixed-price") {
+		t.Errorf("expected regional currency, got %q", rendered.User)
+	}
+	if !strings.Contains(rendered.System, "French (fr-SN)") {
+		t.Errorf("expected language instruction in system prompt, got %q", rendered.System)
+	}
+
+	rendered, err = registry.Render(models.PromptJobDescription, RenderOptions{}, data, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if rendered.System != "" || !strings.Contains(rendered.User, "$500 fixed-price") {
+		t.Errorf("expected English defaults, got system %q", rendered.System)
+	}
+}
+
+func TestRegistryPostProcess(t *testing.T) {
+	registry, err := NewRegistry("", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	bio := map[string]interface{}{"profile": map[string]interface{}{"name": "Jane", "title": "Engineer"}}
+	rendered, err := registry.Render(models.PromptTalentBio, RenderOptions{Length: models.LengthShort}, bio, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	long := "Here is your bio:\n" + strings.Repeat("I build reliable Go services. ", 20)
+
+	first := rendered.PostProcess.Run(long, false)
+	if first.Retry == "" {
+		t.Fatal("expected a re-prompt for output over the short bio limit")
+	}
+	if strings.HasPrefix(first.Text, "Here is") {
+		t.Errorf("expected preamble to be stripped, got %q", first.Text)
+	}
+
+	final := rendered.PostProcess.Run(long, true)
+	if n := len([]rune(final.Text)); n > 300 {
+		t.Errorf("expected final pass to trim to 300 characters, got %d", n)
+	}
+}
+
+func TestNewRegistryRejectsUnknownLengthAction(t *testing.T) {
+	dir := copyEmbeddedTemplates(t)
+
+	manifest := `{"max_chars": {"medium": 500}, "postprocess": {"length_action": "ignore"}}`
+	if err := os.WriteFile(filepath.Join(dir, "talent_bio.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	if _, err := NewRegistry(dir, zap.NewNop()); err == nil {
+		t.Fatal("NewRegistry() expected error for unknown length_action")
+	}
+}
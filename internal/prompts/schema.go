@@ -0,0 +1,162 @@
+package prompts
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of published input schemas
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// InputSchema returns the JSON Schema for a template's data, derived from its typed
+// input and validation rules
+func InputSchema(name models.PromptTemplateEnum) (map[string]interface{}, bool) {
+	spec, ok := templateSpecs[name]
+	if !ok {
+		return nil, false
+	}
+
+	schema := schemaFor(spec.inputType)
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = string(name)
+	return schema, true
+}
+
+// schemaFor describes a Go type as a JSON Schema
+func schemaFor(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct's JSON fields, applying their validation rules
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{}, t.NumField())
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		name, prop, isRequired, ok := fieldSchema(t.Field(i))
+		if !ok {
+			continue
+		}
+		if isRequired {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fieldSchema describes a struct field by its JSON name. ok is false for fields that
+// are not part of the JSON input.
+func fieldSchema(field reflect.StructField) (name string, prop map[string]interface{}, required, ok bool) {
+	name = strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" || !field.IsExported() {
+		return "", nil, false, false
+	}
+
+	prop = schemaFor(field.Type)
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "required":
+			required = true
+		case "gte":
+			if v, err := strconv.ParseFloat(param, 64); err == nil {
+				prop["minimum"] = v
+			}
+		case "lte":
+			if v, err := strconv.ParseFloat(param, 64); err == nil {
+				prop["maximum"] = v
+			}
+		case "oneof":
+			prop["enum"] = strings.Fields(param)
+		case "datetime":
+			prop["format"] = "date-time"
+		}
+	}
+
+	return name, prop, required, true
+}
+
+// InputFields lists a template's input fields as dotted paths in declaration order.
+// Nested objects are flattened into their fields; a field is required only when it
+// and every object above it are required.
+func InputFields(name models.PromptTemplateEnum) ([]models.TemplateField, bool) {
+	spec, ok := templateSpecs[name]
+	if !ok {
+		return nil, false
+	}
+	return appendFields(nil, spec.inputType, "", true), true
+}
+
+// appendFields adds the fields of a struct type under a path prefix
+func appendFields(fields []models.TemplateField, t reflect.Type, prefix string, required bool) []models.TemplateField {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, prop, isRequired, ok := fieldSchema(field)
+		if !ok {
+			continue
+		}
+
+		path := prefix + name
+		isRequired = required && isRequired
+
+		if ft := field.Type; ft.Kind() == reflect.Struct || (ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct) {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			fields = appendFields(fields, ft, path+".", isRequired)
+			continue
+		}
+
+		f := models.TemplateField{
+			Path:     path,
+			Type:     prop["type"].(string),
+			Required: isRequired,
+		}
+		if items, ok := prop["items"].(map[string]interface{}); ok {
+			f.Items, _ = items["type"].(string)
+		}
+		if enum, ok := prop["enum"].([]string); ok {
+			f.Enum = enum
+		}
+		f.Format, _ = prop["format"].(string)
+		if v, ok := prop["minimum"].(float64); ok {
+			f.Minimum = &v
+		}
+		if v, ok := prop["maximum"].(float64); ok {
+			f.Maximum = &v
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
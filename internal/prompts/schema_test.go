@@ -0,0 +1,96 @@
+package prompts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+func TestInputSchema(t *testing.T) {
+	schema, ok := InputSchema(models.PromptProposalCoverLetter)
+	if !ok {
+		t.Fatal("InputSchema() returned no schema for proposal_cover_letter")
+	}
+
+	if schema["$schema"] != jsonSchemaDraft || schema["title"] != "proposal_cover_letter" {
+		t.Errorf("unexpected schema header: %v, %v", schema["$schema"], schema["title"])
+	}
+
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"talent", "job"}) {
+		t.Errorf("required = %v, want [talent job]", got)
+	}
+
+	talent := schema["properties"].(map[string]interface{})["talent"].(map[string]interface{})
+	rating := talent["properties"].(map[string]interface{})["rating"].(map[string]interface{})
+	if rating["type"] != "number" || rating["minimum"] != 0.0 || rating["maximum"] != 5.0 {
+		t.Errorf("unexpected rating schema: %v", rating)
+	}
+
+	job := schema["properties"].(map[string]interface{})["job"].(map[string]interface{})
+	skills := job["properties"].(map[string]interface{})["required_skills"].(map[string]interface{})
+	if skills["type"] != "array" || skills["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("unexpected required_skills schema: %v", skills)
+	}
+}
+
+func TestInputSchemaCoversAllTemplates(t *testing.T) {
+	for name := range templateSpecs {
+		if _, ok := InputSchema(name); !ok {
+			t.Errorf("no schema for %s", name)
+		}
+	}
+
+	if _, ok := InputSchema(models.PromptNone); ok {
+		t.Error("expected no schema for the none template")
+	}
+}
+
+func TestInputFields(t *testing.T) {
+	fields, ok := InputFields(models.PromptJobDescription)
+	if !ok {
+		t.Fatal("InputFields() returned no fields for job_description")
+	}
+
+	byPath := map[string]models.TemplateField{}
+	for _, f := range fields {
+		byPath[f.Path] = f
+	}
+
+	tests := []struct {
+		path     string
+		typ      string
+		required bool
+	}{
+		{"job.title", "string", true},
+		{"client.name", "string", true},
+		{"job.description", "string", false},
+		// category is optional, so its required name is optional too
+		{"job.category.name", "string", false},
+		{"job.pricing.budget", "number", false},
+		{"job.is_third_party", "boolean", false},
+	}
+	for _, tt := range tests {
+		f, ok := byPath[tt.path]
+		if !ok {
+			t.Errorf("missing field %s", tt.path)
+			continue
+		}
+		if f.Type != tt.typ || f.Required != tt.required {
+			t.Errorf("%s = %+v, want type %s required %v", tt.path, f, tt.typ, tt.required)
+		}
+	}
+
+	if f := byPath["job.required_skills"]; f.Type != "array" || f.Items != "string" {
+		t.Errorf("unexpected required_skills field: %+v", f)
+	}
+	if f := byPath["job.application_deadline"]; f.Format != "date-time" {
+		t.Errorf("unexpected application_deadline field: %+v", f)
+	}
+	if fields[0].Path != "job.title" {
+		t.Errorf("expected fields in declaration order, first is %s", fields[0].Path)
+	}
+	if _, ok := byPath["job"]; ok {
+		t.Error("expected objects to be flattened into their fields")
+	}
+}
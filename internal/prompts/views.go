@@ -0,0 +1,258 @@
+package prompts
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+)
+
+// templateSpec ties a template to its typed input and the code that prepares its data.
+// Template wording lives in the template files; only data extraction and formatting
+// lives here.
+type templateSpec struct {
+	inputType reflect.Type
+	decode    func(data map[string]interface{}) (interface{}, error)
+	view      func(input interface{}, user *models.AuthUser, loc locale.Locale) map[string]interface{}
+	// sample is minimal valid input used to check templates render at load time
+	sample interface{}
+}
+
+// newSpec builds a templateSpec for an input type T
+func newSpec[T any](view func(input *T, user *models.AuthUser, loc locale.Locale) map[string]interface{}, sample *T) templateSpec {
+	return templateSpec{
+		inputType: reflect.TypeOf((*T)(nil)).Elem(),
+		decode: func(data map[string]interface{}) (interface{}, error) {
+			input := new(T)
+			if err := decodeInput(data, input); err != nil {
+				return nil, err
+			}
+			return input, nil
+		},
+		view: func(input interface{}, user *models.AuthUser, loc locale.Locale) map[string]interface{} {
+			return view(input.(*T), user, loc)
+		},
+		sample: sample,
+	}
+}
+
+// templateSpecs maps each template ID to its input and view
+var templateSpecs = map[models.PromptTemplateEnum]templateSpec{
+	models.PromptTalentBio: newSpec(talentBioView, &TalentBioInput{
+		Profile: &TalentProfile{Name: "Ada", Title: "Engineer"},
+	}),
+	models.PromptClientAboutUs: newSpec(clientAboutUsView, &ClientAboutUsInput{
+		Profile: &ClientProfile{Name: "Acme"},
+	}),
+	models.PromptJobDescription: newSpec(jobDescriptionView, &JobDescriptionInput{
+		Job:    &Job{Title: "Engineer"},
+		Client: &JobClient{Name: "Acme"},
+	}),
+	models.PromptProposalCoverLetter: newSpec(proposalCoverLetterView, &ProposalCoverLetterInput{
+		Talent: &ProposalTalent{Name: "Ada", Title: "Engineer"},
+		Job:    &Job{Title: "Engineer"},
+	}),
+}
+
+// talentBioView prepares the data for the talent bio template
+func talentBioView(input *TalentBioInput, user *models.AuthUser, loc locale.Locale) map[string]interface{} {
+	profile := input.Profile
+
+	skillsList := ""
+	if profile.Skills != "" {
+		parts := strings.Split(profile.Skills, ",")
+		if len(parts) > 5 {
+			parts = parts[:5]
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		skillsList = strings.Join(parts, ", ")
+	}
+
+	fullName := profile.Name
+	if profile.User != nil {
+		fullName = strings.TrimSpace(profile.User.FirstName + " " + profile.User.LastName)
+	}
+
+	rating := 0.0
+	if profile.Rating != nil {
+		rating = *profile.Rating
+	}
+
+	return map[string]interface{}{
+		"User":          user,
+		"FullName":      fullName,
+		"Title":         profile.Title,
+		"Skills":        skillsList,
+		"Rating":        rating,
+		"JobsCompleted": profile.JobsCompleted,
+	}
+}
+
+// clientAboutUsView prepares the data for the client "About Us" template
+func clientAboutUsView(input *ClientAboutUsInput, user *models.AuthUser, loc locale.Locale) map[string]interface{} {
+	profile := input.Profile
+
+	name := profile.Name
+	industry := profile.Industry
+	country := ""
+
+	if company := profile.Company; company != nil {
+		name = company.Name
+		if company.Industry != "" {
+			industry = company.Industry
+		}
+		if company.Country != nil {
+			country = company.Country.Name
+		}
+	}
+
+	if country == "" && profile.Country != nil {
+		country = profile.Country.Name
+	}
+
+	return map[string]interface{}{
+		"User":     user,
+		"Company":  name,
+		"Industry": industry,
+		"Country":  country,
+	}
+}
+
+// jobDescriptionView prepares the data for the job description template
+func jobDescriptionView(input *JobDescriptionInput, user *models.AuthUser, loc locale.Locale) map[string]interface{} {
+	job := input.Job
+
+	companyName := input.Client.Name
+	if job.IsThirdParty && job.ThirdPartyMetadata != nil && job.ThirdPartyMetadata.CompanyName != "" {
+		companyName = job.ThirdPartyMetadata.CompanyName
+	}
+
+	// Budget info
+	budgetInfo := ""
+	if pricing := job.Pricing; pricing != nil {
+		currencySymbol := loc.CurrencySymbol()
+		if pricing.Currency != nil {
+			currencySymbol = pricing.Currency.Symbol
+		}
+
+		if pricing.Budget > 0 {
+			if pricing.FixedPrice {
+				budgetInfo = loc.FormatMoney(currencySymbol, pricing.Budget) + " fixed-price"
+			} else {
+				budgetInfo = loc.FormatMoney(currencySymbol, pricing.Budget) + "/hr"
+			}
+		} else if pricing.FixedPrice {
+			budgetInfo = "Fixed-price project"
+		} else {
+			budgetInfo = "Hourly rate (budget negotiable)"
+		}
+	}
+
+	deadline := "Open until filled"
+	if job.ApplicationDeadline != "" {
+		if t, err := time.Parse(time.RFC3339, job.ApplicationDeadline); err == nil {
+			deadline = fmt.Sprintf("Apply by %s", loc.FormatDate(t))
+		}
+	}
+
+	location := job.Address
+	if job.Country != nil {
+		if job.Address != "" {
+			location = fmt.Sprintf("%s - %s", job.Country.Name, job.Address)
+		} else {
+			location = job.Country.Name
+		}
+	}
+
+	return map[string]interface{}{
+		"User":         user,
+		"Title":        job.Title,
+		"Category":     job.CategoryName,
+		"Company":      companyName,
+		"IsThirdParty": job.IsThirdParty,
+		"ClientAbout":  input.Client.About,
+		"Budget":       budgetInfo,
+		"JobType":      job.JobType,
+		"Duration":     job.EstimatedDuration,
+		"Deadline":     deadline,
+		"Skills":       strings.Join(job.RequiredSkills, " · "),
+		"Location":     location,
+	}
+}
+
+// proposalCoverLetterView prepares the data for the proposal cover letter template
+func proposalCoverLetterView(input *ProposalCoverLetterInput, user *models.AuthUser, loc locale.Locale) map[string]interface{} {
+	talent := input.Talent
+	job := input.Job
+
+	additionalNotes := ""
+	if input.Metadata != nil {
+		additionalNotes = input.Metadata.AdditionalNotes
+	}
+
+	jobCategory := ""
+	if job.Category != nil {
+		jobCategory = job.Category.Name
+	}
+
+	clientName := ""
+	if job.Client != nil {
+		clientName = job.Client.Name
+	}
+
+	companyName := clientName
+	if job.ThirdPartyMetadata != nil && job.ThirdPartyMetadata.CompanyName != "" {
+		companyName = job.ThirdPartyMetadata.CompanyName
+	}
+
+	// Badge text
+	badgeText := "verified talent"
+	if talent.Badge == "pro" {
+		badgeText = "Pro"
+	} else if talent.Badge == "star" {
+		badgeText = "Top-Rated Star"
+	}
+
+	// Rating text
+	ratingStars := "experienced talent"
+	if talent.Rating != nil && *talent.Rating > 0 {
+		ratingStars = fmt.Sprintf("rated %.1f/5", *talent.Rating)
+	}
+
+	pricing := ""
+	if talent.Pricing > 0 {
+		pricing = loc.FormatMoney(loc.CurrencySymbol(), talent.Pricing)
+	}
+
+	resumeDescription := ""
+	if input.Resume != nil {
+		resumeDescription = input.Resume.Description
+	}
+
+	return map[string]interface{}{
+		"User":            user,
+		"TalentName":      talent.Name,
+		"TalentTitle":     talent.Title,
+		"TalentBio":       talent.Bio,
+		"TalentSkills":    talent.Skills,
+		"TalentPricing":   pricing,
+		"Badge":           badgeText,
+		"Rating":          ratingStars,
+		"Resume":          resumeDescription,
+		"JobTitle":        job.Title,
+		"JobCategory":     jobCategory,
+		"JobType":         job.JobType,
+		"JobDescription":  job.Description,
+		"ExperienceLevel": job.ExperienceLevel,
+		"RequiredSkills":  strings.Join(job.RequiredSkills, ", "),
+		"Duration":        job.EstimatedDuration,
+		"ClientName":      clientName,
+		"Company":         companyName,
+		"AdditionalNotes": additionalNotes,
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// CompleteInput is a free-form completion, optionally for a user
+type CompleteInput struct {
+	// Owner is the service the generation is recorded for
+	Owner  string
+	Text   string
+	UserID string
+	Locale string
+	// Format converts the completion to it; it is returned as written when empty
+	Format models.OutputFormatEnum
+	// System is added to the system prompt, ahead of the locale instruction
+	System string
+	// Params overrides the default sampling parameters where set
+	Params prompts.Params
+	// Usage, when set, records the LLM calls
+	Usage *llm.Usage
+}
+
+// options builds the LLM options for a completion in loc
+func (in CompleteInput) options(loc locale.Locale) llm.Options {
+	opts := in.Params.Apply(llm.DefaultOptions())
+	opts.SystemPrompt = strings.TrimSpace(in.System + "\n\n" + loc.Instruction())
+	opts.Usage = in.Usage
+	return opts
+}
+
+// Complete gets a completion for free-form text, in the context of the user when one
+// is given. The generation is recorded for feedback.
+func (s *Service) Complete(ctx context.Context, in CompleteInput) (*models.CompletionData, error) {
+	loc, err := locale.Parse(in.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	s.logger.Info("Received text completion request", zap.String("locale", loc.String()))
+
+	var user *models.AuthUser
+	if in.UserID != "" {
+		user, err = s.backendClient.GetUser(in.UserID)
+		if err != nil {
+			s.logger.Warn("Failed to fetch user",
+				zap.String("user_id", in.UserID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(in.UserID, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Get LLM completion in the requested language
+	completion, _, err := s.completeInLocale(ctx, in.Text, user, in.options(loc), loc)
+	if err != nil {
+		s.logger.Error("LLM completion failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to generate completion")
+	}
+
+	if in.Format != "" {
+		completion = format.Convert(completion, in.Format)
+	}
+
+	generationID := s.generations.Record(generations.Generation{
+		Owner:        in.Owner,
+		TemplateName: models.PromptNone,
+		UserID:       in.UserID,
+		Locale:       loc.String(),
+		Format:       in.Format,
+		Completion:   completion,
+	})
+
+	s.logger.Info("Text completion successful", zap.String("generation_id", generationID))
+
+	return &models.CompletionData{
+		Completion:   completion,
+		GenerationID: generationID,
+		Locale:       loc.String(),
+		Format:       in.Format,
+	}, nil
+}
+
+// StreamComplete is Complete with the completion passed to onDelta as the model
+// produces it. Streamed text can't be taken back, so there is no language retry, and
+// Format only applies to the completion returned at the end.
+func (s *Service) StreamComplete(ctx context.Context, in CompleteInput, onDelta func(string) error) (*models.CompletionData, error) {
+	loc, err := locale.Parse(in.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	s.logger.Info("Received streaming completion request", zap.String("locale", loc.String()))
+
+	var user *models.AuthUser
+	if in.UserID != "" {
+		user, err = s.backendClient.GetUser(in.UserID)
+		if err != nil {
+			s.logger.Warn("Failed to fetch user",
+				zap.String("user_id", in.UserID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(in.UserID, err)
+		}
+	}
+
+	completion, err := s.llmClient.Stream(ctx, in.Text, user, in.options(loc), onDelta)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		s.logger.Error("LLM streaming completion failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to generate completion")
+	}
+
+	if in.Format != "" {
+		completion = format.Convert(completion, in.Format)
+	}
+
+	generationID := s.generations.Record(generations.Generation{
+		Owner:        in.Owner,
+		TemplateName: models.PromptNone,
+		UserID:       in.UserID,
+		Locale:       loc.String(),
+		Format:       in.Format,
+		Completion:   completion,
+	})
+
+	s.logger.Info("Streaming completion successful", zap.String("generation_id", generationID))
+
+	return &models.CompletionData{
+		Completion:   completion,
+		GenerationID: generationID,
+		Locale:       loc.String(),
+		Format:       in.Format,
+	}, nil
+}
+
+// completeInLocale gets a completion and, for non-default locales, checks it came back
+// in the requested language. On a mismatch it retries once with a firmer instruction.
+// It returns the number of LLM calls made.
+func (s *Service) completeInLocale(ctx context.Context, prompt string, user *models.AuthUser, opts llm.Options, loc locale.Locale) (string, int, error) {
+	completion, err := s.llmClient.CompleteWithOptions(ctx, prompt, user, opts)
+	if err != nil {
+		return completion, 1, err
+	}
+
+	completion, retries := s.retryInLocale(ctx, prompt, completion, user, opts, loc)
+	return completion, 1 + retries, nil
+}
+
+// retryInLocale asks once more, for non-default locales, when a completion came back
+// in another language. The completion is sent back so the model can translate it. It
+// returns the completion to use and the number of LLM calls made.
+func (s *Service) retryInLocale(ctx context.Context, prompt, completion string, user *models.AuthUser, opts llm.Options, loc locale.Locale) (string, int) {
+	if loc.IsDefault() || loc.Matches(completion) {
+		return completion, 0
+	}
+
+	s.logger.Warn("Completion not in requested language, retrying once",
+		zap.String("locale", loc.String()),
+	)
+
+	retry, err := s.revise(ctx, prompt, completion, loc.RetryInstruction(), user, opts)
+	if err != nil {
+		s.logger.Warn("Language retry failed, returning first completion",
+			zap.String("locale", loc.String()),
+			zap.Error(err),
+		)
+		return completion, 1
+	}
+
+	if !loc.Matches(retry) {
+		s.logger.Warn("Completion still not in requested language after retry",
+			zap.String("locale", loc.String()),
+		)
+	}
+
+	return retry, 1
+}
+
+// revise asks the model to revise its answer to a prompt. The answer is sent back as
+// the assistant's turn, followed by the instruction, so the model can rewrite the
+// answer it gave instead of starting over.
+func (s *Service) revise(ctx context.Context, prompt, answer, instruction string, user *models.AuthUser, opts llm.Options) (string, error) {
+	return s.llmClient.CompleteConversation(ctx, []llm.Message{
+		{Role: llm.RoleUser, Content: prompt},
+		{Role: llm.RoleAssistant, Content: answer},
+		{Role: llm.RoleUser, Content: instruction},
+	}, user, opts)
+}
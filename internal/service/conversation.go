@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/conversations"
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// summarizePrompt asks for a summary of conversation turns that no longer fit the
+// token window
+const summarizePrompt = `Summarize the conversation below so it can replace the messages in later turns. Keep every fact, requirement and preference the user stated, and the current state of any text being written, including its latest wording where it matters. Write plain prose, no more than 200 words, and nothing but the summary.`
+
+// CreateConversationInput starts a conversation, optionally for a user
+type CreateConversationInput struct {
+	// Owner is the service the conversation belongs to; only it can use the conversation
+	Owner  string
+	UserID string
+	Locale string
+}
+
+// CreateConversation starts an empty conversation. Every turn is answered in the
+// conversation's locale and in the context of its user, if it has one.
+func (s *Service) CreateConversation(ctx context.Context, in CreateConversationInput) (*models.Conversation, error) {
+	loc, err := locale.Parse(in.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	// Check the user exists now rather than on the first message
+	if in.UserID != "" {
+		if _, err := s.backendClient.GetUser(in.UserID); err != nil {
+			s.logger.Warn("Failed to fetch user",
+				zap.String("user_id", in.UserID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(in.UserID, err)
+		}
+	}
+
+	conv := s.conversations.Create(in.Owner, in.UserID, loc.String())
+
+	s.logger.Info("Conversation created",
+		zap.String("conversation_id", conv.ID),
+		zap.String("locale", conv.Locale),
+	)
+
+	return &conv, nil
+}
+
+// GetConversation returns a conversation owned by the given service
+func (s *Service) GetConversation(owner, id string) (*models.Conversation, error) {
+	conv, err := s.conversations.Get(owner, id)
+	if err != nil {
+		return nil, conversationNotFound(id, err)
+	}
+	return &conv, nil
+}
+
+// DeleteConversation ends a conversation owned by the given service
+func (s *Service) DeleteConversation(owner, id string) (*models.Conversation, error) {
+	conv, err := s.conversations.Delete(owner, id)
+	if err != nil {
+		return nil, conversationNotFound(id, err)
+	}
+
+	s.logger.Info("Conversation deleted", zap.String("conversation_id", id))
+
+	return &conv, nil
+}
+
+// SendMessageInput is a user's next message in a conversation
+type SendMessageInput struct {
+	Owner          string
+	ConversationID string
+	Text           string
+	// Format converts the reply's completion to it; it is returned as written when empty
+	Format models.OutputFormatEnum
+	// Usage, when set, records the LLM calls, including any summarization
+	Usage *llm.Usage
+}
+
+// SendMessage answers a message with the conversation's full history. When the
+// history outgrows the token window, the oldest messages are summarized first. The
+// message and reply are only stored once the reply succeeds, so a failed turn can be
+// sent again.
+func (s *Service) SendMessage(ctx context.Context, in SendMessageInput) (*models.ConversationReply, error) {
+	conv, err := s.conversations.Get(in.Owner, in.ConversationID)
+	if err != nil {
+		return nil, conversationNotFound(in.ConversationID, err)
+	}
+
+	loc, err := locale.Parse(conv.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	s.logger.Info("Received conversation message",
+		zap.String("conversation_id", conv.ID),
+		zap.Int("messages", len(conv.Messages)),
+	)
+
+	var user *models.AuthUser
+	if conv.UserID != "" {
+		user, err = s.backendClient.GetUser(conv.UserID)
+		if err != nil {
+			s.logger.Warn("Failed to fetch user",
+				zap.String("user_id", conv.UserID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(conv.UserID, err)
+		}
+	}
+
+	conv.Messages = append(conv.Messages, models.ConversationMessage{
+		Role:      models.ConversationRoleUser,
+		Content:   in.Text,
+		CreatedAt: time.Now(),
+	})
+
+	summarized := s.fitWindow(ctx, &conv, in.Usage)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opts := llm.DefaultOptions()
+	opts.SystemPrompt = loc.Instruction()
+	if conv.Summary != "" {
+		opts.SystemPrompt = strings.TrimSpace(opts.SystemPrompt + "\n\nSummary of the earlier conversation:\n" + conv.Summary)
+	}
+	opts.Usage = in.Usage
+
+	completion, err := s.llmClient.CompleteConversation(ctx, turns(conv.Messages), user, opts)
+	if err != nil {
+		s.logger.Error("LLM conversation completion failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to generate reply")
+	}
+
+	reply := models.ConversationMessage{
+		Role:      models.ConversationRoleAssistant,
+		Content:   completion,
+		CreatedAt: time.Now(),
+	}
+	conv.Messages = append(conv.Messages, reply)
+
+	if _, err := s.conversations.Save(in.Owner, conv); err != nil {
+		if errors.Is(err, conversations.ErrConflict) {
+			return nil, newError(models.ErrConflict, err, "Conversation %s was updated by another message; please send again", conv.ID)
+		}
+		return nil, conversationNotFound(conv.ID, err)
+	}
+
+	if in.Format != "" {
+		completion = format.Convert(completion, in.Format)
+	}
+
+	generationID := s.generations.Record(generations.Generation{
+		Owner:        in.Owner,
+		TemplateName: models.PromptNone,
+		UserID:       conv.UserID,
+		Locale:       conv.Locale,
+		Format:       in.Format,
+		Completion:   completion,
+	})
+
+	s.logger.Info("Conversation reply successful",
+		zap.String("conversation_id", conv.ID),
+		zap.String("generation_id", generationID),
+		zap.Bool("summarized", summarized),
+	)
+
+	return &models.ConversationReply{
+		ConversationID: conv.ID,
+		Message:        reply,
+		Completion:     completion,
+		GenerationID:   generationID,
+		Locale:         conv.Locale,
+		Format:         in.Format,
+		Summarized:     summarized,
+	}, nil
+}
+
+// fitWindow folds the oldest half of the messages into the conversation's summary
+// until the history fits the token window, always keeping the latest message. If
+// summarizing fails the messages are dropped, so the turn can still be answered.
+// It reports whether any messages were folded.
+func (s *Service) fitWindow(ctx context.Context, conv *models.Conversation, usage *llm.Usage) bool {
+	window := s.conversations.WindowTokens()
+	summarized := false
+
+	for len(conv.Messages) > 1 && historyTokens(*conv) > window {
+		n := len(conv.Messages) / 2
+		folded := conv.Messages[:n]
+
+		summary, err := s.summarize(ctx, conv.Summary, folded, usage)
+		if err != nil {
+			s.logger.Warn("Failed to summarize conversation, dropping oldest messages",
+				zap.String("conversation_id", conv.ID),
+				zap.Int("dropped", n),
+				zap.Error(err),
+			)
+		} else {
+			conv.Summary = summary
+		}
+
+		conv.Messages = append([]models.ConversationMessage{}, conv.Messages[n:]...)
+		conv.SummarizedMessages += n
+		summarized = true
+	}
+
+	return summarized
+}
+
+// summarize condenses messages, and the summary of the messages before them, into a
+// new summary
+func (s *Service) summarize(ctx context.Context, previous string, messages []models.ConversationMessage, usage *llm.Usage) (string, error) {
+	var b strings.Builder
+	b.WriteString(summarizePrompt)
+	if previous != "" {
+		b.WriteString("\n\nSummary so far:\n")
+		b.WriteString(previous)
+	}
+	b.WriteString("\n\nConversation:\n")
+	for _, m := range messages {
+		fmt.Fprintf(&b, "%s: %s\n", m.Role, m.Content)
+	}
+
+	opts := llm.DefaultOptions()
+	opts.Usage = usage
+
+	summary, err := s.llmClient.CompleteWithOptions(ctx, b.String(), nil, opts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// historyTokens estimates the prompt tokens of a conversation's summary and messages
+func historyTokens(conv models.Conversation) int {
+	tokens := llm.EstimateTokens(turns(conv.Messages))
+	if conv.Summary != "" {
+		tokens += llm.EstimateTokens([]llm.Message{{Role: "system", Content: conv.Summary}})
+	}
+	return tokens
+}
+
+// turns converts conversation messages to LLM messages
+func turns(messages []models.ConversationMessage) []llm.Message {
+	result := make([]llm.Message, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, llm.Message{Role: string(m.Role), Content: m.Content})
+	}
+	return result
+}
+
+// conversationNotFound reports a conversation that is unknown, expired or owned by
+// another service
+func conversationNotFound(id string, err error) *Error {
+	return newError(models.ErrNotFound, err, "Conversation not found: %s", id)
+}
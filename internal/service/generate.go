@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/postprocess"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// GenerateInput is a template generation for a user
+type GenerateInput struct {
+	// Owner is the service the generation is recorded for
+	Owner   string
+	Request models.PromptGenerationRequest
+	UserID  string
+	// Usage, when set, records the LLM calls
+	Usage *llm.Usage
+}
+
+// GenerateFromTemplate renders a template with the user's context, completes it and
+// runs the template's post-processing. The generation is recorded for feedback.
+func (s *Service) GenerateFromTemplate(ctx context.Context, in GenerateInput) (*models.CompletionData, error) {
+	req := in.Request
+
+	loc, err := locale.Parse(req.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	s.logger.Info("Received prompt generation request",
+		zap.String("template", string(req.TemplateName)),
+		zap.String("locale", loc.String()),
+	)
+
+	user, err := s.backendClient.GetUser(in.UserID)
+	if err != nil {
+		s.logger.Warn("Failed to fetch user",
+			zap.String("user_id", in.UserID),
+			zap.Error(err),
+		)
+		return nil, userNotFound(in.UserID, err)
+	}
+
+	// Pick the template version, keeping each user on the same side of an experiment
+	rendered, err := s.registry.Render(req.TemplateName, renderOptions(req, in.UserID, loc), req.Data, user)
+	if err != nil {
+		s.logger.Warn("Failed to build prompt", zap.String("template", string(req.TemplateName)), zap.Error(err))
+		return nil, renderFailed(err)
+	}
+
+	// The "none" template has nothing to generate
+	if req.TemplateName == models.PromptNone {
+		return &models.CompletionData{}, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Prompt built successfully",
+		zap.String("template_version", rendered.Version),
+		zap.String("tone", string(rendered.Tone)),
+		zap.String("length", string(rendered.Length)),
+		zap.Int("prompt_length", len(rendered.User)),
+	)
+
+	// Get LLM completion with the template's generation parameters
+	opts := rendered.Params.Apply(llm.DefaultOptions())
+	opts.SystemPrompt = rendered.System
+	opts.Usage = in.Usage
+
+	completion, metadata, err := s.completeRendered(ctx, rendered, user, opts, loc)
+	if err != nil {
+		s.logger.Error("LLM completion failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to generate completion")
+	}
+
+	// Templates ask for markdown; convert to what the caller's editor expects, or
+	// return it as written when no format is requested
+	outputFormat := req.Format
+	if outputFormat != "" {
+		completion = s.convertOutput(rendered, completion, outputFormat, metadata)
+	} else {
+		outputFormat = format.Default
+	}
+
+	generationID := s.generations.Record(generations.Generation{
+		Owner:        in.Owner,
+		TemplateName: req.TemplateName,
+		Version:      rendered.Version,
+		UserID:       in.UserID,
+		Tone:         rendered.Tone,
+		Length:       rendered.Length,
+		Locale:       loc.String(),
+		Format:       outputFormat,
+		Completion:   completion,
+	})
+
+	s.logger.Info("Prompt generation successful",
+		zap.String("template", string(req.TemplateName)),
+		zap.String("template_version", rendered.Version),
+		zap.String("generation_id", generationID),
+	)
+
+	return &models.CompletionData{
+		Completion:      completion,
+		GenerationID:    generationID,
+		TemplateVersion: rendered.Version,
+		Locale:          loc.String(),
+		Format:          outputFormat,
+		Metadata:        metadata,
+	}, nil
+}
+
+// RenderTemplate builds the exact LLM request a generation would send, without
+// calling the LLM. The user context is only included when a user is given.
+func (s *Service) RenderTemplate(ctx context.Context, req models.PromptGenerationRequest, userID string) (*models.RenderedPromptData, error) {
+	loc, err := locale.Parse(req.Locale)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	var user *models.AuthUser
+	if userID != "" {
+		user, err = s.backendClient.GetUser(userID)
+		if err != nil {
+			s.logger.Warn("Failed to fetch user for prompt preview",
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(userID, err)
+		}
+	}
+
+	rendered, err := s.registry.Render(req.TemplateName, renderOptions(req, userID, loc), req.Data, user)
+	if err != nil {
+		return nil, renderFailed(err)
+	}
+
+	opts := rendered.Params.Apply(llm.DefaultOptions())
+	opts.SystemPrompt = rendered.System
+	request := s.llmClient.Request(rendered.User, user, opts)
+
+	messages := make([]models.ChatMessage, len(request.Messages))
+	for i, m := range request.Messages {
+		messages[i] = models.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+
+	s.logger.Info("Prompt rendered for preview",
+		zap.String("template", string(req.TemplateName)),
+		zap.String("template_version", rendered.Version),
+	)
+
+	return &models.RenderedPromptData{
+		TemplateName:    req.TemplateName,
+		TemplateVersion: rendered.Version,
+		Tone:            rendered.Tone,
+		Length:          rendered.Length,
+		Locale:          loc.String(),
+		Model:           request.Model,
+		Params: models.GenerationParams{
+			MaxTokens:   request.MaxTokens,
+			Temperature: request.Temperature,
+			TopP:        request.TopP,
+		},
+		Messages:        messages,
+		EstimatedTokens: llm.EstimateTokens(request.Messages),
+	}, nil
+}
+
+// renderOptions selects the template version and style for a request
+func renderOptions(req models.PromptGenerationRequest, userID string, loc locale.Locale) prompts.RenderOptions {
+	return prompts.RenderOptions{
+		UserID:  userID,
+		Version: req.TemplateVersion,
+		Tone:    req.Tone,
+		Length:  req.Length,
+		Locale:  loc,
+	}
+}
+
+// convertOutput converts a post-processed completion to a format. Escaping can make
+// markdown longer than the template's max_chars allows, so converted markdown and
+// text are trimmed to it again; HTML tags don't count towards it.
+func (s *Service) convertOutput(rendered *prompts.RenderedPrompt, completion string, to models.OutputFormatEnum, metadata *models.GenerationMetadata) string {
+	converted := format.Convert(completion, to)
+	if to == models.FormatHTML || rendered.MaxChars <= 0 {
+		return converted
+	}
+
+	length := utf8.RuneCountInString(converted)
+	if length <= rendered.MaxChars {
+		return converted
+	}
+
+	s.logger.Info("Converted completion exceeds the character limit, trimming",
+		zap.String("template_version", rendered.Version),
+		zap.String("format", string(to)),
+	)
+	metadata.ConstraintViolations = append(metadata.ConstraintViolations, models.ConstraintViolation{
+		Rule:    postprocess.RuleMaxLength,
+		Message: fmt.Sprintf("trimmed from %d to at most %d characters after %s conversion", length, rendered.MaxChars, to),
+		Fixed:   true,
+	})
+	return postprocess.Truncate(converted, rendered.MaxChars)
+}
+
+// completeRendered gets a completion for a rendered prompt and runs the template's
+// post-processing chain. If a check asks for a retry (e.g. the output is too long)
+// the model is asked once more to revise its answer, and the final pass fixes
+// whatever is left.
+func (s *Service) completeRendered(ctx context.Context, rendered *prompts.RenderedPrompt, user *models.AuthUser, opts llm.Options, loc locale.Locale) (string, *models.GenerationMetadata, error) {
+	completion, attempts, err := s.completeInLocale(ctx, rendered.User, user, opts, loc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	result := rendered.PostProcess.Run(completion, false)
+	violations := result.Violations
+
+	if result.Retry != "" {
+		s.logger.Info("Completion broke an output constraint, re-prompting",
+			zap.String("template_version", rendered.Version),
+			zap.String("instruction", result.Retry),
+		)
+
+		retried, err := s.revise(ctx, rendered.User, result.Text, result.Retry, user, opts)
+		attempts++
+		if err != nil {
+			s.logger.Warn("Constraint re-prompt failed, fixing first completion", zap.Error(err))
+			retried = result.Text
+		} else {
+			var retries int
+			retried, retries = s.retryInLocale(ctx, rendered.User, retried, user, opts, loc)
+			attempts += retries
+		}
+
+		result = rendered.PostProcess.Run(retried, true)
+		violations = append(violations, result.Violations...)
+	}
+
+	if len(violations) > 0 {
+		s.logger.Info("Output constraint violations",
+			zap.String("template_version", rendered.Version),
+			zap.Int("count", len(violations)),
+		)
+	}
+
+	if violations == nil {
+		violations = []models.ConstraintViolation{}
+	}
+
+	return result.Text, &models.GenerationMetadata{
+		Attempts:             attempts,
+		ConstraintViolations: violations,
+	}, nil
+}
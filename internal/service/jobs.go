@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// CategorizeInput is a batch of jobs to categorize
+type CategorizeInput struct {
+	Jobs []models.JobData
+	// Categories, when set, are used instead of fetching them from the backend, so
+	// callers categorizing in several batches fetch them once
+	Categories []models.JobCategory
+	// NoCache skips the LLM response cache
+	NoCache bool
+	// Usage, when set, records the LLM calls
+	Usage *llm.Usage
+	// Progress, when set, is called with the number of jobs done after each job
+	Progress func(completed int)
+}
+
+// DescribeInput is a batch of jobs to write descriptions for
+type DescribeInput struct {
+	Jobs []models.JobDescribeRequest
+	// Format converts descriptions to it, and short descriptions to plain text.
+	// Descriptions are returned as written when it is empty.
+	Format models.OutputFormatEnum
+	// Usage, when set, records the LLM call
+	Usage *llm.Usage
+}
+
+const categorizePrompt = `You are a job categorization expert for Dokoola platform.
+
+Analyze this job posting and select the SINGLE MOST RELEVANT category from the list below.
+
+Job Description:
+"""%s"""
+
+Available Categories:
+%s
+
+Instructions:
+- Return ONLY the category slug (e.g., "web-development")
+- Choose the most specific matching category
+- If no exact match, choose the closest parent category
+- Return only the slug, nothing else
+
+Category slug:`
+
+const describePrompt = `You are a job description expert for Dokoola platform.
+
+Analyze these job postings and provide a detailed description and a short description for each.
+
+Data
+"""%s"""
+
+Instructions:
+- Return a JSON array with one object per job, in the same order, each with two fields: "description" and "short_description"
+- The "description" should be a detailed summary of the job posting
+- The "short_description" a summary of the job (400 characters max) not a rich text, use plaintext only
+- Do not include any other text, only the JSON array
+
+- Note: If description is already matured and efficient enough like (>500chars), you don't need to add any details,
+just generate the short description, Otherwise, generate both description and short description.
+
+- Note: Your generation should be in first person, like this is written by the job poster, not a third party description of the job.
+So avoid using third person perspective in the description.
+
+Job descriptions:`
+
+// JobCategories fetches the job categories from the backend
+func (s *Service) JobCategories() ([]models.JobCategory, error) {
+	categories, err := s.backendClient.GetCategories()
+	if err != nil {
+		s.logger.Error("Failed to fetch categories", zap.Error(err))
+		return nil, newError(models.ErrUpstreamError, err, "Failed to fetch categories: %s", err.Error())
+	}
+	return categories, nil
+}
+
+// CategorizeJobs assigns each job the slug of its most relevant category. Jobs the
+// LLM fails on get an empty category; processing stops early if ctx is cancelled.
+// Categorization runs at temperature 0, so completions are cached unless NoCache is set.
+func (s *Service) CategorizeJobs(ctx context.Context, in CategorizeInput) ([]models.JobResponseData, error) {
+	categories := in.Categories
+	if categories == nil {
+		var err error
+		if categories, err = s.JobCategories(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Build categories description for prompt
+	categoriesDesc := buildCategoriesDescription(categories)
+
+	opts := llm.DefaultOptions()
+	opts.Temperature = 0
+	opts.Cache = !in.NoCache
+	opts.Usage = in.Usage
+
+	results := make([]models.JobResponseData, 0, len(in.Jobs))
+
+	for _, job := range in.Jobs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		s.logger.Debug("Processing job", zap.String("public_id", job.PublicID))
+
+		prompt := fmt.Sprintf(categorizePrompt, job.Description, categoriesDesc)
+		completion, err := s.llmClient.CompleteWithOptions(ctx, prompt, nil, opts)
+		if err != nil {
+			s.logger.Error("LLM completion failed", zap.String("public_id", job.PublicID), zap.Error(err))
+			// Use default category or empty string
+			results = append(results, models.JobResponseData{
+				PublicID: job.PublicID,
+				Category: "",
+			})
+			reportProgress(in.Progress, len(results))
+			continue
+		}
+
+		// Extract category slug from response
+		categorySlug := strings.TrimSpace(completion)
+		categorySlug = strings.Trim(categorySlug, "\"'`")
+
+		s.logger.Debug("Job categorized",
+			zap.String("public_id", job.PublicID),
+			zap.String("category", categorySlug),
+		)
+
+		results = append(results, models.JobResponseData{
+			PublicID: job.PublicID,
+			Category: categorySlug,
+		})
+		reportProgress(in.Progress, len(results))
+	}
+
+	s.logger.Info("Job categorization completed", zap.Int("processed", len(results)))
+
+	return results, nil
+}
+
+// DescribeJobs generates detailed and short descriptions for a batch of jobs
+func (s *Service) DescribeJobs(ctx context.Context, in DescribeInput) ([]models.JobDescription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(in.Jobs, "", "  ")
+	if err != nil {
+		return nil, newError(models.ErrInternal, err, "Failed to encode jobs: %s", err.Error())
+	}
+
+	opts := llm.DefaultOptions()
+	opts.Usage = in.Usage
+
+	completion, err := s.llmClient.CompleteWithOptions(ctx, fmt.Sprintf(describePrompt, data), nil, opts)
+	if err != nil {
+		s.logger.Error("LLM completion failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to generate description")
+	}
+
+	// Parse the JSON response
+	var payload []models.JobDescription
+	if err := json.Unmarshal([]byte(completion), &payload); err != nil {
+		s.logger.Error("Failed to parse LLM response", zap.String("response", completion), zap.Error(err))
+		return nil, newError(models.ErrUpstreamError, err, "Failed to generate description: failed to parse description response: %s", err.Error())
+	}
+
+	if in.Format != "" {
+		for i := range payload {
+			payload[i].Description = format.Convert(payload[i].Description, in.Format)
+			payload[i].ShortDescription = format.Convert(payload[i].ShortDescription, models.FormatText)
+		}
+	}
+
+	return payload, nil
+}
+
+// buildCategoriesDescription creates a formatted string of categories
+func buildCategoriesDescription(categories []models.JobCategory) string {
+	var sb strings.Builder
+
+	for i, cat := range categories {
+		if cat.ParentSlug != nil {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s) - Child of: %s\n",
+				i+1, cat.Slug, cat.Description, *cat.ParentSlug))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s)\n",
+				i+1, cat.Slug, cat.Description))
+		}
+	}
+
+	return sb.String()
+}
+
+// reportProgress calls the progress callback if one was given
+func reportProgress(progress func(int), completed int) {
+	if progress != nil {
+		progress(completed)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dokoola/llm-go/internal/format"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/locale"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// RefineInput is a targeted edit of generated content
+type RefineInput struct {
+	// Owner is the service refining the content; it must own the generation refined
+	Owner   string
+	Request models.RefineRequest
+	// UserID overrides the user of the original generation
+	UserID string
+	// Usage, when set, records the LLM calls
+	Usage *llm.Usage
+}
+
+// Refine revises previously generated content following an instruction, instead of
+// generating it again. The revision keeps the original's template version and goes
+// through the template's post-processing, so its output constraints still hold. A
+// generation's tone, length, locale and format apply unless the request sets them. It
+// is recorded as a new generation.
+func (s *Service) Refine(ctx context.Context, in RefineInput) (*models.CompletionData, error) {
+	req := in.Request
+
+	templateName, version, userID, original := req.TemplateName, req.TemplateVersion, in.UserID, req.Text
+	tone, length, localeName, outputFormat := req.Tone, req.Length, req.Locale, req.Format
+	if req.GenerationID != "" {
+		gen, err := s.generations.Get(in.Owner, req.GenerationID)
+		if err != nil {
+			return nil, newError(models.ErrNotFound, err, "Generation not found: %s", req.GenerationID)
+		}
+		if templateName != "" && templateName != gen.TemplateName {
+			return nil, invalidInput(fmt.Errorf("template_name %s does not match generation %s, which used %s", templateName, gen.ID, gen.TemplateName))
+		}
+
+		templateName = gen.TemplateName
+		if version == "" {
+			version = gen.Version
+		}
+		if userID == "" {
+			userID = gen.UserID
+		}
+		if original == "" {
+			original = gen.Completion
+		}
+		if tone == "" {
+			tone = gen.Tone
+		}
+		if length == "" {
+			length = gen.Length
+		}
+		if localeName == "" {
+			localeName = gen.Locale
+		}
+		if outputFormat == "" {
+			outputFormat = gen.Format
+		}
+	}
+
+	loc, err := locale.Parse(localeName)
+	if err != nil {
+		return nil, invalidInput(err)
+	}
+
+	s.logger.Info("Received refinement request",
+		zap.String("template", string(templateName)),
+		zap.String("generation_id", req.GenerationID),
+		zap.String("locale", loc.String()),
+	)
+
+	var user *models.AuthUser
+	if userID != "" {
+		user, err = s.backendClient.GetUser(userID)
+		if err != nil {
+			s.logger.Warn("Failed to fetch user",
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+			return nil, userNotFound(userID, err)
+		}
+	}
+
+	// Generations are stored in the format they were returned in; the model edits markdown
+	original = format.Convert(original, models.FormatMarkdown)
+
+	sel := prompts.RenderOptions{
+		UserID:  userID,
+		Version: version,
+		Tone:    tone,
+		Length:  length,
+		Locale:  loc,
+	}
+	rendered, err := s.registry.RenderRefinement(templateName, sel, original, req.Instruction)
+	if err != nil {
+		s.logger.Warn("Failed to build refinement prompt", zap.String("template", string(templateName)), zap.Error(err))
+		return nil, renderFailed(err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opts := rendered.Params.Apply(llm.DefaultOptions())
+	opts.SystemPrompt = rendered.System
+	opts.Usage = in.Usage
+
+	completion, metadata, err := s.completeRendered(ctx, rendered, user, opts, loc)
+	if err != nil {
+		s.logger.Error("LLM refinement failed", zap.Error(err))
+		return nil, completionFailed(err, "Failed to refine content")
+	}
+
+	if outputFormat == "" {
+		outputFormat = format.Default
+	}
+	completion = s.convertOutput(rendered, completion, outputFormat, metadata)
+
+	generationID := s.generations.Record(generations.Generation{
+		Owner:        in.Owner,
+		TemplateName: templateName,
+		Version:      rendered.Version,
+		UserID:       userID,
+		Tone:         rendered.Tone,
+		Length:       rendered.Length,
+		Locale:       loc.String(),
+		Format:       outputFormat,
+		Completion:   completion,
+	})
+
+	s.logger.Info("Refinement successful",
+		zap.String("template", string(templateName)),
+		zap.String("template_version", rendered.Version),
+		zap.String("generation_id", generationID),
+	)
+
+	return &models.CompletionData{
+		Completion:      completion,
+		GenerationID:    generationID,
+		TemplateVersion: rendered.Version,
+		Locale:          loc.String(),
+		Format:          outputFormat,
+		Metadata:        metadata,
+	}, nil
+}
@@ -0,0 +1,102 @@
+// Package service holds the business logic of the LLM service: building prompts,
+// calling the LLM and parsing what comes back. It knows nothing about HTTP, so
+// handlers, async tasks and the command line share it.
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/conversations"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// Service generates content for Dokoola users
+type Service struct {
+	llmClient     *llm.Client
+	backendClient *clients.BackendClient
+	registry      *prompts.Registry
+	generations   *generations.Store
+	conversations *conversations.Store
+	logger        *zap.Logger
+}
+
+// New creates a service. The registry and the generation and conversation stores
+// are only needed for template generation, for recording generations and for
+// conversations.
+func New(llmClient *llm.Client, backendClient *clients.BackendClient, registry *prompts.Registry, generationStore *generations.Store, conversationStore *conversations.Store, logger *zap.Logger) *Service {
+	return &Service{
+		llmClient:     llmClient,
+		backendClient: backendClient,
+		registry:      registry,
+		generations:   generationStore,
+		conversations: conversationStore,
+		logger:        logger,
+	}
+}
+
+// Error is a failure the caller can act on, with the code transports report it with
+type Error struct {
+	Code        models.ErrorCodeEnum
+	Message     string
+	FieldErrors []models.FieldError
+	Err         error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps err with a code and message
+func newError(code models.ErrorCodeEnum, err error, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// invalidInput reports a request that failed validation
+func invalidInput(err error) *Error {
+	return newError(models.ErrInvalidRequest, err, "Invalid request: %s", err.Error())
+}
+
+// userNotFound reports a user the backend couldn't provide
+func userNotFound(userID string, err error) *Error {
+	return newError(models.ErrUserNotFound, err, "User not found: %s", userID)
+}
+
+// renderFailed reports a template that failed to render, listing the invalid
+// fields when the data failed validation
+func renderFailed(err error) *Error {
+	var verr *prompts.ValidationError
+	if errors.As(err, &verr) {
+		e := newError(models.ErrTemplateInvalid, err, "Invalid data: %s", err.Error())
+		e.FieldErrors = verr.Fields
+		return e
+	}
+	return newError(models.ErrTemplateInvalid, err, "Failed to build prompt: %s", err.Error())
+}
+
+// completionFailed reports a failed LLM call, e.g. "Failed to generate completion".
+// Upstream rate limits get their own code so callers back off.
+func completionFailed(err error, what string) *Error {
+	if errors.Is(err, llm.ErrRateLimited) {
+		return newError(models.ErrUpstreamRateLimited, err, "Upstream LLM service overloaded; please try again later")
+	}
+	return newError(models.ErrUpstreamError, err, "%s: %s", what, err.Error())
+}
+
+// ErrorCode returns the code of a service error, or internal_error for anything else
+func ErrorCode(err error) models.ErrorCodeEnum {
+	var serr *Error
+	if errors.As(err, &serr) {
+		return serr.Code
+	}
+	return models.ErrInternal
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/clients"
+	"github.com/dokoola/llm-go/internal/clients/backendtest"
+	"github.com/dokoola/llm-go/internal/conversations"
+	"github.com/dokoola/llm-go/internal/generations"
+	"github.com/dokoola/llm-go/internal/llm"
+	"github.com/dokoola/llm-go/internal/llm/llmtest"
+	"github.com/dokoola/llm-go/internal/models"
+	"github.com/dokoola/llm-go/internal/prompts"
+	"go.uber.org/zap"
+)
+
+// conversationWindow is small enough for a few turns to need summarizing
+const conversationWindow = 200
+
+type testEnv struct {
+	llm     *llmtest.Server
+	backend *backendtest.Server
+	service *Service
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	logger := zap.NewNop()
+
+	env := &testEnv{
+		llm:     llmtest.NewServer(t),
+		backend: backendtest.NewServer(t),
+	}
+
+	llmClient := llm.NewClient("test-key", logger)
+	llmClient.SetAPIURL(env.llm.URL())
+	llmClient.SetCache(llm.NewCache(100, time.Minute))
+
+	registry, err := prompts.NewRegistry("", logger)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+
+	env.service = New(llmClient, clients.NewBackendClient(env.backend.URL(), logger), registry, generations.NewStore(10, logger), conversations.NewStore(10, time.Hour, conversationWindow, logger), logger)
+	return env
+}
+
+func TestCategorizeJobs(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply(`"graphic-design"`) })
+
+	jobs := []models.JobData{
+		{PublicID: "job-1", Description: "Design a logo"},
+		{PublicID: "job-2", Description: "Design a logo"},
+	}
+
+	var progress []int
+	usage := &llm.Usage{}
+	results, err := env.service.CategorizeJobs(context.Background(), CategorizeInput{
+		Jobs:     jobs,
+		Usage:    usage,
+		Progress: func(n int) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("CategorizeJobs() error = %v", err)
+	}
+	if len(results) != 2 || results[0].PublicID != "job-1" || results[1].Category != "graphic-design" {
+		t.Errorf("results = %+v", results)
+	}
+	if len(progress) != 2 || progress[1] != 2 {
+		t.Errorf("progress = %v", progress)
+	}
+
+	// The second job has the same prompt, so it is served from the cache
+	if totals := usage.Totals(); totals.LLMCalls != 1 || totals.CachedCalls != 1 {
+		t.Errorf("usage = %+v", totals)
+	}
+	if len(env.llm.Requests()) != 1 {
+		t.Errorf("expected one upstream call, got %d", len(env.llm.Requests()))
+	}
+
+	// Cancellation stops processing
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := env.service.CategorizeJobs(ctx, CategorizeInput{Jobs: jobs, NoCache: true}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: error = %v, want context.Canceled", err)
+	}
+
+	// Categories are cached once fetched, so a fresh service sees the failure
+	env = newTestEnv(t)
+	env.backend.Fail(backendtest.RouteCategories, http.StatusInternalServerError, 0)
+	if _, err := env.service.CategorizeJobs(context.Background(), CategorizeInput{Jobs: jobs}); ErrorCode(err) != models.ErrUpstreamError {
+		t.Errorf("categories unavailable: code = %q (%v)", ErrorCode(err), err)
+	}
+
+	// Preloaded categories skip the backend
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply("graphic-design") })
+	preloaded := []models.JobCategory{{Slug: "graphic-design", Description: "Logos and branding"}}
+	results, err = env.service.CategorizeJobs(context.Background(), CategorizeInput{Jobs: jobs, Categories: preloaded})
+	if err != nil || len(results) != 2 || results[0].Category != "graphic-design" {
+		t.Errorf("preloaded categories: results = %+v, error = %v", results, err)
+	}
+	if requests := env.backend.Requests(); len(requests) != 1 {
+		t.Errorf("expected only the failed categories request, got %v", requests)
+	}
+}
+
+func TestDescribeJobs(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply(`[{"description": "I need a **logo**.", "short_description": "A *logo*."}]`))
+
+	jobs := []models.JobDescribeRequest{{Title: "Logo", Description: "Design a logo", Category: "graphic-design"}}
+	descriptions, err := env.service.DescribeJobs(context.Background(), DescribeInput{Jobs: jobs, Format: models.FormatHTML})
+	if err != nil {
+		t.Fatalf("DescribeJobs() error = %v", err)
+	}
+	if len(descriptions) != 1 || descriptions[0].Description != "<p>I need a <strong>logo</strong>.</p>" || descriptions[0].ShortDescription != "A logo." {
+		t.Errorf("descriptions = %+v", descriptions)
+	}
+
+	// The jobs reach the prompt as JSON
+	if prompt := env.llm.Requests()[0].UserPrompt(); !strings.Contains(prompt, `"title": "Logo"`) {
+		t.Errorf("prompt does not contain the jobs as JSON:\n%s", prompt)
+	}
+
+	env.llm.Enqueue(llmtest.Reply("not json"))
+	if _, err := env.service.DescribeJobs(context.Background(), DescribeInput{Jobs: jobs}); ErrorCode(err) != models.ErrUpstreamError {
+		t.Errorf("unparseable reply: code = %q (%v)", ErrorCode(err), err)
+	}
+}
+
+func TestGenerateFromTemplate(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+
+	req := models.PromptGenerationRequest{
+		TemplateName: models.PromptTalentBio,
+		Data:         map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+	}
+
+	usage := &llm.Usage{}
+	data, err := env.service.GenerateFromTemplate(context.Background(), GenerateInput{Request: req, UserID: backendtest.TalentID, Usage: usage})
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+	if data.GenerationID == "" || data.TemplateVersion == "" || data.Metadata == nil || data.Metadata.Attempts != 1 {
+		t.Errorf("data = %+v", data)
+	}
+	if usage.Totals().LLMCalls != 1 {
+		t.Errorf("usage = %+v", usage.Totals())
+	}
+
+	tests := []struct {
+		name   string
+		in     GenerateInput
+		before func()
+		want   models.ErrorCodeEnum
+	}{
+		{
+			name: "unknown user",
+			in:   GenerateInput{Request: req, UserID: "ghost"},
+			want: models.ErrUserNotFound,
+		},
+		{
+			name: "invalid locale",
+			in: GenerateInput{Request: models.PromptGenerationRequest{
+				TemplateName: req.TemplateName, Data: req.Data, Locale: "xx-not-a-locale",
+			}, UserID: backendtest.TalentID},
+			want: models.ErrInvalidRequest,
+		},
+		{
+			name: "invalid data",
+			in: GenerateInput{Request: models.PromptGenerationRequest{
+				TemplateName: req.TemplateName, Data: map[string]interface{}{"profile": map[string]interface{}{}},
+			}, UserID: backendtest.TalentID},
+			want: models.ErrTemplateInvalid,
+		},
+		{
+			name:   "rate limited",
+			in:     GenerateInput{Request: req, UserID: backendtest.TalentID},
+			before: func() { env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.RateLimited() }) },
+			want:   models.ErrUpstreamRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+			_, err := env.service.GenerateFromTemplate(context.Background(), tt.in)
+			if ErrorCode(err) != tt.want {
+				t.Errorf("code = %q (%v), want %q", ErrorCode(err), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefine(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Python services."))
+
+	generated, err := env.service.GenerateFromTemplate(context.Background(), GenerateInput{
+		Request: models.PromptGenerationRequest{
+			TemplateName: models.PromptTalentBio,
+			Data:         map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+			Format:       models.FormatHTML,
+		},
+		UserID: backendtest.TalentID,
+	})
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+
+	usage := &llm.Usage{}
+	data, err := env.service.Refine(context.Background(), RefineInput{
+		Request: models.RefineRequest{GenerationID: generated.GenerationID, Instruction: "Emphasize Python"},
+		Usage:   usage,
+	})
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	// The revision is returned in the generation's format
+	if data.Completion != "<p>I build <strong>reliable</strong> Python services.</p>" || data.Format != models.FormatHTML ||
+		data.GenerationID == generated.GenerationID || data.TemplateVersion != generated.TemplateVersion {
+		t.Errorf("data = %+v", data)
+	}
+	if usage.Totals().LLMCalls != 1 {
+		t.Errorf("usage = %+v", usage.Totals())
+	}
+
+	// The stored HTML is edited as markdown, for the generation's user
+	refine := env.llm.Requests()[1]
+	if prompt := refine.UserPrompt(); !strings.Contains(prompt, "I build **reliable** Go services.") || !strings.Contains(prompt, "Emphasize Python") {
+		t.Errorf("unexpected refinement prompt:\n%s", prompt)
+	}
+	if got := env.backend.Requests(); len(got) != 2 {
+		t.Errorf("expected the generation's user to be fetched again, backend requests = %v", got)
+	}
+
+	// Output over the template's limit is re-prompted, like a generation
+	env.llm.Enqueue(llmtest.Reply(strings.Repeat("I build reliable Python services. ", 20)))
+	env.llm.Enqueue(llmtest.Reply("I build Python services."))
+	data, err = env.service.Refine(context.Background(), RefineInput{Request: models.RefineRequest{
+		TemplateName: models.PromptTalentBio, Text: "I build Go services.", Instruction: "Emphasize Python", Length: models.LengthShort,
+	}})
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	if data.Completion != "I build Python services." || data.Metadata.Attempts != 2 {
+		t.Errorf("data = %+v", data)
+	}
+
+	// The re-prompt sends the answer back to be shortened
+	retry := env.llm.Requests()[3]
+	if last := retry.Messages[len(retry.Messages)-2]; last.Role != llm.RoleAssistant || !strings.HasPrefix(last.Content, "I build reliable Python services.") {
+		t.Errorf("expected the first answer as the assistant turn, got %+v", retry.Messages)
+	}
+	if prompt := retry.UserPrompt(); !strings.Contains(prompt, "at most 300 characters") {
+		t.Errorf("unexpected re-prompt instruction: %q", prompt)
+	}
+
+	tests := []struct {
+		name string
+		req  models.RefineRequest
+		want models.ErrorCodeEnum
+	}{
+		{"unknown generation", models.RefineRequest{GenerationID: "gen_missing", Instruction: "shorter"}, models.ErrNotFound},
+		{"template mismatch", models.RefineRequest{GenerationID: generated.GenerationID, TemplateName: models.PromptClientAboutUs, Instruction: "shorter"}, models.ErrInvalidRequest},
+		{"free-form completion", models.RefineRequest{TemplateName: models.PromptNone, Text: "Hello", Instruction: "shorter"}, models.ErrTemplateInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.service.Refine(context.Background(), RefineInput{Request: tt.req})
+			if ErrorCode(err) != tt.want {
+				t.Errorf("code = %q (%v), want %q", ErrorCode(err), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefineKeepsGenerationStyle(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("Je développe des services Go fiables pour les équipes produit."))
+	env.llm.Enqueue(llmtest.Reply("Je développe des services Python fiables pour les équipes produit."))
+
+	generated, err := env.service.GenerateFromTemplate(context.Background(), GenerateInput{
+		Owner: "DKL_WEB",
+		Request: models.PromptGenerationRequest{
+			TemplateName: models.PromptTalentBio,
+			Data:         map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+			Length:       models.LengthShort,
+			Locale:       "fr",
+		},
+		UserID: backendtest.TalentID,
+	})
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+
+	// Without length and locale, the refinement keeps the generation's
+	data, err := env.service.Refine(context.Background(), RefineInput{
+		Owner:   "DKL_WEB",
+		Request: models.RefineRequest{GenerationID: generated.GenerationID, Instruction: "Mets Python en avant"},
+	})
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	if data.Locale != "fr" || data.Format != generated.Format {
+		t.Errorf("data = %+v", data)
+	}
+
+	refine := env.llm.Requests()[1]
+	if prompt := refine.UserPrompt(); !strings.Contains(prompt, "Under 300 characters") {
+		t.Errorf("expected the short bio limit in the refinement prompt:\n%s", prompt)
+	}
+	french := false
+	for _, m := range refine.Messages {
+		french = french || (m.Role == "system" && strings.Contains(m.Content, "French"))
+	}
+	if !french {
+		t.Errorf("expected the French instruction in the system prompt, got %+v", refine.Messages)
+	}
+}
+
+func TestGenerateFromTemplateFormatLength(t *testing.T) {
+	env := newTestEnv(t)
+	// Under the short bio limit as written, over it once markdown escapes the brackets
+	bio := strings.Repeat("I ship Go [v2] APIs. ", 14)
+	env.llm.Respond(func(llmtest.Request) llmtest.Response { return llmtest.Reply(bio) })
+
+	req := models.PromptGenerationRequest{
+		TemplateName: models.PromptTalentBio,
+		Data:         map[string]interface{}{"profile": map[string]interface{}{"name": "Ada", "title": "Engineer"}},
+		Length:       models.LengthShort,
+	}
+
+	// Without a format the completion is returned as written
+	data, err := env.service.GenerateFromTemplate(context.Background(), GenerateInput{Request: req, UserID: backendtest.TalentID})
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+	if data.Completion != strings.TrimSpace(bio) || data.Format != models.FormatMarkdown {
+		t.Errorf("expected the completion as written, got %q (%s)", data.Completion, data.Format)
+	}
+
+	req.Format = models.FormatMarkdown
+	data, err = env.service.GenerateFromTemplate(context.Background(), GenerateInput{Request: req, UserID: backendtest.TalentID})
+	if err != nil {
+		t.Fatalf("GenerateFromTemplate() error = %v", err)
+	}
+	if n := len([]rune(data.Completion)); n > 300 || !strings.Contains(data.Completion, `\[v2\]`) {
+		t.Errorf("expected escaped markdown within 300 characters, got %d: %q", n, data.Completion)
+	}
+	if violations := data.Metadata.ConstraintViolations; len(violations) != 1 || !violations[0].Fixed {
+		t.Errorf("expected the trim to be reported, got %+v", violations)
+	}
+}
+
+func TestCompleteLanguageRetry(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("We are looking for a developer to join our team and help us build the platform."))
+	env.llm.Enqueue(llmtest.Reply("Nous recherchons un développeur pour rejoindre notre équipe et construire la plateforme avec nous."))
+
+	data, err := env.service.Complete(context.Background(), CompleteInput{Text: "Write a job post", Locale: "fr"})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !strings.HasPrefix(data.Completion, "Nous recherchons") {
+		t.Errorf("expected the French retry, got %q", data.Completion)
+	}
+
+	// The English answer is sent back to be rewritten in French
+	retry := env.llm.Requests()[1]
+	if last := retry.Messages[len(retry.Messages)-2]; last.Role != llm.RoleAssistant || !strings.HasPrefix(last.Content, "We are looking") {
+		t.Errorf("expected the first answer as the assistant turn, got %+v", retry.Messages)
+	}
+	if prompt := retry.UserPrompt(); !strings.Contains(prompt, "French") {
+		t.Errorf("unexpected retry instruction: %q", prompt)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("**Go** and *Rust*"))
+
+	data, err := env.service.Complete(context.Background(), CompleteInput{Text: "Name two languages", Format: models.FormatText})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if data.Completion != "Go and Rust" || data.GenerationID == "" || data.Locale == "" {
+		t.Errorf("data = %+v", data)
+	}
+	if len(env.backend.Requests()) != 0 {
+		t.Error("expected no user to be fetched without a user ID")
+	}
+
+	// A caller's system prompt and parameters are sent ahead of the locale instruction
+	env.llm.Enqueue(llmtest.Reply("Bonjour"))
+	maxTokens := 50
+	if _, err := env.service.Complete(context.Background(), CompleteInput{
+		Text: "Say hello", Locale: "fr", System: "Be brief.", Params: prompts.Params{MaxTokens: &maxTokens},
+	}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	req := env.llm.Requests()[1]
+	var system string
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system += m.Content + "\n"
+		}
+	}
+	if req.MaxTokens != 50 || !strings.Contains(system, "Be brief.\n\nWrite your entire response in French") {
+		t.Errorf("request = %+v", req)
+	}
+
+	if ErrorCode(errors.New("boom")) != models.ErrInternal {
+		t.Error("expected errors from outside the service to be internal")
+	}
+}
+
+func TestConversation(t *testing.T) {
+	env := newTestEnv(t)
+	env.llm.Enqueue(llmtest.Reply("I build **reliable** Go services."))
+	env.llm.Enqueue(llmtest.Reply("I build Go services."))
+
+	conv, err := env.service.CreateConversation(context.Background(), CreateConversationInput{Owner: "DKL_WEB", UserID: backendtest.TalentID})
+	if err != nil {
+		t.Fatalf("CreateConversation() error = %v", err)
+	}
+
+	if _, err := env.service.SendMessage(context.Background(), SendMessageInput{Owner: "DKL_WEB", ConversationID: conv.ID, Text: "Write a bio"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	usage := &llm.Usage{}
+	reply, err := env.service.SendMessage(context.Background(), SendMessageInput{
+		Owner: "DKL_WEB", ConversationID: conv.ID, Text: "Make it shorter", Format: models.FormatText, Usage: usage,
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if reply.Completion != "I build Go services." || reply.Message.Role != models.ConversationRoleAssistant || reply.GenerationID == "" || reply.Summarized {
+		t.Errorf("reply = %+v", reply)
+	}
+	if usage.Totals().LLMCalls != 1 {
+		t.Errorf("usage = %+v", usage.Totals())
+	}
+
+	// The second turn is sent with the first one's history
+	requests := env.llm.Requests()
+	var contents []string
+	for _, m := range requests[1].Messages {
+		if m.Role == llm.RoleUser || m.Role == llm.RoleAssistant {
+			contents = append(contents, m.Content)
+		}
+	}
+	want := []string{"Write a bio", "I build **reliable** Go services.", "Make it shorter"}
+	if strings.Join(contents, "|") != strings.Join(want, "|") {
+		t.Errorf("turns sent = %q, want %q", contents, want)
+	}
+
+	// The stored history keeps replies as written, not as formatted
+	stored, err := env.service.GetConversation("DKL_WEB", conv.ID)
+	if err != nil {
+		t.Fatalf("GetConversation() error = %v", err)
+	}
+	if len(stored.Messages) != 4 || stored.Messages[3].Content != "I build Go services." {
+		t.Errorf("messages = %+v", stored.Messages)
+	}
+
+	// Conversations belong to the service that created them
+	if _, err := env.service.GetConversation("DKL_OTHER", conv.ID); ErrorCode(err) != models.ErrNotFound {
+		t.Errorf("GetConversation() by another service error = %v", err)
+	}
+	if _, err := env.service.DeleteConversation("DKL_WEB", conv.ID); err != nil {
+		t.Fatalf("DeleteConversation() error = %v", err)
+	}
+	_, err = env.service.SendMessage(context.Background(), SendMessageInput{Owner: "DKL_WEB", ConversationID: conv.ID, Text: "Hello"})
+	if ErrorCode(err) != models.ErrNotFound {
+		t.Errorf("SendMessage() after delete error = %v", err)
+	}
+
+	_, err = env.service.CreateConversation(context.Background(), CreateConversationInput{Owner: "DKL_WEB", UserID: "ghost"})
+	if ErrorCode(err) != models.ErrUserNotFound {
+		t.Errorf("CreateConversation() for an unknown user error = %v", err)
+	}
+}
+
+func TestConversationSummarizesOlderTurns(t *testing.T) {
+	env := newTestEnv(t)
+	long := strings.Repeat("word ", 100)
+	env.llm.Respond(func(req llmtest.Request) llmtest.Response {
+		if strings.HasPrefix(req.UserPrompt(), summarizePrompt) {
+			return llmtest.Reply("The user wants a bio.")
+		}
+		return llmtest.Reply(long)
+	})
+
+	conv, err := env.service.CreateConversation(context.Background(), CreateConversationInput{Owner: "DKL_WEB"})
+	if err != nil {
+		t.Fatalf("CreateConversation() error = %v", err)
+	}
+
+	var reply *models.ConversationReply
+	for i := 0; i < 3; i++ {
+		reply, err = env.service.SendMessage(context.Background(), SendMessageInput{Owner: "DKL_WEB", ConversationID: conv.ID, Text: "Another draft please"})
+		if err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+	if !reply.Summarized {
+		t.Error("expected older turns to be summarized")
+	}
+
+	stored, _ := env.service.GetConversation("DKL_WEB", conv.ID)
+	if stored.Summary != "The user wants a bio." || stored.SummarizedMessages == 0 || len(stored.Messages)+stored.SummarizedMessages != 6 {
+		t.Errorf("conversation = %+v", stored)
+	}
+
+	// The summary is sent in place of the folded messages
+	requests := env.llm.Requests()
+	last := requests[len(requests)-1]
+	found := false
+	for _, m := range last.Messages {
+		if m.Role == "system" && strings.Contains(m.Content, "The user wants a bio.") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the summary in the last request, got %+v", last.Messages)
+	}
+}
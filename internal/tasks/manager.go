@@ -0,0 +1,308 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound is returned when a task ID is unknown, expired, or owned by another service
+	ErrNotFound = errors.New("tasks: not found")
+	// ErrQueueFull is returned when the worker queue cannot accept more tasks
+	ErrQueueFull = errors.New("tasks: queue full")
+	// ErrFinished is returned when cancelling a task that has already finished
+	ErrFinished = errors.New("tasks: already finished")
+)
+
+// FinishFunc is called after a task with a callback URL completes or fails
+type FinishFunc func(owner, callbackURL string, info models.TaskInfo)
+
+// Func is the unit of work executed by a task. It should report progress through
+// the given callback and stop early when ctx is cancelled.
+type Func func(ctx context.Context, progress func(completed int)) (interface{}, error)
+
+// task is the internal, mutable task state
+type task struct {
+	info        models.TaskInfo
+	owner       string
+	callbackURL string
+	fn          Func
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// Manager runs tasks on a bounded pool of in-process workers
+type Manager struct {
+	mu      sync.RWMutex
+	tasks   map[string]*task
+	queue   chan *task
+	workers int
+	ttl     time.Duration
+	logger  *zap.Logger
+
+	onFinish FinishFunc
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewManager creates a new task manager. Finished tasks are kept for ttl before
+// they expire.
+func NewManager(workers, queueSize int, ttl time.Duration, logger *zap.Logger) *Manager {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	return &Manager{
+		tasks:   make(map[string]*task),
+		queue:   make(chan *task, queueSize),
+		workers: workers,
+		ttl:     ttl,
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+}
+
+// OnFinish registers a hook invoked when a task with a callback URL completes or fails.
+// It must be called before Start.
+func (m *Manager) OnFinish(fn FinishFunc) {
+	m.onFinish = fn
+}
+
+// Start launches the workers and the expiry janitor
+func (m *Manager) Start() {
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+
+	m.wg.Add(1)
+	go m.janitor()
+
+	m.logger.Info("Task manager started",
+		zap.Int("workers", m.workers),
+		zap.Int("queue_size", cap(m.queue)),
+		zap.Duration("ttl", m.ttl),
+	)
+}
+
+// Stop cancels running tasks and waits for the workers to exit
+func (m *Manager) Stop() {
+	close(m.stop)
+
+	m.mu.Lock()
+	for _, t := range m.tasks {
+		t.cancel()
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// Submit queues a new task owned by the given service and returns its initial state.
+// If callbackURL is set, the OnFinish hook is called once the task completes or fails.
+func (m *Manager) Submit(owner string, kind models.TaskKindEnum, total int, callbackURL string, fn Func) (models.TaskInfo, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+
+	t := &task{
+		info: models.TaskInfo{
+			ID:        newID(),
+			Kind:      kind,
+			Status:    models.TaskQueued,
+			Progress:  models.TaskProgress{Total: total},
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		owner:       owner,
+		callbackURL: callbackURL,
+		fn:          fn,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	info := t.info
+
+	m.mu.Lock()
+	select {
+	case m.queue <- t:
+		m.tasks[info.ID] = t
+		m.mu.Unlock()
+	default:
+		m.mu.Unlock()
+		cancel()
+		return models.TaskInfo{}, ErrQueueFull
+	}
+
+	m.logger.Info("Task queued",
+		zap.String("task_id", info.ID),
+		zap.String("kind", string(kind)),
+		zap.Int("total", total),
+	)
+
+	return info, nil
+}
+
+// Get returns the current state of a task owned by the given service
+func (m *Manager) Get(owner, id string) (models.TaskInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.owner != owner {
+		return models.TaskInfo{}, ErrNotFound
+	}
+	return t.info, nil
+}
+
+// Cancel stops a queued or running task owned by the given service
+func (m *Manager) Cancel(owner, id string) (models.TaskInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.owner != owner {
+		return models.TaskInfo{}, ErrNotFound
+	}
+	if t.info.Status.Finished() {
+		return t.info, ErrFinished
+	}
+
+	m.finish(t, models.TaskCancelled, nil, "cancelled by client")
+
+	m.logger.Info("Task cancelled", zap.String("task_id", id))
+
+	return t.info, nil
+}
+
+// worker pulls tasks off the queue until the manager stops
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case t := <-m.queue:
+			m.run(t)
+		}
+	}
+}
+
+// run executes a single task and records its outcome
+func (m *Manager) run(t *task) {
+	m.mu.Lock()
+	if t.info.Status != models.TaskQueued {
+		// Cancelled while waiting in the queue
+		m.mu.Unlock()
+		return
+	}
+	t.info.Status = models.TaskRunning
+	t.info.UpdatedAt = time.Now()
+	m.mu.Unlock()
+
+	progress := func(completed int) {
+		m.mu.Lock()
+		if !t.info.Status.Finished() {
+			t.info.Progress.Completed = completed
+			t.info.UpdatedAt = time.Now()
+		}
+		m.mu.Unlock()
+	}
+
+	result, err := t.fn(t.ctx, progress)
+
+	m.mu.Lock()
+	if t.info.Status.Finished() {
+		// Cancelled while running; discard the result
+		m.mu.Unlock()
+		return
+	}
+
+	if err != nil {
+		m.logger.Error("Task failed", zap.String("task_id", t.info.ID), zap.Error(err))
+		m.finish(t, models.TaskFailed, nil, err.Error())
+	} else {
+		t.info.Progress.Completed = t.info.Progress.Total
+		m.finish(t, models.TaskCompleted, result, "")
+
+		m.logger.Info("Task completed",
+			zap.String("task_id", t.info.ID),
+			zap.String("kind", string(t.info.Kind)),
+			zap.Duration("duration", t.info.UpdatedAt.Sub(t.info.CreatedAt)),
+		)
+	}
+	info := t.info
+	m.mu.Unlock()
+
+	if t.callbackURL != "" && m.onFinish != nil {
+		m.onFinish(t.owner, t.callbackURL, info)
+	}
+}
+
+// finish moves a task into a terminal state. Callers must hold the write lock.
+func (m *Manager) finish(t *task, status models.TaskStatusEnum, result interface{}, errMsg string) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	t.info.Status = status
+	t.info.Result = result
+	t.info.UpdatedAt = now
+	t.info.ExpiresAt = &expiresAt
+	if errMsg != "" {
+		t.info.Error = &errMsg
+	}
+	t.cancel()
+}
+
+// janitor periodically removes expired tasks
+func (m *Manager) janitor() {
+	defer m.wg.Done()
+
+	interval := m.ttl / 2
+	if interval <= 0 || interval > time.Minute {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			m.purgeExpired(now)
+		}
+	}
+}
+
+// purgeExpired removes finished tasks whose expiry has passed
+func (m *Manager) purgeExpired(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, t := range m.tasks {
+		if t.info.ExpiresAt != nil && now.After(*t.info.ExpiresAt) {
+			delete(m.tasks, id)
+			m.logger.Debug("Task expired", zap.String("task_id", id))
+		}
+	}
+}
+
+// newID generates a random task ID
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "task_" + hex.EncodeToString(b)
+}
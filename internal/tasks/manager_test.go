@@ -0,0 +1,173 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// waitForStatus polls a task until it reaches a finished state or the deadline passes
+func waitForStatus(t *testing.T, m *Manager, owner, id string) models.TaskInfo {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		info, err := m.Get(owner, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Status.Finished() {
+			return info
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("task %s did not finish in time", id)
+	return models.TaskInfo{}
+}
+
+func TestManagerRunsTaskToCompletion(t *testing.T) {
+	m := NewManager(2, 10, time.Minute, zap.NewNop())
+	m.Start()
+	defer m.Stop()
+
+	info, err := m.Submit("DKL001", models.TaskJobCategorize, 3, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		for i := 1; i <= 3; i++ {
+			progress(i)
+		}
+		return []string{"a", "b", "c"}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Status != models.TaskQueued {
+		t.Errorf("expected queued status, got %q", info.Status)
+	}
+
+	final := waitForStatus(t, m, "DKL001", info.ID)
+	if final.Status != models.TaskCompleted {
+		t.Errorf("expected completed status, got %q", final.Status)
+	}
+
+	if final.Progress.Completed != 3 || final.Progress.Total != 3 {
+		t.Errorf("unexpected progress: %+v", final.Progress)
+	}
+
+	if final.ExpiresAt == nil {
+		t.Error("expected expiry to be set on finished task")
+	}
+}
+
+func TestManagerRecordsFailure(t *testing.T) {
+	m := NewManager(1, 10, time.Minute, zap.NewNop())
+	m.Start()
+	defer m.Stop()
+
+	info, _ := m.Submit("DKL001", models.TaskJobDescribe, 1, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	final := waitForStatus(t, m, "DKL001", info.ID)
+	if final.Status != models.TaskFailed {
+		t.Errorf("expected failed status, got %q", final.Status)
+	}
+
+	if final.Error == nil || *final.Error != "boom" {
+		t.Errorf("expected error message 'boom', got %v", final.Error)
+	}
+}
+
+func TestManagerCancelRunningTask(t *testing.T) {
+	m := NewManager(1, 10, time.Minute, zap.NewNop())
+	m.Start()
+	defer m.Stop()
+
+	started := make(chan struct{})
+	info, _ := m.Submit("DKL001", models.TaskJobCategorize, 1, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	<-started
+
+	if _, err := m.Cancel("DKL002", info.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another service, got %v", err)
+	}
+
+	cancelled, err := m.Cancel("DKL001", info.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cancelled.Status != models.TaskCancelled {
+		t.Errorf("expected cancelled status, got %q", cancelled.Status)
+	}
+
+	if _, err := m.Cancel("DKL001", info.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("expected ErrFinished on second cancel, got %v", err)
+	}
+}
+
+func TestManagerQueueFull(t *testing.T) {
+	// Workers are not started, so the queue never drains
+	m := NewManager(1, 1, time.Minute, zap.NewNop())
+
+	noop := func(ctx context.Context, progress func(int)) (interface{}, error) { return nil, nil }
+
+	if _, err := m.Submit("DKL001", models.TaskJobDescribe, 1, "", noop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Submit("DKL001", models.TaskJobDescribe, 1, "", noop); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestManagerPurgesExpiredTasks(t *testing.T) {
+	m := NewManager(1, 10, time.Minute, zap.NewNop())
+	m.Start()
+	defer m.Stop()
+
+	info, _ := m.Submit("DKL001", models.TaskJobDescribe, 1, "", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return "done", nil
+	})
+	waitForStatus(t, m, "DKL001", info.ID)
+
+	m.purgeExpired(time.Now().Add(2 * time.Minute))
+
+	if _, err := m.Get("DKL001", info.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired task to be purged, got %v", err)
+	}
+}
+
+func TestManagerCallsOnFinishForCallbacks(t *testing.T) {
+	m := NewManager(1, 10, time.Minute, zap.NewNop())
+
+	finished := make(chan models.TaskInfo, 1)
+	m.OnFinish(func(owner, callbackURL string, info models.TaskInfo) {
+		if owner != "DKL001" || callbackURL != "https://hooks.dokoola.com/done" {
+			t.Errorf("unexpected owner %q or callback %q", owner, callbackURL)
+		}
+		finished <- info
+	})
+	m.Start()
+	defer m.Stop()
+
+	info, _ := m.Submit("DKL001", models.TaskJobDescribe, 1, "https://hooks.dokoola.com/done", func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return "done", nil
+	})
+
+	select {
+	case got := <-finished:
+		if got.ID != info.ID || got.Status != models.TaskCompleted {
+			t.Errorf("unexpected finished task: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnFinish was not called")
+	}
+}
@@ -0,0 +1,265 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of "<timestamp>.<body>"
+	SignatureHeader = "X-Dokoola-Signature"
+	// TimestampHeader carries the unix timestamp used in the signature
+	TimestampHeader = "X-Dokoola-Timestamp"
+	// EventHeader carries the webhook event name
+	EventHeader = "X-Dokoola-Event"
+
+	maxDeadLetters = 1000
+	requestTimeout = 10 * time.Second
+)
+
+var (
+	// ErrCallbackNotAllowed is returned when a callback URL's host is not registered for the service
+	ErrCallbackNotAllowed = errors.New("webhooks: callback host not allowed")
+)
+
+// Dispatcher delivers signed task results to registered callback URLs,
+// retrying with exponential backoff and keeping failed deliveries in a dead-letter list
+type Dispatcher struct {
+	services    map[string]config.ServiceConfig
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	logger      *zap.Logger
+
+	mu          sync.RWMutex
+	deadLetters []models.WebhookDeadLetter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(services map[string]config.ServiceConfig, maxAttempts int, backoff time.Duration, logger *zap.Logger) *Dispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Dispatcher{
+		services: services,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			// Redirects are not followed, so deliveries only reach registered hosts
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// ValidateURL checks that a callback URL is well-formed and its host is registered for the service
+func (d *Dispatcher) ValidateURL(serviceKey, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid callback_url: %q", rawURL)
+	}
+
+	service, ok := d.services[serviceKey]
+	if !ok {
+		return ErrCallbackNotAllowed
+	}
+
+	for _, host := range service.CallbackHosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrCallbackNotAllowed, u.Host)
+}
+
+// NotifyTask delivers a finished task to its callback URL in the background
+func (d *Dispatcher) NotifyTask(serviceKey, callbackURL string, info models.TaskInfo) {
+	event := "task." + string(info.Status)
+
+	body, err := json.Marshal(models.WebhookPayload{
+		Event: event,
+		Task:  info,
+	})
+	if err != nil {
+		d.logger.Error("Failed to marshal webhook payload", zap.String("task_id", info.ID), zap.Error(err))
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.deliver(serviceKey, callbackURL, event, info.ID, body)
+	}()
+}
+
+// DeadLetters returns the deliveries that exhausted their retries, newest first
+func (d *Dispatcher) DeadLetters() []models.WebhookDeadLetter {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]models.WebhookDeadLetter, len(d.deadLetters))
+	for i, dl := range d.deadLetters {
+		result[len(d.deadLetters)-1-i] = dl
+	}
+	return result
+}
+
+// Stop aborts pending retries and waits for in-flight deliveries to finish
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// deliver posts the payload, retrying with exponential backoff until it succeeds
+// or attempts run out, in which case it is dead-lettered
+func (d *Dispatcher) deliver(serviceKey, callbackURL, event, taskID string, body []byte) {
+	secret := d.secretFor(serviceKey)
+
+	var lastErr error
+	lastStatus := 0
+
+	for attempt := 0; attempt < d.maxAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := d.backoff * time.Duration(1<<(attempt-1))
+			select {
+			case <-d.ctx.Done():
+				lastErr = d.ctx.Err()
+				d.deadLetter(serviceKey, callbackURL, taskID, attempt, lastStatus, lastErr, body)
+				return
+			case <-time.After(backoff):
+			}
+		}
+
+		lastStatus, lastErr = d.post(callbackURL, event, secret, body)
+		if lastErr == nil {
+			d.logger.Info("Webhook delivered",
+				zap.String("task_id", taskID),
+				zap.String("url", callbackURL),
+				zap.Int("attempt", attempt+1),
+			)
+			return
+		}
+
+		d.logger.Warn("Webhook delivery failed",
+			zap.String("task_id", taskID),
+			zap.String("url", callbackURL),
+			zap.Int("attempt", attempt+1),
+			zap.Error(lastErr),
+		)
+	}
+
+	d.deadLetter(serviceKey, callbackURL, taskID, d.maxAttempts, lastStatus, lastErr, body)
+}
+
+// post sends a single signed delivery attempt
+func (d *Dispatcher) post(callbackURL, event, secret string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return resp.StatusCode, fmt.Errorf("callback redirected with status %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// deadLetter records a delivery that could not be completed
+func (d *Dispatcher) deadLetter(serviceKey, callbackURL, taskID string, attempts, lastStatus int, lastErr error, body []byte) {
+	errMsg := ""
+	if lastErr != nil {
+		errMsg = lastErr.Error()
+	}
+
+	d.logger.Error("Webhook moved to dead-letter list",
+		zap.String("task_id", taskID),
+		zap.String("url", callbackURL),
+		zap.Int("attempts", attempts),
+		zap.String("error", errMsg),
+	)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deadLetters = append(d.deadLetters, models.WebhookDeadLetter{
+		TaskID:     taskID,
+		ServiceKey: serviceKey,
+		URL:        callbackURL,
+		Attempts:   attempts,
+		LastStatus: lastStatus,
+		LastError:  errMsg,
+		FailedAt:   time.Now(),
+		Payload:    json.RawMessage(body),
+	})
+	if len(d.deadLetters) > maxDeadLetters {
+		d.deadLetters = d.deadLetters[len(d.deadLetters)-maxDeadLetters:]
+	}
+}
+
+// secretFor returns the signing secret for a service
+func (d *Dispatcher) secretFor(serviceKey string) string {
+	service := d.services[serviceKey]
+	if service.CallbackSecret != "" {
+		return service.CallbackSecret
+	}
+	return service.SecretHash
+}
+
+// Sign computes the hex HMAC-SHA256 of "<timestamp>.<body>" with the given secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
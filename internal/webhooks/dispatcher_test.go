@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dokoola/llm-go/internal/config"
+	"github.com/dokoola/llm-go/internal/models"
+	"go.uber.org/zap"
+)
+
+func newTestDispatcher(callbackHost string, maxAttempts int) *Dispatcher {
+	services := map[string]config.ServiceConfig{
+		"DKL001": {
+			ClientName:     "BACKEND_CLIENT",
+			SecretHash:     "secret-hash",
+			CallbackHosts:  []string{callbackHost},
+			CallbackSecret: "callback-secret",
+		},
+	}
+	return NewDispatcher(services, maxAttempts, time.Millisecond, zap.NewNop())
+}
+
+func TestDispatcherValidateURL(t *testing.T) {
+	d := newTestDispatcher("hooks.dokoola.com", 1)
+
+	tests := []struct {
+		name       string
+		serviceKey string
+		url        string
+		wantErr    bool
+	}{
+		{name: "registered host", serviceKey: "DKL001", url: "https://hooks.dokoola.com/llm/done", wantErr: false},
+		{name: "unregistered host", serviceKey: "DKL001", url: "https://evil.example.com/hook", wantErr: true},
+		{name: "unknown service", serviceKey: "DKL999", url: "https://hooks.dokoola.com/llm/done", wantErr: true},
+		{name: "bad scheme", serviceKey: "DKL001", url: "ftp://hooks.dokoola.com/x", wantErr: true},
+		{name: "not a url", serviceKey: "DKL001", url: "::nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.ValidateURL(tt.serviceKey, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := d.ValidateURL("DKL001", "https://evil.example.com/hook"); !errors.Is(err, ErrCallbackNotAllowed) {
+		t.Errorf("expected ErrCallbackNotAllowed, got %v", err)
+	}
+}
+
+func TestDispatcherDeliversSignedPayload(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	d := newTestDispatcher(u.Host, 3)
+	defer d.Stop()
+
+	d.NotifyTask("DKL001", server.URL+"/done", models.TaskInfo{ID: "task_1", Status: models.TaskCompleted})
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		expected := "sha256=" + Sign("callback-secret", r.Header.Get(TimestampHeader), body)
+		if r.Header.Get(SignatureHeader) != expected {
+			t.Errorf("expected signature %q, got %q", expected, r.Header.Get(SignatureHeader))
+		}
+
+		if r.Header.Get(EventHeader) != "task.completed" {
+			t.Errorf("expected event task.completed, got %q", r.Header.Get(EventHeader))
+		}
+
+		var payload models.WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("failed to unmarshal payload: %v", err)
+		}
+		if payload.Task.ID != "task_1" {
+			t.Errorf("expected task_1, got %q", payload.Task.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestDispatcherDeadLettersAfterRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	d := newTestDispatcher(u.Host, 3)
+	defer d.Stop()
+
+	d.NotifyTask("DKL001", server.URL, models.TaskInfo{ID: "task_2", Status: models.TaskFailed})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(d.DeadLetters()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+
+	deadLetters := d.DeadLetters()
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(deadLetters))
+	}
+
+	if deadLetters[0].TaskID != "task_2" || deadLetters[0].LastStatus != http.StatusInternalServerError {
+		t.Errorf("unexpected dead letter: %+v", deadLetters[0])
+	}
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	var redirected int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&redirected, 1)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	d := newTestDispatcher(u.Host, 1)
+	defer d.Stop()
+
+	d.NotifyTask("DKL001", server.URL, models.TaskInfo{ID: "task_3", Status: models.TaskCompleted})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(d.DeadLetters()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	deadLetters := d.DeadLetters()
+	if len(deadLetters) != 1 || deadLetters[0].LastStatus != http.StatusTemporaryRedirect {
+		t.Fatalf("expected the redirect to be dead-lettered, got %+v", deadLetters)
+	}
+	if got := atomic.LoadInt32(&redirected); got != 0 {
+		t.Errorf("expected the redirect not to be followed, target got %d requests", got)
+	}
+}